@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+type AttachCommand struct {
+	*Cli
+}
+
+func (attachCmd *AttachCommand) Name() string {
+	return "attach"
+}
+
+func (attachCmd *AttachCommand) Description() string {
+	return "Keep encrypted files (PDFs, images, diagrams) next to notes"
+}
+
+func (attachCmd *AttachCommand) Run(args []string) error {
+	if len(args) < 1 {
+		attachCmd.Help()
+		return errors.New("missing arguments")
+	}
+	cmd := args[0]
+	attachArgs := args[1:]
+	switch cmd {
+	case "add", "list", "rm", "remove":
+		resolved, err := attachCmd.resolveIds(attachArgs, 1)
+		if err != nil {
+			return err
+		}
+		attachArgs = resolved
+	case "get":
+		// -o may come first, so resolve the note after parsing flags
+		expanded, err := attachCmd.expandTitles(attachArgs)
+		if err != nil {
+			return err
+		}
+		attachArgs = expanded
+	}
+	switch cmd {
+	case "add":
+		if len(attachArgs) < 2 {
+			return errors.New("usage: attach add <note-id> <file>")
+		}
+		data, err := os.ReadFile(attachArgs[1])
+		if err != nil {
+			return err
+		}
+		attachment, err := attachCmd.store.Attach(attachArgs[0], attachArgs[1], data, attachCmd.username, attachCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Attached %s (%d bytes)\n", attachment.Name, attachment.Size)
+
+	case "list":
+		if len(attachArgs) < 1 {
+			return errors.New("usage: attach list <note-id>")
+		}
+		return attachCmd.printList(attachArgs[0])
+
+	case "get":
+		flagSet := newFlagSet("attach get")
+		output := flagSet.String("o", "", "Output path (default: attachment name, - for stdout)")
+		positional, err := parseFlags(flagSet, attachArgs)
+		if err != nil {
+			return err
+		}
+		if len(positional) < 2 {
+			return errors.New("usage: attach get <note-id> <name> [-o path]")
+		}
+		if positional[0], err = attachCmd.store.Resolve(positional[0], attachCmd.username, attachCmd.keyProvider); err != nil {
+			return err
+		}
+		data, err := attachCmd.store.Attachment(positional[0], positional[1], attachCmd.username, attachCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		if *output == "-" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if *output == "" {
+			*output = filepath.Base(positional[1])
+		}
+		// Never overwrite an existing file with decrypted content
+		outputFS, err := os.OpenFile(*output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := outputFS.Write(data); err != nil {
+			outputFS.Close()
+			return err
+		}
+		if err := outputFS.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Saved %s\n", *output)
+
+	case "rm", "remove":
+		if len(attachArgs) < 2 {
+			return errors.New("usage: attach rm <note-id> <name>")
+		}
+		return attachCmd.store.Detach(attachArgs[0], attachArgs[1], attachCmd.username, attachCmd.keyProvider)
+
+	default:
+		return fmt.Errorf("unknown subcommand: %s", cmd)
+	}
+	return nil
+}
+
+func (attachCmd *AttachCommand) printList(noteId string) error {
+	noteData, err := attachCmd.store.Load(noteId, attachCmd.username, attachCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(noteData.Attachments) == 0 {
+		fmt.Println("No attachments found!")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tTYPE\tADDED")
+	fmt.Fprintln(w, "----\t----\t----\t-----")
+	for _, attachment := range noteData.Attachments {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
+			attachment.Name,
+			attachment.Size,
+			attachment.MediaType,
+			attachment.AddedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
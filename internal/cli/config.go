@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+type ConfigCommand struct {
+	*Cli
+}
+
+func (configCmd *ConfigCommand) Name() string {
+	return "config"
+}
+
+func (configCmd *ConfigCommand) Description() string {
+	return "View and change per-user store settings"
+}
+
+func (configCmd *ConfigCommand) Run(args []string) error {
+	if len(args) < 1 {
+		configCmd.Help()
+		return errors.New("missing arguments")
+	}
+	cmd := args[0]
+	configArgs := args[1:]
+	switch cmd {
+	case "list":
+		settings, err := configCmd.store.LoadSettings(configCmd.username, configCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, key := range note.SettingKeys() {
+			value, _ := settings.Get(key)
+			fmt.Fprintf(w, "%s\t%s\n", key, value)
+		}
+		return w.Flush()
+
+	case "get":
+		if len(configArgs) < 1 {
+			return errors.New("usage: config get <key>")
+		}
+		settings, err := configCmd.store.LoadSettings(configCmd.username, configCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		value, err := settings.Get(configArgs[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+
+	case "set":
+		if len(configArgs) < 2 {
+			return errors.New("usage: config set <key> <value>")
+		}
+		unlock, err := configCmd.lockStore()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		settings, err := configCmd.store.LoadSettings(configCmd.username, configCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		if err := settings.Set(configArgs[0], configArgs[1]); err != nil {
+			return err
+		}
+		return configCmd.store.SaveSettings(settings, configCmd.username, configCmd.keyProvider)
+
+	default:
+		return fmt.Errorf("unknown subcommand: %s", cmd)
+	}
+	return nil
+}
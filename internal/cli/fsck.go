@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+type FsckCommand struct {
+	*Cli
+}
+
+func (fsckCmd *FsckCommand) Name() string {
+	return "fsck"
+}
+
+func (fsckCmd *FsckCommand) Description() string {
+	return "Check the store for damaged notes, links and index entries"
+}
+
+func (fsckCmd *FsckCommand) Run(args []string) error {
+	flagSet := newFlagSet("fsck")
+	repair := flagSet.Bool("repair", false, "Fix what is safely fixable and quarantine the rest")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+
+	report, err := fsckCmd.store.Fsck(*repair, fsckCmd.username, fsckCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(report.Problems) == 0 {
+		fmt.Printf("✓ %d note(s) checked, no problems found\n", report.Checked)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROBLEM\tNOTE\tDETAIL\tREPAIR")
+	fmt.Fprintln(w, "-------\t----\t------\t------")
+	for _, problem := range report.Problems {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", problem.Kind, problem.Name, problem.Detail, problem.Repair)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !*repair {
+		return fmt.Errorf("%d problem(s) found in %d note(s), run 'pkm fsck --repair' to fix them", len(report.Problems), report.Checked)
+	}
+	fmt.Printf("✓ %d problem(s) repaired\n", len(report.Problems))
+	return nil
+}
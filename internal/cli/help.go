@@ -18,6 +18,8 @@ USAGE:
 FLAGS:
   --user <username>            Username (required for note operations)
   --storeDirectory <path>      Storage directory (default: ~/.pkm)
+  --lock-timeout <duration>    Wait for other pkm processes (default: 10s)
+  --parallelism <n>            Notes decrypted at once in bulk operations (default: CPUs)
 
 COMMANDS:
 `)
@@ -29,9 +31,19 @@ COMMANDS:
 	}{
 		{"user", "Create users, change passwords, manage accounts"},
 		{"note", "Create, read, edit, and delete encrypted notes"},
+		{"inbox", "Process fleeting notes: promote, merge or discard each one"},
+		{"template", "Manage encrypted templates for new notes"},
+		{"journal", "Write one journal note per day"},
 		{"link", "Create and manage links between notes for knowledge discovery"},
 		{"tag", "Organize notes with tags for categorization and search"},
-		{"search", "Search notes by keywords or tags"},
+		{"search", "Search notes by keywords, tags or properties"},
+		{"index", "Maintain the encrypted search index and manifest"},
+		{"config", "View and change per-user store settings"},
+		{"trash", "List, restore and empty deleted notes"},
+		{"attach", "Keep encrypted files (PDFs, images, diagrams) next to notes"},
+		{"fsck", "Check the store for damaged notes, links and index entries"},
+		{"migrate", "Upgrade a vault to the current on-disk format"},
+		{"recompress", "Rewrite the vault with the current compression setting"},
 		{"help", "Show detailed help for a command (help <command>)"},
 		{"guide", "Show a quick guide"},
 	}
@@ -66,6 +78,10 @@ DETAILED HELP:
     $ pkm link help
     $ pkm tag help
     $ pkm search help
+    $ pkm index help
+    $ pkm config help
+    $ pkm trash help
+    $ pkm attach help
 
 USER COMMANDS:
 
@@ -83,8 +99,9 @@ USER COMMANDS:
 
 NOTE COMMANDS:
 
-  pkm --user <username> note new <title>
-    Create a new note (opens $EDITOR for content)
+  pkm --user <username> note new [--type <type>] [--template <name>] <title>
+    Create a new note (opens $EDITOR for content); types are fleeting,
+    literature, permanent (default) and structure
     
   pkm --user <username> note edit <note-id>
     Edit existing note content
@@ -92,23 +109,80 @@ NOTE COMMANDS:
   pkm --user <username> note get <note-id>
     Display note content in terminal
     
-  pkm --user <username> note delete <note-id>
-    Delete a note permanently
+  pkm --user <username> note delete <note-id> [--refuse-linked]
+    Move a note to the trash, removing the links to it
     
-  pkm --user <username> note list
-    List all notes with IDs and titles
+  pkm --user <username> note list [--long] [--type <type>]
+    List all notes with IDs and titles (--long adds types and properties)
+
+  pkm --user <username> note set <note-id> <key=value>...
+    Set typed properties on a note (unset removes them)
+
+  pkm --user <username> note type <note-id> <type>
+    Change a note's type
+
+  pkm --user <username> note history <note-id>
+    List earlier revisions of a note
+
+  pkm --user <username> note diff <note-id> <rev1> <rev2> [--words]
+    Compare two revisions ("current" is the live note)
+
+  pkm --user <username> note revert <note-id> <rev>
+    Restore a note to an earlier revision
 
 LINK COMMANDS:
 
-  pkm --user <username> link add <source-id> <target-id>
-    Create a link from source note to target note
+  pkm --user <username> link add <source-id> <target-id> [--type <relation>] [--oneway]
+    Create a link from source note to target note, optionally typed
     
   pkm --user <username> link remove <source-id> <target-id>
     Remove a link between two notes
     
-  pkm --user <username> link list <note-id>
+  pkm --user <username> link list <note-id> [--type <relation>]
     Show all outgoing links from a note
 
+  pkm --user <username> link walk <note-id> [--depth <n>] [--type <relation>]
+    Follow links from a note, only those of a relation with --type
+
+  pkm --user <username> link export [--type <relation>] [--format tsv|dot]
+    Print the link graph of the vault
+
+  pkm --user <username> link move <structure-id> <child-id> <position>
+    Reorder the children of a structure note
+
+TEMPLATE COMMANDS:
+
+  pkm --user <username> template add <name>
+    Write a new template in $EDITOR
+
+  pkm --user <username> template list
+    Show all templates
+
+  pkm --user <username> template edit <name>
+    Change a template
+
+  pkm --user <username> template rm <name>
+    Delete a template
+
+JOURNAL COMMANDS:
+
+  pkm --user <username> journal [date]
+    Open the journal entry of a day (default: today), creating it if needed
+
+  pkm --user <username> journal add "<text>"
+    Append a line to today's entry without opening $EDITOR
+
+  pkm --user <username> journal list [--month] [YYYY-MM]
+    Show journal entries, optionally of one month
+
+INBOX COMMANDS:
+
+  pkm --user <username> inbox
+    Walk through fleeting notes to promote, merge or discard each one
+
+  pkm --user <username> inbox list
+    Show fleeting notes waiting in the inbox, oldest first
+
 TAG COMMANDS:
 
   pkm --user <username> tag add <note-id> <tag1,tag2,...>
@@ -117,16 +191,90 @@ TAG COMMANDS:
   pkm --user <username> tag remove <note-id> <tag1,tag2,...>
     Remove tags from a note
     
-  pkm --user <username> tag list <note-id>
-    Show all tags on a note
+  pkm --user <username> tag list [note-id]
+    Show every tag with its note count, or the tags of one note
+
+  pkm --user <username> tag rename <old> <new>
+    Rename a tag on every note
+
+  pkm --user <username> tag merge <tag>... --into <tag>
+    Replace several tags with one on every note
+
+  pkm --user <username> tag orphans
+    Show tags used by a single note
+
+  pkm --user <username> tag tree
+    Show the tag hierarchy with note counts
+
+  pkm --user <username> tag alias add <alias> <tag>
+    Make a tag a synonym of another and retag the notes using it
+
+  pkm --user <username> tag suggest
+    Show tags that look like synonyms
 
 SEARCH COMMANDS:
 
   pkm --user <username> search keyword <term1> [term2] ...
     Find notes by searching keywords in title and content
     
-  pkm --user <username> search tag <tag1> [tag2] ...
-    Find notes by tag (returns notes with all specified tags)
+  pkm --user <username> search tag [--exact] <tag1> [tag2] ...
+    Find notes by tag (returns notes with all specified tags or tags below them)
+
+  pkm --user <username> search prop <key[=value]> ...
+    Find notes by property (returns notes matching all queries)
+
+  pkm --user <username> search <type> --type <note-type> <terms...>
+    Only show notes of one note type
+
+INDEX COMMANDS:
+
+  pkm --user <username> index rebuild
+    Regenerate the search index and manifest from every note
+
+  pkm --user <username> index verify
+    Check that the manifest used by 'note list' matches every note
+
+  pkm --user <username> fsck [--repair]
+    Check notes, links and the index; --repair fixes them
+
+  pkm --user <username> migrate [--dry-run]
+    Upgrade files written by older pkm versions to the current format
+
+  pkm --user <username> recompress [--dry-run]
+    Apply the compression setting to every existing file
+
+ATTACH COMMANDS:
+
+  pkm --user <username> attach add <note-id> <file>
+    Encrypt a file and attach it to a note
+
+  pkm --user <username> attach list <note-id>
+    Show a note's attachments
+
+  pkm --user <username> attach get <note-id> <name> [-o path]
+    Decrypt an attachment to a file (or - for stdout)
+
+  pkm --user <username> attach rm <note-id> <name>
+    Remove an attachment from a note
+
+TRASH COMMANDS:
+
+  pkm --user <username> trash list
+    Show deleted notes and who linked to them
+
+  pkm --user <username> trash restore <note-id> | --title <title>
+    Bring a deleted note back with its links
+
+  pkm --user <username> trash empty [--older-than 30d]
+    Permanently delete trashed notes
+
+CONFIG COMMANDS:
+
+  pkm --user <username> config list
+    Show all settings
+
+  pkm --user <username> config set <key> <value>
+    Change a setting (e.g. history.keep 50, history.max-age 90d, compression gzip)
 
 COMMON WORKFLOWS:
 
@@ -138,8 +286,14 @@ COMMON WORKFLOWS:
     $ pkm --user alice tag add <graph-theory-id> "algorithms,graphs"
     $ pkm --user alice search tag "algorithms"
 
+  Processing fleeting notes:
+    $ pkm --user alice note new --type fleeting "Idea from the podcast"
+    $ pkm --user alice inbox
+
   Exploring your knowledge:
     $ pkm --user alice note list
+  $ pkm --user alice note list --sort updated
+  $ pkm --user alice note info 550e8
     $ pkm --user alice note get <note-id>
     $ pkm --user alice link list <note-id>
     $ pkm --user alice search keyword "recursion"
@@ -151,7 +305,17 @@ DATA & SECURITY:
     ├── .crypt              (encrypted user keys - NEVER commit to git)
     ├── <username>/
     │   ├── <note-id>.pkm   (encrypted notes)
-    │   └── .index.pkm      (encrypted search index)
+    │   ├── .index/         (encrypted search index shards)
+    │   ├── .manifest.pkm   (encrypted note summaries for listing)
+    │   ├── .aliases.pkm    (encrypted alias to note id map)
+    │   ├── .tag-aliases.pkm (encrypted tag synonym table)
+    │   ├── .settings.pkm   (encrypted settings)
+    │   ├── .trash/         (encrypted deleted notes)
+    │   ├── .templates/     (encrypted note templates)
+    │   ├── .blobs/         (encrypted attachments, deduplicated)
+    │   ├── .quarantine/    (damaged notes set aside by fsck --repair)
+    │   ├── .backup/        (original files kept by migrate)
+    │   └── .history/       (encrypted earlier revisions)
 
   Encryption:
     ✓ AES-256-GCM encryption
@@ -176,7 +340,25 @@ TROUBLESHOOTING:
 
   "Note file not found"
     → Check note ID: pkm --user <username> note list
-    → IDs are long UUIDs (not incremental)
+    → IDs are long UUIDs (not incremental); a unique prefix is enough
+
+  "ambiguous prefix ... matches N notes"
+    → Type more characters of the id, or use --title "<title>"
+
+  "Store is locked by pid N"
+    → Another pkm process is writing to the same user store
+    → Wait for it to finish or raise --lock-timeout
+
+  "Note changed on disk while it was being edited"
+    → Another process saved the note while $EDITOR was open
+    → Re-run 'note edit' to edit the latest version
+
+  "Unsupported format version N"
+    → The vault was written by a newer pkm; upgrade pkm
+
+  "Note corrupted" or notes missing from search
+    → Run: pkm --user <username> fsck
+    → Then: pkm --user <username> fsck --repair
 
   "Editor not opening"
     → Set $EDITOR: export EDITOR=nano
@@ -196,11 +378,25 @@ USAGE:
   pkm --user <username> note <subcommand> [arguments]
 
 SUBCOMMANDS:
-  new <title>              Create a new note (opens $EDITOR)
+  new <title>              Create a new note (opens $EDITOR, --alias to pick one,
+                           --type fleeting|literature|permanent|structure,
+                           --template <name> to start from a template)
   edit <note-id>           Edit an existing note
   get <note-id>            Display note content
-  delete <note-id>         Delete a note
-  list                     List all notes
+  delete <note-id>         Move a note to the trash and remove the links to it
+                           (--refuse-linked keeps it while notes link to it)
+  list                     List all notes (--sort title|created|updated, --reverse,
+                           --long to show types and properties, --type <type>)
+  info <note-id>           Show a note's type, dates, edit count, words and hash
+                           (and the ordered children of a structure note)
+  type <note-id> <type>    Change a note's type
+  rename <note-id> <title> Change a note's title
+  alias <note-id> <alias>  Set a note's alias (--clear removes it)
+  set <note-id> <k=v>...   Set properties (string, number, date, bool, [list])
+  unset <note-id> <key>... Remove properties
+  history <note-id>        List earlier revisions of a note
+  diff <note-id> <r1> <r2> Compare revisions (--words for word diff)
+  revert <note-id> <rev>   Restore an earlier revision
   help                      Show this help message
 
 EXAMPLES:
@@ -209,12 +405,45 @@ EXAMPLES:
   $ pkm --user alice note get 550e8400-e29b
   $ pkm --user alice note edit 550e8400-e29b
   $ pkm --user alice note delete 550e8400-e29b
+  $ pkm --user alice note history 550e8400-e29b
+  $ pkm --user alice note diff 550e8400-e29b 2 current
+  $ pkm --user alice note revert 550e8400-e29b 2
+  $ pkm --user alice note edit 550e8
+  $ pkm --user alice note edit --title "Graph Theory"
+  $ pkm --user alice note alias 550e8 graphs
+  $ pkm --user alice note edit graphs
+  $ pkm --user alice note set graphs status=draft rating=4 due=2025-03-01
+  $ pkm --user alice note set graphs "authors=[Knuth, Tarjan]"
+  $ pkm --user alice note list --long
+  $ pkm --user alice note new --type literature "TAOCP, Vol. 1"
+  $ pkm --user alice note list --type fleeting
+  $ pkm --user alice note new --template meeting "Standup"
 
 NOTES:
+  • IDs: Any unique prefix of at least 4 characters works like git;
+         an ambiguous prefix lists the notes it matches
+  • Titles: --title "<title>" stands in for a note id, matching the
+            exact title first, then titles containing it, ignoring case
+  • Aliases: New notes get an alias derived from their title
+             (graph-theory); aliases are unique and work as note ids.
+             Renaming a note updates an alias derived from its title.
+             An alias that is also an id prefix is reported as ambiguous
   • Editors: Uses $EDITOR environment variable (default: vi)
   • Format: Notes are stored as JSON with encryption
   • Links: Add links using 'link add' command
   • Tags: Add tags using 'tag add' command
+  • History: Every save keeps the previous revision (see 'config')
+  • Metadata: Saves that change a note update its time and edit count;
+              'list --sort updated' shows the most recent first
+  • Types: Fleeting notes wait in 'pkm inbox'; literature notes need a
+           source property; structure notes list their children, in
+           the order 'link move' gives them, in 'note info'.
+           Notes without a type are permanent
+  • Properties: 'note edit' shows them as frontmatter between "---"
+                lines above the content; values are typed as numbers,
+                YYYY-MM-DD dates, true/false, [a, b] lists or strings
+                (quote a value to keep it a string). An edit that cannot
+                be saved reopens the editor, or is kept in a file
 `
 }
 
@@ -255,18 +484,47 @@ SUBCOMMANDS:
   add <source-id> <target-id>    Create link from source to target
   remove <source-id> <target-id> Remove link between notes
   list <note-id>                  List all links from a note
+  walk <note-id>                  Show the notes reachable from a note
+  export                          Print every link in the vault
+  move <source> <target> <pos>    Move a link to a position (1 = first)
   help                            Show this help message
 
+OPTIONS:
+  --type <relation>        add: relation of the link; list, walk, export:
+                           only links of this relation
+  --annotation <text>      add: note kept on the link
+  --oneway                 add: do not link back from the target
+  --inverse <relation>     add: relation of the back-link instead of the
+                           inverse of --type
+  --depth <n>              walk: links to follow (default: 2)
+  --format tsv|dot         export: table or Graphviz (default: tsv)
+
+RELATIONS:
+  supports / supported-by, contradicts, example-of / has-example,
+  source-of / derived-from, part-of / has-part
+  Other relations are allowed and are their own inverse.
+
 EXAMPLES:
   $ pkm --user alice link add 550e8400-e29b 6ba7b810-9dad
+  $ pkm --user alice link add claim-a study-b --type supported-by --annotation "n=200"
+  $ pkm --user alice link add draft source-paper --type derived-from --oneway
   $ pkm --user alice link list 550e8400-e29b
+  $ pkm --user alice link walk claim-a --depth 3 --type supported-by
+  $ pkm --user alice link export --type contradicts --format dot > contradictions.dot
   $ pkm --user alice link remove 550e8400-e29b 6ba7b810-9dad
+  $ pkm --user alice link add 550e8 --title "Graph Theory"
+  $ pkm --user alice link move graphs-moc bfs 1
 
 ABOUT LINKS:
   • Directional: A→B is different from B→A
-  • Backlinks: Automatically tracked for discovery
-  • No cycles: Links create knowledge graph, not circular
-  • UUID-based: Use full note IDs for accuracy
+  • Backlinks: Added to the target with the inverse relation, so a note
+               that supports another shows up there as supported-by
+  • Relations: Untyped links are plain "related" links
+  • Integrity: Both notes must exist and a note cannot link to itself;
+               a link and its back-link are written together
+  • IDs: Full ids, unique prefixes or --title "<title>" for either note
+  • Order: Links keep the order they were added in; structure notes
+           show them as their children, reorder them with 'link move'
 `
 }
 
@@ -280,20 +538,44 @@ USAGE:
 SUBCOMMANDS:
   add <note-id> <tags>           Add tags to a note (comma-separated)
   remove <note-id> <tags>        Remove tags from a note
+  list                           List every tag with its note count
   list <note-id>                 List all tags on a note
+  rename <old> <new>             Rename a tag on every note
+  merge <tags>... --into <tag>   Replace several tags with one on every note
+  orphans                        List tags used by a single note
+  tree                           Show the tag hierarchy with note counts
+  alias add <alias> <tag>        Make alias a synonym of tag
+  alias remove <alias>           Forget a synonym
+  alias list                     List synonyms and their tags
+  suggest                        Suggest synonyms by spelling and co-occurrence
   help                           Show this help message
 
 EXAMPLES:
   $ pkm --user alice tag add 550e8400-e29b "learning,graphs"
+  $ pkm --user alice tag list
   $ pkm --user alice tag list 550e8400-e29b
   $ pkm --user alice tag remove 550e8400-e29b "learning"
+  $ pkm --user alice tag add --title "Graph Theory" graphs
+  $ pkm --user alice tag rename algoritms algorithms
+  $ pkm --user alice tag merge ml machine-learn --into machine-learning
+  $ pkm --user alice tag add 550e8400-e29b cs/algorithms/graphs
+  $ pkm --user alice tag rename cs computer-science
+  $ pkm --user alice tag tree
+  $ pkm --user alice tag alias add ml machine-learning
+  $ pkm --user alice tag suggest
 
 TAG GUIDELINES:
   • Format: lowercase, hyphen-separated (e.g., machine-learning)
-  • Comma-separated: Use commas without spaces
+  • Comma-separated: Spaces around tags are trimmed, empty tags rejected
   • Case-insensitive: Automatically converted to lowercase
   • Unique per note: Duplicate tags are rejected
   • Searchable: Search by tag with 'search tag' command
+  • Vault-wide: rename and merge rewrite every affected note and the
+                index in one write, or change nothing
+  • Hierarchy: '/' nests tags; cs/algorithms is below cs. Searching or
+               renaming a tag covers the tags below it
+  • Synonyms: Notes are saved with the tag an alias stands for, and
+              searching either name finds them; the table is encrypted
 `
 }
 
@@ -302,23 +584,335 @@ func (searchCmd *SearchCommand) Help() string {
 NOTE SEARCH
 
 USAGE:
-  pkm --user <username> search <type> <terms...>
+  pkm --user <username> search <type> [--type <note-type>] [--exact] <terms...>
 
 SEARCH TYPES:
   keyword <term1> [term2] ...   Search by keywords in title/content
   tag <tag1> [tag2] ...         Search by tags (intersection)
+  prop <key[=value]> ...        Search by properties (intersection)
 
 EXAMPLES:
   $ pkm --user alice search keyword "graph theory"
   $ pkm --user alice search keyword recursion
   $ pkm --user alice search tag learning
   $ pkm --user alice search tag productivity algorithms
+  $ pkm --user alice search tag cs
+  $ pkm --user alice search tag --exact cs
+  $ pkm --user alice search prop status=draft
+  $ pkm --user alice search prop source authors=knuth
+  $ pkm --user alice search keyword --type literature recursion
 
 SEARCH BEHAVIOR:
   • Keywords: Case-insensitive substring match in title and content
   • Multiple keywords: AND logic (all must be present)
-  • Tags: Case-insensitive exact match (intersection if multiple)
+  • Tags: Case-insensitive match of the tag and the tags below it, so cs
+          finds cs/algorithms; --exact matches the tag alone
+          (intersection if multiple)
+  • Properties: A key alone finds notes that have it; key=value
+                matches the value case-insensitively, or any item of a list
   • Results: Returns note IDs and titles
   • Index: Uses built-in keyword/tag index for speed
 `
 }
+
+func (indexCmd *IndexCommand) Help() string {
+	return `
+INDEX MAINTENANCE
+
+USAGE:
+  pkm --user <username> index <subcommand>
+
+SUBCOMMANDS:
+  rebuild                  Regenerate .index/ and .manifest.pkm from every note
+  verify                   Report manifest entries that drifted from their notes
+  help                     Show this help message
+
+EXAMPLES:
+  $ pkm --user alice index verify
+  $ pkm --user alice index rebuild
+
+ABOUT THE INDEX:
+  • Updates: Edits, tag changes and deletes keep the index in sync
+  • Shards: Searches decrypt only the shards of the terms they look up,
+            and saves rewrite only the shards whose postings changed
+  • Manifest: 'note list' and search results read summaries from it
+  • Rebuild: Use after restoring notes by hand or copying vaults
+  • Encryption: The index is encrypted with the same key as notes
+`
+}
+
+func (configCmd *ConfigCommand) Help() string {
+	return `
+STORE SETTINGS
+
+USAGE:
+  pkm --user <username> config <subcommand> [arguments]
+
+SUBCOMMANDS:
+  list                     Show all settings
+  get <key>                Show one setting
+  set <key> <value>        Change a setting
+  help                     Show this help message
+
+SETTINGS:
+  history.keep             Revisions kept per note, 0 keeps all (default: 20)
+  history.max-age          Drop revisions older than this, e.g. 90d (default: 0, keep)
+  compression              Compress files before encryption: none or gzip (default: none)
+  journal.template         Template new journal entries start from (default: built-in)
+
+EXAMPLES:
+  $ pkm --user alice config set history.keep 50
+  $ pkm --user alice config set history.max-age 30d
+  $ pkm --user alice config set compression gzip
+  $ pkm --user alice config set journal.template daily
+`
+}
+
+func (templateCmd *TemplateCommand) Help() string {
+	return `
+TEMPLATES
+
+USAGE:
+  pkm --user <username> template <subcommand> [arguments]
+
+SUBCOMMANDS:
+  add <name>               Write a new template in $EDITOR
+  list                     List templates with their first line
+  show <name>              Print a template
+  edit <name>              Change a template in $EDITOR
+  rm <name>                Delete a template
+  help                     Show this help message
+
+EXAMPLES:
+  $ pkm --user alice template add meeting
+  $ pkm --user alice template list
+  $ pkm --user alice note new --template meeting "Standup"
+
+TEMPLATE FORMAT:
+  ---
+  tags: [meeting, work]
+  links: [standups]
+  attendees: {{prompt:Attendees}}
+  ---
+  # {{title}}, {{date}} {{time}}
+
+  Notes by {{user}}:
+
+PLACEHOLDERS:
+  {{date}}, {{time}}       The day (YYYY-MM-DD) and time (HH:MM) of creation
+  {{datetime}}             Both
+  {{title}}                The title given to 'note new'
+  {{user}}                 The user creating the note
+  {{prompt:<question>}}    Asks the question once and uses the answer
+
+NOTES:
+  • Frontmatter: 'type' sets the note type ('note new --type' wins),
+                 'tags' and 'links' are added to every new note (links
+                 take ids, prefixes or aliases); other keys become properties
+  • Names: Lowercase letters, digits and single hyphens
+  • Editing: A template with an unknown placeholder reopens the editor,
+             or is kept in a file
+  • Encryption: Templates are stored encrypted under .templates/
+`
+}
+
+func (journalCmd *JournalCommand) Help() string {
+	return `
+JOURNAL
+
+USAGE:
+  pkm --user <username> journal [date]
+  pkm --user <username> journal <subcommand> [arguments]
+
+SUBCOMMANDS:
+  [date]                   Open the entry of a day in $EDITOR, creating it
+                           first; date is YYYY-MM-DD, today (default),
+                           yesterday or tomorrow
+  add [--date <date>] <text>
+                           Append a line to an entry without $EDITOR
+  list [--month] [YYYY-MM] List entries, of this month with --month
+  help                     Show this help message
+
+EXAMPLES:
+  $ pkm --user alice journal
+  $ pkm --user alice journal yesterday
+  $ pkm --user alice journal add "Finished chapter 3"
+  $ pkm --user alice journal list --month
+  $ pkm --user alice note edit 2026-10-16
+
+NOTES:
+  • Entries: One note per day, titled and aliased by its date and tagged
+             'journal'
+  • Links: A new entry links both ways to the entries before and after it
+  • Skeleton: New entries start from the template named by the
+              journal.template setting, or from "# {{date}}"; placeholders
+              see the entry's day
+`
+}
+
+func (inboxCmd *InboxCommand) Help() string {
+	return `
+INBOX
+
+USAGE:
+  pkm --user <username> inbox [list]
+
+SUBCOMMANDS:
+  (none)                   Walk through fleeting notes, oldest first
+  list                     Show the fleeting notes in the inbox
+  help                     Show this help message
+
+CHOICES FOR EACH NOTE:
+  p, promote               Make it a literature, permanent or structure note
+                           (literature notes ask for their source)
+  m, merge                 Append it to another note, given by id, alias or
+                           title, and move it to the trash
+  d, discard               Move it to the trash
+  s, skip                  Leave it in the inbox
+  q, quit                  Stop for now
+
+EXAMPLES:
+  $ pkm --user alice note new --type fleeting "Spaced repetition?"
+  $ pkm --user alice inbox list
+  $ pkm --user alice inbox
+
+ABOUT MERGING:
+  • Content: The fleeting note's text is appended after a blank line
+  • Tags, links, attachments: Added to the note merged into
+  • Properties: Added unless the note merged into already sets them
+  • Back-links: Notes linking to the fleeting note link to the merged note
+`
+}
+
+func (trashCmd *TrashCommand) Help() string {
+	return `
+TRASH
+
+USAGE:
+  pkm --user <username> trash <subcommand> [arguments]
+
+SUBCOMMANDS:
+  list                     Show deleted notes, newest first
+  restore <note-id>        Restore a note, re-index it and relink it;
+                           the id may be a unique prefix, or use --title
+  empty [--older-than 30d] Permanently delete trashed notes
+  help                     Show this help message
+
+EXAMPLES:
+  $ pkm --user alice note delete 550e8400-e29b
+  $ pkm --user alice trash list
+  $ pkm --user alice trash restore 550e8400-e29b
+  $ pkm --user alice trash restore --title "Meeting notes"
+  $ pkm --user alice trash empty --older-than 30d
+
+ABOUT THE TRASH:
+  • Encrypted: Trashed notes stay encrypted like any other note
+  • Links: Links to a deleted note are removed and put back on restore
+  • History: Revisions are kept until the trash is emptied
+`
+}
+
+func (attachCmd *AttachCommand) Help() string {
+	return `
+ATTACHMENTS
+
+USAGE:
+  pkm --user <username> attach <subcommand> [arguments]
+
+SUBCOMMANDS:
+  add <note-id> <file>           Encrypt and attach a file
+  list <note-id>                 List a note's attachments
+  get <note-id> <name> [-o path] Decrypt an attachment (-o - for stdout)
+  rm <note-id> <name>            Remove an attachment
+  help                           Show this help message
+
+EXAMPLES:
+  $ pkm --user alice attach add 550e8400-e29b paper.pdf
+  $ pkm --user alice attach list 550e8400-e29b
+  $ pkm --user alice attach get 550e8400-e29b paper.pdf -o /tmp/paper.pdf
+  $ pkm --user alice attach rm 550e8400-e29b paper.pdf
+
+ABOUT ATTACHMENTS:
+  • Encrypted: Files are encrypted with your key like notes
+  • Deduplicated: Identical files are stored once across notes
+  • Cleanup: Unused files are removed when notes are deleted for good
+  • Safe output: 'get' never overwrites an existing file
+  • Notes: Take a full id, a unique prefix or --title "<title>"
+`
+}
+
+func (fsckCmd *FsckCommand) Help() string {
+	return `
+STORE INTEGRITY CHECK
+
+USAGE:
+  pkm --user <username> fsck [--repair]
+
+FLAGS:
+  --repair                 Fix what is safely fixable, quarantine the rest
+
+CHECKS:
+  undecryptable            Note has no PKM header or fails to decrypt
+  bad-json                 Decrypted note is not a valid note
+  id-mismatch              Note id differs from its file name
+  dangling-link            Link points to a note that does not exist
+  one-sided-link           Linked note does not link back
+  index                    Index entries for missing or changed notes
+  manifest                 Manifest entries for missing or changed notes
+
+EXAMPLES:
+  $ pkm --user alice fsck
+  $ pkm --user alice fsck --repair
+
+ABOUT REPAIRS:
+  • Index: Index and manifest rebuilt from every readable note
+  • Links: Dangling links are dropped, missing back-links added
+  • Quarantine: Unreadable notes move to .quarantine/ untouched
+  • Atomic: All repairs are written in one step
+`
+}
+
+func (migrateCmd *MigrateCommand) Help() string {
+	return `
+FORMAT MIGRATION
+
+USAGE:
+  pkm --user <username> migrate [--dry-run]
+
+FLAGS:
+  --dry-run                List the files that would be upgraded, change nothing
+
+EXAMPLES:
+  $ pkm --user alice migrate --dry-run
+  $ pkm --user alice migrate
+
+ABOUT THE FORMAT:
+  • Header: Every file records format version, cipher, key id and flags
+  • Legacy: Files from older versions stay readable until migrated
+  • Backup: Originals are kept in .backup/migrate-<time>/
+  • Index: A JSON index from older versions is rebuilt as shards
+  • Atomic: All files are upgraded in one step
+`
+}
+
+func (recompressCmd *RecompressCommand) Help() string {
+	return `
+RECOMPRESSION
+
+USAGE:
+  pkm --user <username> recompress [--dry-run]
+
+FLAGS:
+  --dry-run                List the files that would be rewritten, change nothing
+
+EXAMPLES:
+  $ pkm --user alice config set compression gzip
+  $ pkm --user alice recompress
+
+ABOUT COMPRESSION:
+  • Setting: 'compression' applies to every file written afterwards
+  • Existing files: recompress rewrites them to match the setting
+  • Order: Files are gzipped before encryption, never after
+  • Skipped: Files gzip cannot shrink (e.g. PDFs) stay uncompressed
+`
+}
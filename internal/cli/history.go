@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func (noteCmd *NoteCommand) printHistory(noteId string) error {
+	history, err := noteCmd.store.History(noteId, noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Println("No revisions found!")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REV\tREPLACED\tTITLE\tWORDS")
+	fmt.Fprintln(w, "---\t--------\t-----\t-----")
+	for _, revision := range history {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\n",
+			revision.Rev,
+			revision.ReplacedAt.Local().Format(time.DateTime),
+			revision.Note.Title,
+			len(strings.Fields(revision.Note.Content)))
+	}
+	return w.Flush()
+}
+
+func (noteCmd *NoteCommand) printDiff(args []string) error {
+	flagSet := newFlagSet("note diff")
+	words := flagSet.Bool("words", false, "Diff words instead of lines")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 3 {
+		return errors.New("usage: note diff <id> <rev1> <rev2> [--words]")
+	}
+
+	before, err := noteCmd.loadRevision(positional[0], positional[1])
+	if err != nil {
+		return err
+	}
+	after, err := noteCmd.loadRevision(positional[0], positional[2])
+	if err != nil {
+		return err
+	}
+
+	if *words {
+		var out []string
+		for _, op := range note.Diff(strings.Fields(before.Content), strings.Fields(after.Content)) {
+			switch op.Kind {
+			case '-':
+				out = append(out, "[-"+op.Text+"-]")
+			case '+':
+				out = append(out, "{+"+op.Text+"+}")
+			default:
+				out = append(out, op.Text)
+			}
+		}
+		fmt.Println(strings.Join(out, " "))
+		return nil
+	}
+
+	fmt.Printf("--- %s (%s)\n+++ %s (%s)\n", before.Title, positional[1], after.Title, positional[2])
+	for _, op := range note.Diff(strings.Split(before.Content, "\n"), strings.Split(after.Content, "\n")) {
+		fmt.Printf("%c %s\n", op.Kind, op.Text)
+	}
+	return nil
+}
+
+func (noteCmd *NoteCommand) revert(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: note revert <id> <rev>")
+	}
+	rev, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid revision %q", args[1])
+	}
+	if err := noteCmd.store.Revert(args[0], rev, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Note %s reverted to revision %d\n", args[0], rev)
+	return nil
+}
+
+// loadRevision loads an archived revision, or the live note for "current"
+func (noteCmd *NoteCommand) loadRevision(noteId string, rev string) (*note.Note, error) {
+	if rev == "current" {
+		return noteCmd.store.Load(noteId, noteCmd.username, noteCmd.keyProvider)
+	}
+	revNumber, err := strconv.Atoi(rev)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	return noteCmd.store.LoadRevision(noteId, revNumber, noteCmd.username, noteCmd.keyProvider)
+}
@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+type InboxCommand struct {
+	*Cli
+}
+
+func (inboxCmd *InboxCommand) Name() string {
+	return "inbox"
+}
+
+func (inboxCmd *InboxCommand) Description() string {
+	return "Process fleeting notes: promote, merge or discard each one"
+}
+
+func (inboxCmd *InboxCommand) Run(args []string) error {
+	if len(args) == 0 {
+		return inboxCmd.walk()
+	}
+	switch args[0] {
+	case "list":
+		return inboxCmd.printList()
+	default:
+		return fmt.Errorf("unknown subcommand: %s", args[0])
+	}
+}
+
+// fleeting returns the fleeting notes, oldest first
+func (inboxCmd *InboxCommand) fleeting() ([]note.NoteSummary, error) {
+	noteSummaryList, err := inboxCmd.store.List(inboxCmd.username, inboxCmd.keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	noteSummaryList, err = filterType(noteSummaryList, note.TypeFleeting)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(noteSummaryList, func(a, b note.NoteSummary) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return noteSummaryList, nil
+}
+
+func (inboxCmd *InboxCommand) printList() error {
+	inbox, err := inboxCmd.fleeting()
+	if err != nil {
+		return err
+	}
+	if len(inbox) == 0 {
+		fmt.Println("Inbox is empty!")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tTITLE\tCREATED")
+	fmt.Fprintln(w, "---\t-----\t-------")
+	for _, summary := range inbox {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", summary.Id, summary.Title, summary.CreatedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
+
+// walk shows each fleeting note in turn and asks what to do with it
+func (inboxCmd *InboxCommand) walk() error {
+	inbox, err := inboxCmd.fleeting()
+	if err != nil {
+		return err
+	}
+	if len(inbox) == 0 {
+		fmt.Println("Inbox is empty!")
+		return nil
+	}
+
+	processed := 0
+	for i, summary := range inbox {
+		noteData, err := inboxCmd.store.Load(summary.Id, inboxCmd.username, inboxCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n[%d/%d] %s (%s), created %s\n\n%s\n", i+1, len(inbox), noteData.Title, noteData.Id,
+			noteData.CreatedAt.Local().Format(time.DateTime), strings.TrimRight(noteData.Content, "\n"))
+
+		done, err := inboxCmd.process(noteData)
+		if errors.Is(err, io.EOF) || errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			processed++
+		}
+	}
+	fmt.Printf("\n✓ %d of %d fleeting note(s) processed\n", processed, len(inbox))
+	return nil
+}
+
+// errQuit stops the inbox walk early
+var errQuit = errors.New("quit")
+
+// process asks what to do with one fleeting note until an action succeeds.
+// It reports whether the note left the inbox
+func (inboxCmd *InboxCommand) process(noteData *note.Note) (bool, error) {
+	for {
+		answer, err := inboxCmd.prompt("[p]romote, [m]erge, [d]iscard, [s]kip, [q]uit? ")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "p", "promote":
+			err = inboxCmd.promote(noteData)
+		case "m", "merge":
+			err = inboxCmd.merge(noteData)
+		case "d", "discard":
+			if _, err = inboxCmd.store.Trash(noteData.Id, false, inboxCmd.username, inboxCmd.keyProvider); err == nil {
+				fmt.Printf("✓ Note %s moved to trash\n", noteData.Id)
+			}
+		case "s", "skip", "":
+			return false, nil
+		case "q", "quit":
+			return false, errQuit
+		default:
+			fmt.Printf("Unknown choice %q\n", answer)
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return false, err
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		return true, nil
+	}
+}
+
+// promote turns a fleeting note into another type, asking for the source
+// a literature note needs
+func (inboxCmd *InboxCommand) promote(noteData *note.Note) error {
+	answer, err := inboxCmd.prompt("Promote to (literature, permanent, structure) [permanent]: ")
+	if err != nil {
+		return err
+	}
+	noteType := strings.ToLower(answer)
+	if noteType == "" {
+		noteType = note.TypePermanent
+	}
+	if noteType == note.TypeFleeting {
+		return errors.New("note is already fleeting")
+	}
+	// Change a copy so that a failed promotion can be retried
+	promoted := *noteData
+	promoted.Properties = maps.Clone(noteData.Properties)
+	if err := promoted.SetType(noteType); err != nil {
+		return err
+	}
+	if _, ok := promoted.Properties[note.SourceProperty]; noteType == note.TypeLiterature && !ok {
+		source, err := inboxCmd.prompt("Source: ")
+		if err != nil {
+			return err
+		}
+		if err := promoted.SetProperty(note.SourceProperty, source); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := inboxCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := inboxCmd.store.Save(&promoted, inboxCmd.username, inboxCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Note %s is now a %s note\n", noteData.Id, noteType)
+	return nil
+}
+
+// merge folds a fleeting note into a note named by id, alias or title
+func (inboxCmd *InboxCommand) merge(noteData *note.Note) error {
+	answer, err := inboxCmd.prompt("Merge into (id, alias or title): ")
+	if err != nil {
+		return err
+	}
+	if answer == "" {
+		return errors.New("no note given")
+	}
+	targetId, err := inboxCmd.store.Resolve(answer, inboxCmd.username, inboxCmd.keyProvider)
+	if errors.Is(err, fs.ErrNotExist) {
+		targetId, err = inboxCmd.store.ResolveTitle(answer, inboxCmd.username, inboxCmd.keyProvider)
+	}
+	if err != nil {
+		return err
+	}
+	into, err := inboxCmd.store.Merge(noteData.Id, targetId, inboxCmd.username, inboxCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Note %s merged into %s\n", noteData.Id, noteLabel(into))
+	return nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+type IndexCommand struct {
+	*Cli
+}
+
+func (indexCmd *IndexCommand) Name() string {
+	return "index"
+}
+
+func (indexCmd *IndexCommand) Description() string {
+	return "Maintain the encrypted search index and manifest"
+}
+
+func (indexCmd *IndexCommand) Run(args []string) error {
+	if len(args) < 1 {
+		indexCmd.Help()
+		return errors.New("missing arguments")
+	}
+	cmd := args[0]
+	switch cmd {
+	case "rebuild":
+		count, err := indexCmd.store.RebuildIndex(indexCmd.username, indexCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Index and manifest rebuilt from %d note(s)\n", count)
+	case "verify":
+		problems, err := indexCmd.store.VerifyManifest(indexCmd.username, indexCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		if len(problems) == 0 {
+			fmt.Println("✓ Manifest matches every note")
+			return nil
+		}
+		for _, problem := range problems {
+			fmt.Printf("%s: %s\n", problem.Name, problem.Detail)
+		}
+		return fmt.Errorf("manifest drifted in %d place(s), run 'pkm index rebuild' to fix it", len(problems))
+	default:
+		return fmt.Errorf("unknown subcommand: %s", cmd)
+	}
+	return nil
+}
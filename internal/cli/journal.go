@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+type JournalCommand struct {
+	*Cli
+}
+
+func (journalCmd *JournalCommand) Name() string {
+	return "journal"
+}
+
+func (journalCmd *JournalCommand) Description() string {
+	return "Write one journal note per day"
+}
+
+func (journalCmd *JournalCommand) Run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return journalCmd.printList(args[1:])
+		case "add":
+			return journalCmd.add(args[1:])
+		}
+	}
+	if len(args) > 1 {
+		return errors.New("usage: journal [date]")
+	}
+	date, err := note.ParseJournalDate(strings.Join(args, ""), time.Now())
+	if err != nil {
+		return err
+	}
+	return journalCmd.open(date)
+}
+
+// entry returns the id of the journal entry of date, or "" when there is none
+func (journalCmd *JournalCommand) entry(date time.Time) (string, error) {
+	entries, err := journalCmd.store.JournalEntries(journalCmd.username, journalCmd.keyProvider)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(entries, func(s note.NoteSummary) bool { return s.Alias == note.JournalAlias(date) })
+	if i < 0 {
+		return "", nil
+	}
+	return entries[i].Id, nil
+}
+
+// open edits the entry of date, creating it from the skeleton first
+func (journalCmd *JournalCommand) open(date time.Time) error {
+	id, err := journalCmd.entry(date)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		noteCmd := &NoteCommand{Cli: journalCmd.Cli}
+		return noteCmd.Run([]string{"edit", id})
+	}
+
+	entry, buffer, err := journalCmd.newEntry(date)
+	if err != nil {
+		return err
+	}
+	buffer, err = journalCmd.editUntilValid(buffer, func(edited string) (err error) {
+		if entry.Properties, entry.Content, err = note.SplitFrontmatter(edited); err != nil {
+			return err
+		}
+		return note.CheckType(entry)
+	})
+	if err != nil {
+		return err
+	}
+	unlock, err := journalCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := journalCmd.store.AddJournal(entry, journalCmd.username, journalCmd.keyProvider); err != nil {
+		return keepEdit(buffer, err)
+	}
+	fmt.Printf("✓ Journal entry %s created\n", noteLabel(entry))
+	return nil
+}
+
+// newEntry returns an unsaved entry for date with the editor buffer its
+// skeleton expands to. The skeleton is the template named by the
+// journal.template setting, or the built-in one
+func (journalCmd *JournalCommand) newEntry(date time.Time) (*note.Note, string, error) {
+	settings, err := journalCmd.store.LoadSettings(journalCmd.username, journalCmd.keyProvider)
+	if err != nil {
+		return nil, "", err
+	}
+	template := &note.Template{Name: "journal", Text: note.DefaultJournalSkeleton}
+	if settings.JournalTemplate != "" {
+		if template, err = journalCmd.store.LoadTemplate(settings.JournalTemplate, journalCmd.username, journalCmd.keyProvider); err != nil {
+			return nil, "", err
+		}
+	}
+	// Placeholders see the entry's day at the current time of day
+	now := time.Now()
+	at := time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), 0, now.Location())
+	entry := note.NewJournal(date, "")
+	expansion, err := template.Expand(note.TemplateVars{Title: entry.Title, User: journalCmd.username, Now: at}, func(question string) (string, error) {
+		return journalCmd.prompt(question + ": ")
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if expansion.Type != "" {
+		entry.Type = expansion.Type
+	}
+	for _, tag := range expansion.Tags {
+		if !slices.Contains(entry.Tags, tag) {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+	for _, ref := range expansion.Links {
+		id, err := journalCmd.store.Resolve(ref, journalCmd.username, journalCmd.keyProvider)
+		if err != nil {
+			return nil, "", fmt.Errorf("template %s: link %s: %w", template.Name, ref, err)
+		}
+		entry.Links = append(entry.Links, note.Link{Target: id})
+	}
+	return entry, note.JoinFrontmatter(expansion.Properties, expansion.Content), nil
+}
+
+// add appends a line to an entry without opening the editor, creating the
+// entry when needed
+func (journalCmd *JournalCommand) add(args []string) error {
+	flagSet := newFlagSet("journal add")
+	day := flagSet.String("date", "today", "Day of the entry, YYYY-MM-DD")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(strings.Join(positional, " "))
+	if text == "" {
+		return errors.New("usage: journal add [--date <date>] <text>")
+	}
+	date, err := note.ParseJournalDate(*day, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Ask the template's questions before taking the lock, which other
+	// pkm processes would otherwise wait on for as long as the prompt does
+	id, err := journalCmd.entry(date)
+	if err != nil {
+		return err
+	}
+	var fresh *note.Note
+	if id == "" {
+		entry, buffer, err := journalCmd.newEntry(date)
+		if err != nil {
+			return err
+		}
+		if entry.Properties, entry.Content, err = note.SplitFrontmatter(buffer); err != nil {
+			return err
+		}
+		entry.Content = appendLine(entry.Content, text)
+		fresh = entry
+	}
+
+	unlock, err := journalCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Another process may have created or deleted the entry meanwhile
+	if id, err = journalCmd.entry(date); err != nil {
+		return err
+	}
+	if id == "" {
+		if fresh == nil {
+			return fmt.Errorf("journal entry %s was deleted meanwhile", note.JournalAlias(date))
+		}
+		if err := journalCmd.store.AddJournal(fresh, journalCmd.username, journalCmd.keyProvider); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Added to new journal entry %s\n", noteLabel(fresh))
+		return nil
+	}
+
+	entry, err := journalCmd.store.Load(id, journalCmd.username, journalCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	entry.Content = appendLine(entry.Content, text)
+	if err := journalCmd.store.Save(entry, journalCmd.username, journalCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Added to journal entry %s\n", noteLabel(entry))
+	return nil
+}
+
+// appendLine adds line to content on a line of its own
+func appendLine(content string, line string) string {
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + line + "\n"
+}
+
+// printList handles `journal list [--month] [YYYY-MM]`
+func (journalCmd *JournalCommand) printList(args []string) error {
+	flagSet := newFlagSet("journal list")
+	thisMonth := flagSet.Bool("month", false, "Only list this month's entries")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	prefix := ""
+	switch {
+	case len(positional) > 1:
+		return errors.New("usage: journal list [--month] [YYYY-MM]")
+	case len(positional) == 1:
+		month, err := time.Parse("2006-01", positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid month %q: use YYYY-MM", positional[0])
+		}
+		prefix = month.Format("2006-01-")
+	case *thisMonth:
+		prefix = time.Now().Format("2006-01-")
+	}
+
+	entries, err := journalCmd.store.JournalEntries(journalCmd.username, journalCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	entries = slices.DeleteFunc(entries, func(s note.NoteSummary) bool { return !strings.HasPrefix(s.Alias, prefix) })
+	if len(entries) == 0 {
+		fmt.Println("No journal entries found!")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tUID\tWORDS\tUPDATED")
+	fmt.Fprintln(w, "----\t---\t-----\t-------")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", entry.Alias, entry.Id, entry.Words, entry.UpdatedAt.Local().Format(time.DateTime))
+	}
+	return w.Flush()
+}
@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
 )
 
 type LinkCommand struct {
@@ -24,57 +30,67 @@ func (linkCmd *LinkCommand) Run(args []string) error {
 		return errors.New("missing arguments")
 	}
 	cmd := args[0]
-	linkArgs := args[1:]
+	switch cmd {
+	case "list":
+		return linkCmd.printLinks(args[1:])
+	case "walk":
+		return linkCmd.walk(args[1:])
+	case "export":
+		return linkCmd.export(args[1:])
+	}
+
+	unlock, err := linkCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	flagSet := newFlagSet("link " + cmd)
+	relation := flagSet.String("type", "", "Relation of the link, e.g. supports")
+	annotation := flagSet.String("annotation", "", "Note on the link")
+	oneWay := flagSet.Bool("oneway", false, "Link without a back-link")
+	inverse := flagSet.String("inverse", "", "Relation of the back-link (default: the inverse of --type)")
+	linkArgs, err := linkCmd.expandTitles(args[1:])
+	if err != nil {
+		return err
+	}
+	if linkArgs, err = parseFlags(flagSet, linkArgs); err != nil {
+		return err
+	}
+	if linkArgs, err = linkCmd.resolveIds(linkArgs, 2); err != nil {
+		return err
+	}
 	if len(linkArgs) < 2 {
 		return errors.New("missing operand")
 	}
+
 	switch cmd {
 	case "add":
-		noteData1, err := linkCmd.Cli.GetStore().Load(linkArgs[0], linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider())
-		if err != nil {
+		link := note.Link{Target: linkArgs[1], Relation: *relation, Annotation: *annotation, OneWay: *oneWay}
+		if err := linkCmd.store.LinkNotes(linkArgs[0], link, *inverse, linkCmd.username, linkCmd.keyProvider); err != nil {
 			return err
 		}
-		if err := noteData1.AddLink(linkArgs[1]); err != nil {
+	case "delete", "remove":
+		if err := linkCmd.store.UnlinkNotes(linkArgs[0], linkArgs[1], linkCmd.username, linkCmd.keyProvider); err != nil {
 			return err
 		}
-		if err := linkCmd.Cli.GetStore().Save(noteData1, linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider()); err != nil {
-			return err
+	case "move":
+		// Order the children of a structure note
+		if len(linkArgs) < 3 {
+			return errors.New("usage: link move <source-id> <target-id> <position>")
 		}
-		// back linking
-		noteData2, err := linkCmd.Cli.GetStore().Load(linkArgs[1], linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider())
+		position, err := strconv.Atoi(linkArgs[2])
 		if err != nil {
-			return err
-		}
-		if err := noteData2.AddLink(linkArgs[0]); err != nil {
-			return err
-		}
-		if err := linkCmd.Cli.GetStore().Save(noteData2, linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider()); err != nil {
-			return err
+			return fmt.Errorf("invalid position %q", linkArgs[2])
 		}
-	case "delete", "remove":
-		noteData1, err := linkCmd.Cli.GetStore().Load(linkArgs[0], linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider())
+		noteData, err := linkCmd.store.Load(linkArgs[0], linkCmd.username, linkCmd.keyProvider)
 		if err != nil {
 			return err
 		}
-		if err := noteData1.RemoveLink(linkArgs[1]); err != nil {
+		if err := noteData.MoveLink(linkArgs[1], position); err != nil {
 			return err
 		}
-		if err := linkCmd.Cli.GetStore().Save(noteData1, linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider()); err != nil {
-			return err
-		}
-		// back linking
-		noteData2, err := linkCmd.Cli.GetStore().Load(linkArgs[1], linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider())
-		if err != nil {
-			return err
-		}
-		if err := noteData2.RemoveLink(linkArgs[0]); err != nil {
-			if strings.Contains(err.Error(), "link not found") {
-				// ignore if back-link didn't exist
-			} else {
-				return err
-			}
-		}
-		if err := linkCmd.Cli.GetStore().Save(noteData2, linkCmd.Cli.GetUsername(), linkCmd.Cli.GetKeyProvider()); err != nil {
+		if err := linkCmd.store.Save(noteData, linkCmd.username, linkCmd.keyProvider); err != nil {
 			return err
 		}
 	default:
@@ -82,3 +98,153 @@ func (linkCmd *LinkCommand) Run(args []string) error {
 	}
 	return nil
 }
+
+// titles maps every note id to its title
+func (linkCmd *LinkCommand) titles() (map[string]string, error) {
+	noteSummaryList, err := linkCmd.store.List(linkCmd.username, linkCmd.keyProvider)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[string]string, len(noteSummaryList))
+	for _, summary := range noteSummaryList {
+		titles[summary.Id] = summary.Title
+	}
+	return titles, nil
+}
+
+// relationLabel names the relation of a link, "related" for plain links
+func relationLabel(link note.Link) string {
+	label := link.Relation
+	if label == "" {
+		label = "related"
+	}
+	if link.OneWay {
+		label += " (one-way)"
+	}
+	return label
+}
+
+// printLinks handles `link list <id> [--type <relation>]`
+func (linkCmd *LinkCommand) printLinks(args []string) error {
+	flagSet := newFlagSet("link list")
+	relation := flagSet.String("type", "", "Only list links of this relation")
+	args, err := linkCmd.expandTitles(args)
+	if err != nil {
+		return err
+	}
+	if args, err = parseFlags(flagSet, args); err != nil {
+		return err
+	}
+	if args, err = linkCmd.resolveIds(args, 1); err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("usage: link list <id> [--type <relation>]")
+	}
+	noteData, err := linkCmd.store.Load(args[0], linkCmd.username, linkCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	titles, err := linkCmd.titles()
+	if err != nil {
+		return err
+	}
+	edges := note.Edges([]*note.Note{noteData}, *relation)
+	if len(edges) == 0 {
+		fmt.Println("No links found!")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tTITLE\tRELATION\tANNOTATION")
+	fmt.Fprintln(w, "---\t-----\t--------\t----------")
+	for _, edge := range edges {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", edge.Target, titles[edge.Target], relationLabel(edge.Link), edge.Annotation)
+	}
+	return w.Flush()
+}
+
+// walk handles `link walk <id> [--depth <n>] [--type <relation>]`, printing
+// the notes reached as an indented tree
+func (linkCmd *LinkCommand) walk(args []string) error {
+	flagSet := newFlagSet("link walk")
+	depth := flagSet.Int("depth", 2, "How many links to follow")
+	relation := flagSet.String("type", "", "Only follow links of this relation")
+	args, err := linkCmd.expandTitles(args)
+	if err != nil {
+		return err
+	}
+	if args, err = parseFlags(flagSet, args); err != nil {
+		return err
+	}
+	if args, err = linkCmd.resolveIds(args, 1); err != nil {
+		return err
+	}
+	if len(args) != 1 || *depth < 1 {
+		return errors.New("usage: link walk <id> [--depth <n>] [--type <relation>]")
+	}
+	notes, err := linkCmd.store.LoadAll(context.Background(), linkCmd.username, linkCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	titles, err := linkCmd.titles()
+	if err != nil {
+		return err
+	}
+
+	steps := note.Walk(notes, args[0], *depth, *relation)
+	children := make(map[string][]note.Step)
+	for _, step := range steps {
+		children[step.Via.From] = append(children[step.Via.From], step)
+	}
+	fmt.Printf("%s %s\n", args[0], titles[args[0]])
+	var print func(id string)
+	print = func(id string) {
+		for _, step := range children[id] {
+			fmt.Printf("%s-[%s]-> %s %s\n", strings.Repeat("  ", step.Depth), relationLabel(step.Via.Link), step.Via.Target, titles[step.Via.Target])
+			print(step.Via.Target)
+		}
+	}
+	print(args[0])
+	return nil
+}
+
+// export handles `link export [--type <relation>] [--format tsv|dot]`,
+// writing the link graph of the vault to stdout
+func (linkCmd *LinkCommand) export(args []string) error {
+	flagSet := newFlagSet("link export")
+	relation := flagSet.String("type", "", "Only export links of this relation")
+	format := flagSet.String("format", "tsv", "Output format: tsv or dot")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errors.New("usage: link export [--type <relation>] [--format tsv|dot]")
+	}
+	if *format != "tsv" && *format != "dot" {
+		return fmt.Errorf("unknown format %q (use tsv or dot)", *format)
+	}
+	notes, err := linkCmd.store.LoadAll(context.Background(), linkCmd.username, linkCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	edges := note.Edges(notes, *relation)
+
+	if *format == "tsv" {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FROM\tTO\tRELATION\tANNOTATION")
+		for _, edge := range edges {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", edge.From, edge.Target, relationLabel(edge.Link), edge.Annotation)
+		}
+		return w.Flush()
+	}
+	fmt.Println("digraph pkm {")
+	for _, n := range notes {
+		fmt.Printf("  %q [label=%q];\n", n.Id, n.Title)
+	}
+	for _, edge := range edges {
+		fmt.Printf("  %q -> %q [label=%q];\n", edge.From, edge.Target, edge.Relation)
+	}
+	fmt.Println("}")
+	return nil
+}
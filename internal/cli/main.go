@@ -18,6 +18,8 @@ func NewCli(version, commit string) {
 	username := flag.String("user", "", "Username (required)")
 	versionFlag := flag.Bool("v", false, "Print version")
 	versionLongFlag := flag.Bool("version", false, "Print version")
+	lockTimeout := flag.Duration("lock-timeout", note.DefaultLockTimeout, "How long to wait for another pkm process to release the store")
+	parallelism := flag.Int("parallelism", 0, "Notes decrypted at once by bulk operations (default: one per CPU)")
 
 	flag.Parse()
 
@@ -41,6 +43,7 @@ func NewCli(version, commit string) {
 	if cmdName == "user" {
 		cli := &Cli{
 			store:    note.InitStore(storeDir),
+			storeDir: storeDir,
 			username: "",
 		}
 		cmd := &UserCommand{CLI: cli}
@@ -62,13 +65,24 @@ func NewCli(version, commit string) {
 
 		cli := Cli{
 			store:    note.InitStore(storeDir),
+			storeDir: storeDir,
 			username: "",
 		}
 		commands := []Command{
 			&NoteCommand{Cli: &cli},
+			&InboxCommand{Cli: &cli},
+			&TemplateCommand{Cli: &cli},
+			&JournalCommand{Cli: &cli},
 			&LinkCommand{Cli: &cli},
 			&TagCommand{Cli: &cli},
 			&SearchCommand{Cli: &cli},
+			&IndexCommand{Cli: &cli},
+			&ConfigCommand{Cli: &cli},
+			&TrashCommand{Cli: &cli},
+			&AttachCommand{Cli: &cli},
+			&FsckCommand{Cli: &cli},
+			&MigrateCommand{Cli: &cli},
+			&RecompressCommand{Cli: &cli},
 		}
 		for _, cmd := range commands {
 			if cmd.Name() == args[0] {
@@ -98,17 +112,38 @@ func NewCli(version, commit string) {
 		os.Exit(1)
 	}
 
+	store := note.InitStore(storeDir)
+	store.SetLockTimeout(*lockTimeout)
+	store.SetParallelism(*parallelism)
+
 	cli := Cli{
-		store:       note.InitStore(storeDir),
+		store:       store,
+		storeDir:    storeDir,
 		username:    *username,
 		keyProvider: keyProvider,
 	}
 
+	// Finish or clean up writes interrupted by a previous crash
+	if err := cli.store.Recover(*username); err != nil {
+		fmt.Fprintf(os.Stderr, "Store recovery error: %v\n", err)
+		os.Exit(1)
+	}
+
 	commands := []Command{
 		&NoteCommand{Cli: &cli},
+		&InboxCommand{Cli: &cli},
+		&TemplateCommand{Cli: &cli},
+		&JournalCommand{Cli: &cli},
 		&LinkCommand{Cli: &cli},
 		&TagCommand{Cli: &cli},
 		&SearchCommand{Cli: &cli},
+		&IndexCommand{Cli: &cli},
+		&ConfigCommand{Cli: &cli},
+		&TrashCommand{Cli: &cli},
+		&AttachCommand{Cli: &cli},
+		&FsckCommand{Cli: &cli},
+		&MigrateCommand{Cli: &cli},
+		&RecompressCommand{Cli: &cli},
 	}
 	for _, cmd := range commands {
 		if cmd.Name() == cmdName {
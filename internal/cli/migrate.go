@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+type MigrateCommand struct {
+	*Cli
+}
+
+func (migrateCmd *MigrateCommand) Name() string {
+	return "migrate"
+}
+
+func (migrateCmd *MigrateCommand) Description() string {
+	return "Upgrade a vault to the current on-disk format"
+}
+
+func (migrateCmd *MigrateCommand) Run(args []string) error {
+	flagSet := newFlagSet("migrate")
+	dryRun := flagSet.Bool("dry-run", false, "Only list the files that would be upgraded")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+
+	report, err := migrateCmd.store.Migrate(*dryRun, migrateCmd.username, migrateCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	printRewrite(report, *dryRun, "upgraded", "Vault already uses the current format")
+	return nil
+}
+
+type RecompressCommand struct {
+	*Cli
+}
+
+func (recompressCmd *RecompressCommand) Name() string {
+	return "recompress"
+}
+
+func (recompressCmd *RecompressCommand) Description() string {
+	return "Rewrite the vault with the current compression setting"
+}
+
+func (recompressCmd *RecompressCommand) Run(args []string) error {
+	flagSet := newFlagSet("recompress")
+	dryRun := flagSet.Bool("dry-run", false, "Only list the files that would be rewritten")
+	if _, err := parseFlags(flagSet, args); err != nil {
+		return err
+	}
+
+	report, err := recompressCmd.store.Recompress(*dryRun, recompressCmd.username, recompressCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	printRewrite(report, *dryRun, "recompressed", "Vault already matches the compression setting")
+	return nil
+}
+
+// printRewrite summarizes a Migrate or Recompress run.
+func printRewrite(report *note.MigrateReport, dryRun bool, verb string, upToDate string) {
+	switch {
+	case dryRun:
+		for _, name := range report.Upgraded {
+			fmt.Println(name)
+		}
+		fmt.Printf("%d file(s) would be %s\n", len(report.Upgraded), verb)
+	case len(report.Upgraded) == 0:
+		fmt.Printf("✓ %s\n", upToDate)
+	case report.Backup != "":
+		fmt.Printf("✓ %d file(s) %s, originals kept in %s\n", len(report.Upgraded), verb, report.Backup)
+	default:
+		fmt.Printf("✓ %d file(s) %s\n", len(report.Upgraded), verb)
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Printf("⚠ %d file(s) could not be decrypted and were left as is, run 'pkm fsck'\n", len(report.Skipped))
+	}
+}
@@ -2,10 +2,15 @@ package cli
 
 import (
 	"errors"
+	"flag"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
 )
@@ -30,79 +35,609 @@ func (noteCmd *NoteCommand) Run(args []string) error {
 	cmd := args[0]
 	noteArgs := args[1:]
 	switch cmd {
+	case "edit", "history", "diff", "revert", "alias", "rename", "info", "set", "unset", "type":
+		// Accept short id prefixes and --title for the note operated on
+		resolved, err := noteCmd.resolveIds(noteArgs, 1)
+		if err != nil {
+			return err
+		}
+		noteArgs = resolved
+	}
+	switch cmd {
 	case "new":
+		return noteCmd.newNote(noteArgs)
+
+	case "edit":
 		if len(noteArgs) < 1 {
-			return errors.New("usage: note new <title>")
+			return errors.New("usage: note edit <id>")
 		}
 
-		content, err := tempEditor(nil)
+		noteData, fingerprint, err := noteCmd.loadForEdit(noteArgs[0])
 		if err != nil {
 			return err
 		}
-		if content == "" {
-			return errors.New("no content")
+		// Properties are edited as frontmatter above the content
+		buffer := note.JoinFrontmatter(noteData.Properties, noteData.Content)
+		newBuffer, err := noteCmd.editUntilValid(buffer, func(edited string) error {
+			properties, content, err := note.SplitFrontmatter(edited)
+			if err != nil {
+				return err
+			}
+			noteData.Properties = properties
+			noteData.Content = content
+			return note.CheckType(noteData)
+		})
+		if err != nil {
+			return err
 		}
-		noteData := note.NewNote(strings.Join(noteArgs, " "), content)
-		return noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider)
 
-	case "edit":
-		if len(noteArgs) < 1 {
-			return errors.New("usage: note new <title>")
+		unlock, err := noteCmd.lockStore()
+		if err != nil {
+			return keepEdit(newBuffer, err)
+		}
+		defer unlock()
+
+		// Refuse to clobber changes another process saved while the editor was open
+		current, err := noteCmd.store.Fingerprint(noteData.Id, noteCmd.username)
+		if err != nil {
+			return keepEdit(newBuffer, err)
 		}
+		if current != fingerprint {
+			return keepEdit(newBuffer, fmt.Errorf("note %s changed on disk while it was being edited; edit not saved", noteData.Id))
+		}
+		if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+			return keepEdit(newBuffer, err)
+		}
+		return nil
 
-		noteData, err := noteCmd.store.Load(noteArgs[0], noteCmd.username, noteCmd.keyProvider)
+	case "delete":
+		flagSet := newFlagSet("note delete")
+		refuseLinked := flagSet.Bool("refuse-linked", false, "Keep the note when other notes link to it")
+		expanded, err := noteCmd.expandTitles(noteArgs)
 		if err != nil {
 			return err
 		}
-		newContent, err := tempEditor(&noteData.Content)
+		if noteArgs, err = parseFlags(flagSet, expanded); err != nil {
+			return err
+		}
+		if noteArgs, err = noteCmd.resolveIds(noteArgs, 1); err != nil {
+			return err
+		}
+		if len(noteArgs) < 1 {
+			return errors.New("usage: note delete <id> [--refuse-linked]")
+		}
+		inbound, err := noteCmd.store.Trash(noteArgs[0], *refuseLinked, noteCmd.username, noteCmd.keyProvider)
 		if err != nil {
 			return err
 		}
-		noteData.Content = newContent
-		return noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider)
+		fmt.Printf("✓ Note %s moved to trash (restore with 'trash restore %s')\n", noteArgs[0], noteArgs[0])
+		if len(inbound) > 0 {
+			fmt.Printf("Removed the links from %d note(s): %s\n", len(inbound), strings.Join(inbound, ", "))
+		}
+		return nil
 
-	case "delete":
+	case "list":
+		flagSet := newFlagSet("note list")
+		sortBy := flagSet.String("sort", "title", "Order by title, created or updated")
+		reverse := flagSet.Bool("reverse", false, "Reverse the order")
+		long := flagSet.Bool("long", false, "Show types and properties")
+		noteType := flagSet.String("type", "", "Only list notes of this type")
+		if _, err := parseFlags(flagSet, noteArgs); err != nil {
+			return err
+		}
+		return noteCmd.printList(*sortBy, *reverse, *long, *noteType)
+
+	case "info":
 		if len(noteArgs) < 1 {
-			return errors.New("usage: note delete <id>")
+			return errors.New("usage: note info <id>")
 		}
-		return noteCmd.store.Delete(noteArgs[0], noteCmd.username)
+		return noteCmd.printInfo(noteArgs[0])
 
-	case "list":
-		return noteCmd.printList()
+	case "alias":
+		return noteCmd.setAlias(noteArgs)
+
+	case "rename":
+		return noteCmd.rename(noteArgs)
+
+	case "set":
+		return noteCmd.setProperties(noteArgs)
+
+	case "unset":
+		return noteCmd.unsetProperties(noteArgs)
+
+	case "type":
+		return noteCmd.setType(noteArgs)
+
+	case "history":
+		if len(noteArgs) < 1 {
+			return errors.New("usage: note history <id>")
+		}
+		return noteCmd.printHistory(noteArgs[0])
+
+	case "diff":
+		return noteCmd.printDiff(noteArgs)
+
+	case "revert":
+		return noteCmd.revert(noteArgs)
 
 	default:
 		return fmt.Errorf("unknown subcommand: %s", cmd)
 	}
 }
 
-func (noteCmd *NoteCommand) printList() error {
+// newNote handles `note new`: the editor opens on the expanded template,
+// if any, and the note is saved with the template's tags and links
+func (noteCmd *NoteCommand) newNote(args []string) error {
+	flagSet := newFlagSet("note new")
+	alias := flagSet.String("alias", "", "Alias for the note (default: derived from the title)")
+	noteType := flagSet.String("type", note.TypePermanent, "Note type: fleeting, literature, permanent or structure")
+	templateName := flagSet.String("template", "", "Template to start from")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 {
+		return errors.New("usage: note new [--alias <alias>] [--type <type>] [--template <name>] <title>")
+	}
+	noteData := note.NewNote(strings.Join(positional, " "), "")
+	if err := noteData.SetAlias(*alias); err != nil {
+		return err
+	}
+	if err := noteData.SetType(*noteType); err != nil {
+		return err
+	}
+
+	var expansion note.Expansion
+	var links []note.Link
+	if *templateName != "" {
+		template, err := noteCmd.store.LoadTemplate(*templateName, noteCmd.username, noteCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		expanded, err := template.Expand(note.TemplateVars{Title: noteData.Title, User: noteCmd.username, Now: time.Now()}, func(question string) (string, error) {
+			return noteCmd.prompt(question + ": ")
+		})
+		if err != nil {
+			return err
+		}
+		expansion = *expanded
+		// An explicit --type wins over the template's
+		typeGiven := false
+		flagSet.Visit(func(f *flag.Flag) { typeGiven = typeGiven || f.Name == "type" })
+		if expansion.Type != "" && !typeGiven {
+			noteData.Type = expansion.Type
+		}
+		if len(expansion.Tags) > 0 {
+			if err := noteData.AddTag(strings.Join(expansion.Tags, ",")); err != nil {
+				return err
+			}
+		}
+		// Resolve links before the editor opens so a stale template fails early
+		for _, ref := range expansion.Links {
+			id, err := noteCmd.store.Resolve(ref, noteCmd.username, noteCmd.keyProvider)
+			if err != nil {
+				return fmt.Errorf("template %s: link %s: %w", template.Name, ref, err)
+			}
+			links = append(links, note.Link{Target: id})
+		}
+	}
+
+	// Literature notes start with the source they need to fill in
+	properties := expansion.Properties
+	if _, ok := properties[note.SourceProperty]; noteData.Type == note.TypeLiterature && !ok {
+		properties = maps.Clone(properties)
+		if properties == nil {
+			properties = make(map[string]note.Property)
+		}
+		properties[note.SourceProperty] = note.Property{Type: note.PropertyString}
+	}
+	initial := note.JoinFrontmatter(properties, expansion.Content)
+	buffer, err := noteCmd.editUntilValid(initial, func(edited string) error {
+		properties, content, err := note.SplitFrontmatter(edited)
+		if err != nil {
+			return err
+		}
+		noteData.Properties = properties
+		noteData.Content = content
+		if content == "" && len(properties) == 0 {
+			// Checked below: an empty buffer gives up on the note
+			return nil
+		}
+		return note.CheckType(noteData)
+	})
+	if err != nil {
+		return err
+	}
+	if noteData.Content == "" && len(noteData.Properties) == 0 {
+		return errors.New("no content")
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if noteData.Alias == "" {
+		if noteData.Alias, err = noteCmd.store.SuggestAlias(noteData.Title, noteData.Id, noteCmd.username, noteCmd.keyProvider); err != nil {
+			return err
+		}
+	}
+	// Template links are linked back, as link add does
+	noteData.Links = links
+	if err := noteCmd.store.Create(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return keepEdit(buffer, err)
+	}
+	fmt.Printf("✓ Note %s created\n", noteLabel(noteData))
+	return nil
+}
+
+// loadForEdit reads a note together with the fingerprint of its stored revision
+func (noteCmd *NoteCommand) loadForEdit(noteId string) (*note.Note, string, error) {
+	store, unlock, err := noteCmd.store.Locked(noteCmd.username, false)
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	fingerprint, err := store.Fingerprint(noteId, noteCmd.username)
+	if err != nil {
+		return nil, "", err
+	}
+	noteData, err := store.Load(noteId, noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return nil, "", err
+	}
+	return noteData, fingerprint, nil
+}
+
+func (noteCmd *NoteCommand) printList(sortBy string, reverse bool, long bool, noteType string) error {
 	noteSummaryList, err := noteCmd.store.List(noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if noteSummaryList, err = filterType(noteSummaryList, noteType); err != nil {
+		return err
+	}
+	if err := sortSummaries(noteSummaryList, sortBy, reverse); err != nil {
+		return err
+	}
 	if len(noteSummaryList) == 0 {
 		fmt.Println("No Notes found!")
 		return nil
 	}
-	if err != nil {
-		return err
+	return printSummaries(noteSummaryList, long)
+}
+
+// sortSummaries orders notes by title A-Z, or newest first by creation or
+// update time; List already breaks ties by id
+func sortSummaries(noteSummaryList []note.NoteSummary, sortBy string, reverse bool) error {
+	var less func(a, b note.NoteSummary) bool
+	switch sortBy {
+	case "title":
+		less = func(a, b note.NoteSummary) bool { return a.Title < b.Title }
+	case "created":
+		less = func(a, b note.NoteSummary) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case "updated":
+		less = func(a, b note.NoteSummary) bool { return a.UpdatedAt.After(b.UpdatedAt) }
+	default:
+		return fmt.Errorf("unknown sort order %q (use title, created or updated)", sortBy)
+	}
+	sort.SliceStable(noteSummaryList, func(i, j int) bool {
+		return less(noteSummaryList[i], noteSummaryList[j])
+	})
+	if reverse {
+		slices.Reverse(noteSummaryList)
 	}
-	maxUID, maxTitle, maxTags := 3, 5, 4
+	return nil
+}
+
+// filterType keeps the notes of noteType, or all of them when it is empty
+func filterType(noteSummaryList []note.NoteSummary, noteType string) ([]note.NoteSummary, error) {
+	if noteType == "" {
+		return noteSummaryList, nil
+	}
+	if err := note.ValidateType(noteType); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(noteSummaryList, func(s note.NoteSummary) bool {
+		return note.TypeOf(s.Type) != noteType
+	}), nil
+}
+
+// printSummaries prints notes as a UID/ALIAS/TITLE/TAGS/UPDATED table,
+// with TYPE and PROPERTIES columns when long is set.
+func printSummaries(noteSummaryList []note.NoteSummary, long bool) error {
+	maxUID, maxAlias, maxTitle, maxTags, maxUpdated, maxProperties := 3, 5, 5, 4, len(time.DateTime), 10
 	for _, s := range noteSummaryList {
 		maxUID = max(maxUID, len(s.Id))
+		maxAlias = max(maxAlias, len(s.Alias))
 		maxTitle = max(maxTitle, len(s.Title))
 		maxTags = max(maxTags, len(strings.Join(s.Tags, ",")))
+		maxProperties = max(maxProperties, len(formatProperties(s.Properties)))
 	}
 
 	dashUID := strings.Repeat("-", maxUID)
+	dashAlias := strings.Repeat("-", maxAlias)
 	dashTitle := strings.Repeat("-", maxTitle)
 	dashTags := strings.Repeat("-", maxTags)
-	separator := fmt.Sprintf("%s\t%s\t%s", dashUID, dashTitle, dashTags)
+	dashUpdated := strings.Repeat("-", maxUpdated)
+	header := "UID\tALIAS\tTITLE\tTAGS\tUPDATED"
+	separator := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", dashUID, dashAlias, dashTitle, dashTags, dashUpdated)
+	if long {
+		header += "\tTYPE\tPROPERTIES"
+		separator += "\t----------\t" + strings.Repeat("-", maxProperties)
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "UID\tTITLE\tTAGS")
+	fmt.Fprintln(w, header)
 	fmt.Fprintln(w, separator)
 	for _, noteSummary := range noteSummaryList {
 		tags := strings.Join(noteSummary.Tags, ",")
-		fmt.Fprintf(w, "%s\t%s\t%s\n", noteSummary.Id, noteSummary.Title, tags)
+		updated := noteSummary.UpdatedAt.Local().Format(time.DateTime)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", noteSummary.Id, noteSummary.Alias, noteSummary.Title, tags, updated)
+		if long {
+			fmt.Fprintf(w, "\t%s\t%s", note.TypeOf(noteSummary.Type), formatProperties(noteSummary.Properties))
+		}
+		fmt.Fprintln(w)
 	}
 
 	return w.Flush()
 }
+
+// formatProperties renders properties as key=value pairs in key order
+func formatProperties(properties map[string]note.Property) string {
+	pairs := make([]string, 0, len(properties))
+	for _, key := range note.PropertyKeys(properties) {
+		pairs = append(pairs, key+"="+properties[key].String())
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// setProperties handles `note set <id> key=value...`
+func (noteCmd *NoteCommand) setProperties(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: note set <id> <key=value>...")
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	noteData, err := noteCmd.store.Load(args[0], noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	for _, pair := range args[1:] {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		if err := noteData.SetProperty(strings.TrimSpace(key), value); err != nil {
+			return err
+		}
+	}
+	if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Properties of note %s set\n", noteData.Id)
+	return nil
+}
+
+// unsetProperties handles `note unset <id> key...`
+func (noteCmd *NoteCommand) unsetProperties(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: note unset <id> <key>...")
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	noteData, err := noteCmd.store.Load(args[0], noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	for _, key := range args[1:] {
+		if err := noteData.RemoveProperty(key); err != nil {
+			return err
+		}
+	}
+	if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Properties of note %s removed\n", noteData.Id)
+	return nil
+}
+
+// setType handles `note type <id> <type>`
+func (noteCmd *NoteCommand) setType(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: note type <id> <%s>", strings.Join(note.NoteTypes, "|"))
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	noteData, err := noteCmd.store.Load(args[0], noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if err := noteData.SetType(args[1]); err != nil {
+		return err
+	}
+	if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Note %s is now a %s note\n", noteLabel(noteData), noteData.Type)
+	return nil
+}
+
+// setAlias handles `note alias <id> <alias>` and `note alias <id> --clear`
+func (noteCmd *NoteCommand) setAlias(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: note alias <id> <alias|--clear>")
+	}
+	alias := args[1]
+	if alias == "--clear" {
+		alias = ""
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	noteData, err := noteCmd.store.Load(args[0], noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if err := noteData.SetAlias(alias); err != nil {
+		return err
+	}
+	if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	if alias == "" {
+		fmt.Printf("✓ Alias of note %s cleared\n", noteData.Id)
+		return nil
+	}
+	fmt.Printf("✓ Note %s is now %s\n", noteData.Id, alias)
+	return nil
+}
+
+// rename changes a note's title. An alias derived from the old title
+// follows the new one, a hand-picked alias is kept
+func (noteCmd *NoteCommand) rename(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: note rename <id> <title>")
+	}
+
+	unlock, err := noteCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	noteData, err := noteCmd.store.Load(args[0], noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	derived := noteData.Alias != "" && derivedAlias(noteData.Alias, noteData.Title)
+	noteData.Title = strings.Join(args[1:], " ")
+	if derived {
+		if noteData.Alias, err = noteCmd.store.SuggestAlias(noteData.Title, noteData.Id, noteCmd.username, noteCmd.keyProvider); err != nil {
+			return err
+		}
+	}
+	if err := noteCmd.store.Save(noteData, noteCmd.username, noteCmd.keyProvider); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Note %s renamed\n", noteLabel(noteData))
+	return nil
+}
+
+// derivedAlias reports whether alias is what SuggestAlias makes of title,
+// the slug itself or the slug with a numeric suffix
+func derivedAlias(alias string, title string) bool {
+	slug := note.Slugify(title)
+	if slug == "" {
+		return false
+	}
+	rest, ok := strings.CutPrefix(alias, slug)
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(rest, "-")
+	return ok && suffix != "" && strings.Trim(suffix, "0123456789") == ""
+}
+
+// noteLabel names a note by id and, when it has one, alias
+func noteLabel(noteData *note.Note) string {
+	if noteData.Alias == "" {
+		return noteData.Id
+	}
+	return fmt.Sprintf("%s (%s)", noteData.Id, noteData.Alias)
+}
+
+// printInfo shows a note's metadata without its content
+func (noteCmd *NoteCommand) printInfo(noteId string) error {
+	noteData, err := noteCmd.store.Load(noteId, noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	revisions, err := noteCmd.store.History(noteId, noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+
+	// Notes saved before metadata was kept lack an update time
+	updated := "unknown"
+	if !noteData.UpdatedAt.IsZero() {
+		updated = noteData.UpdatedAt.Local().Format(time.DateTime)
+	}
+	words := noteData.Words
+	if noteData.ContentHash == "" {
+		words = len(strings.Fields(noteData.Content))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", noteData.Id)
+	fmt.Fprintf(w, "Title:\t%s\n", noteData.Title)
+	fmt.Fprintf(w, "Alias:\t%s\n", noteData.Alias)
+	fmt.Fprintf(w, "Type:\t%s\n", note.TypeOf(noteData.Type))
+	fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(noteData.Tags, ","))
+	fmt.Fprintf(w, "Properties:\t%s\n", formatProperties(noteData.Properties))
+	fmt.Fprintf(w, "Created:\t%s\n", noteData.CreatedAt.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Updated:\t%s\n", updated)
+	fmt.Fprintf(w, "Edits:\t%d\n", noteData.Edits)
+	fmt.Fprintf(w, "Words:\t%d\n", words)
+	fmt.Fprintf(w, "Content hash:\t%s\n", noteData.ContentHash)
+	fmt.Fprintf(w, "Links:\t%d\n", len(noteData.Links))
+	fmt.Fprintf(w, "Attachments:\t%d\n", len(noteData.Attachments))
+	fmt.Fprintf(w, "Revisions:\t%d\n", len(revisions))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if noteData.Type == note.TypeStructure {
+		return noteCmd.printChildren(noteData)
+	}
+	return nil
+}
+
+// printChildren lists the links of a structure note in their order
+func (noteCmd *NoteCommand) printChildren(noteData *note.Note) error {
+	if len(noteData.Links) == 0 {
+		fmt.Println("\nNo children yet (add them with 'link add')")
+		return nil
+	}
+	noteSummaryList, err := noteCmd.store.List(noteCmd.username, noteCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	titles := make(map[string]string, len(noteSummaryList))
+	for _, summary := range noteSummaryList {
+		titles[summary.Id] = summary.Title
+	}
+
+	fmt.Println("\nChildren:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, link := range noteData.Links {
+		title, ok := titles[link.Target]
+		if !ok {
+			title = "(missing)"
+		}
+		fmt.Fprintf(w, "  %d.\t%s\t%s\n", i+1, link.Target, title)
+	}
+	return w.Flush()
+}
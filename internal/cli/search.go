@@ -3,6 +3,9 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"slices"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
 )
 
 type SearchCommand struct {
@@ -14,7 +17,7 @@ func (searchCmd *SearchCommand) Name() string {
 }
 
 func (searchCmd *SearchCommand) Description() string {
-	return "Search notes by keywords or tags"
+	return "Search notes by keywords, tags or properties"
 }
 
 func (searchCmd *SearchCommand) Run(args []string) error {
@@ -23,19 +26,54 @@ func (searchCmd *SearchCommand) Run(args []string) error {
 		return errors.New("missing arguments")
 	}
 	cmd := args[0]
-	linkArgs := args[1:]
-	if len(linkArgs) < 1 {
+	flagSet := newFlagSet("search " + cmd)
+	noteType := flagSet.String("type", "", "Only show notes of this type")
+	exact := flagSet.Bool("exact", false, "Match tags exactly, not the tags below them")
+	terms, err := parseFlags(flagSet, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(terms) < 1 {
 		return errors.New("missing operand")
 	}
+	searchType := cmd
+	if *exact {
+		if cmd != "tag" {
+			return errors.New("--exact only applies to 'search tag'")
+		}
+		searchType = "tag-exact"
+	}
 	switch cmd {
-	case "keyword", "tag":
-		results, err := searchCmd.store.Search(cmd, linkArgs, searchCmd.username, searchCmd.keyProvider)
+	case "keyword", "tag", "prop":
+		results, err := searchCmd.store.Search(searchType, terms, searchCmd.username, searchCmd.keyProvider)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("%s(s) found in %v", cmd, results)
+		return searchCmd.printResults(results, *noteType)
 	default:
 		return fmt.Errorf("unknown subcommand: %s", cmd)
 	}
-	return nil
+}
+
+// printResults renders matching notes from the manifest, so search never
+// decrypts the notes it found.
+func (searchCmd *SearchCommand) printResults(results []string, noteType string) error {
+	noteSummaryList, err := searchCmd.store.List(searchCmd.username, searchCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	var matches []note.NoteSummary
+	for _, summary := range noteSummaryList {
+		if slices.Contains(results, summary.Id) {
+			matches = append(matches, summary)
+		}
+	}
+	if matches, err = filterType(matches, noteType); err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Println("No Notes found!")
+		return nil
+	}
+	return printSummaries(matches, false)
 }
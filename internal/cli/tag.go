@@ -3,6 +3,13 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
 )
 
 type TagCommand struct {
@@ -23,10 +30,52 @@ func (tagCmd *TagCommand) Run(args []string) error {
 		return errors.New("missing arguments")
 	}
 	cmd := args[0]
-	tagArgs := args[1:]
+	switch cmd {
+	case "list":
+		if len(args) == 1 {
+			return tagCmd.printTags()
+		}
+		return tagCmd.printNoteTags(args[1:])
+	case "orphans":
+		return tagCmd.printOrphans()
+	case "tree":
+		return tagCmd.printTree()
+	case "alias":
+		return tagCmd.alias(args[1:])
+	case "suggest":
+		return tagCmd.printSuggestions()
+	case "rename":
+		if len(args) != 3 {
+			return errors.New("usage: tag rename <old> <new>")
+		}
+		return tagCmd.rename(args[1:2], args[2])
+	case "merge":
+		flagSet := newFlagSet("tag merge")
+		into := flagSet.String("into", "", "Tag that replaces the merged ones")
+		tags, err := parseFlags(flagSet, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(tags) < 1 || *into == "" {
+			return errors.New("usage: tag merge <tag>... --into <tag>")
+		}
+		return tagCmd.rename(tags, *into)
+	}
+
+	unlock, err := tagCmd.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tagArgs, err := tagCmd.resolveIds(args[1:], 1)
+	if err != nil {
+		return err
+	}
 	if len(tagArgs) < 2 {
 		return errors.New("missing operand")
 	}
+
 	switch cmd {
 	case "add":
 		noteData, err := tagCmd.Cli.GetStore().Load(tagArgs[0], tagCmd.Cli.GetUsername(), tagCmd.Cli.GetKeyProvider())
@@ -44,7 +93,16 @@ func (tagCmd *TagCommand) Run(args []string) error {
 		if err != nil {
 			return err
 		}
-		if err := noteData.RemoveTag(tagArgs[1]); err != nil {
+		// Notes hold the canonical tag an alias stands for
+		synonyms, err := tagCmd.store.TagAliases(tagCmd.username, tagCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		tags, err := note.CanonicalTagList(tagArgs[1], synonyms)
+		if err != nil {
+			return err
+		}
+		if err := noteData.RemoveTag(tags); err != nil {
 			return err
 		}
 		if err := tagCmd.Cli.GetStore().Save(noteData, tagCmd.Cli.GetUsername(), tagCmd.Cli.GetKeyProvider()); err != nil {
@@ -55,3 +113,194 @@ func (tagCmd *TagCommand) Run(args []string) error {
 	}
 	return nil
 }
+
+// printTags lists every tag in the vault with the number of notes using it
+func (tagCmd *TagCommand) printTags() error {
+	tags, err := tagCmd.store.Tags(tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags found!")
+		return nil
+	}
+	names := slices.Sorted(maps.Keys(tags))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tNOTES")
+	fmt.Fprintln(w, "---\t-----")
+	for _, tag := range names {
+		fmt.Fprintf(w, "%s\t%d\n", tag, len(tags[tag]))
+	}
+	return w.Flush()
+}
+
+// printNoteTags handles `tag list <note-id>`
+func (tagCmd *TagCommand) printNoteTags(args []string) error {
+	resolved, err := tagCmd.resolveIds(args, 1)
+	if err != nil {
+		return err
+	}
+	noteData, err := tagCmd.store.Load(resolved[0], tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(noteData.Tags) == 0 {
+		fmt.Printf("Note %s has no tags\n", noteLabel(noteData))
+		return nil
+	}
+	for _, tag := range noteData.Tags {
+		fmt.Println(tag)
+	}
+	return nil
+}
+
+// printOrphans lists the tags used by a single note, often typos
+func (tagCmd *TagCommand) printOrphans() error {
+	tags, err := tagCmd.store.Tags(tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	maps.DeleteFunc(tags, func(_ string, noteIds []string) bool { return len(noteIds) != 1 })
+	if len(tags) == 0 {
+		fmt.Println("No orphan tags found!")
+		return nil
+	}
+	noteSummaryList, err := tagCmd.store.List(tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	titles := make(map[string]string, len(noteSummaryList))
+	for _, summary := range noteSummaryList {
+		titles[summary.Id] = summary.Title
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tUID\tTITLE")
+	fmt.Fprintln(w, "---\t---\t-----")
+	for _, tag := range slices.Sorted(maps.Keys(tags)) {
+		id := tags[tag][0]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tag, id, titles[id])
+	}
+	return w.Flush()
+}
+
+// rename handles `tag rename` and `tag merge`, rewriting every note tagged
+// with one of from in a single write
+func (tagCmd *TagCommand) rename(from []string, to string) error {
+	changed, err := tagCmd.store.RenameTags(from, to, tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	to, _ = note.NormalizeTag(to)
+	fmt.Printf("✓ %s -> %s on %d note(s)\n", strings.Join(from, ", "), to, changed)
+	return nil
+}
+
+// printTree prints the tag hierarchy, each tag with the number of notes
+// tagged with it or with a tag below it
+func (tagCmd *TagCommand) printTree() error {
+	tags, err := tagCmd.store.Tags(tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		fmt.Println("No tags found!")
+		return nil
+	}
+	// Parents nobody uses directly still head their subtree
+	subtrees := make(map[string][]string)
+	for tag, noteIds := range tags {
+		levels := strings.Split(tag, note.TagSeparator)
+		for i := range levels {
+			parent := strings.Join(levels[:i+1], note.TagSeparator)
+			for _, id := range noteIds {
+				if !slices.Contains(subtrees[parent], id) {
+					subtrees[parent] = append(subtrees[parent], id)
+				}
+			}
+		}
+	}
+	// Order level by level so cs/graphs follows cs, not cs-theory
+	order := slices.SortedFunc(maps.Keys(subtrees), func(a, b string) int {
+		return slices.Compare(strings.Split(a, note.TagSeparator), strings.Split(b, note.TagSeparator))
+	})
+	for _, tag := range order {
+		depth := strings.Count(tag, note.TagSeparator)
+		name := tag[strings.LastIndex(tag, note.TagSeparator)+1:]
+		fmt.Printf("%s%s (%d)\n", strings.Repeat("  ", depth), name, len(subtrees[tag]))
+	}
+	return nil
+}
+
+// alias handles `tag alias add|remove|list`
+func (tagCmd *TagCommand) alias(args []string) error {
+	if len(args) < 1 {
+		return errors.New("usage: tag alias <add|remove|list> [arguments]")
+	}
+	switch args[0] {
+	case "add":
+		if len(args) != 3 {
+			return errors.New("usage: tag alias add <alias> <tag>")
+		}
+		changed, err := tagCmd.store.AddTagAlias(args[1], args[2], tagCmd.username, tagCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ %s is now an alias of %s (%d note(s) retagged)\n", args[1], args[2], changed)
+		return nil
+
+	case "remove", "rm":
+		if len(args) != 2 {
+			return errors.New("usage: tag alias remove <alias>")
+		}
+		if err := tagCmd.store.RemoveTagAlias(args[1], tagCmd.username, tagCmd.keyProvider); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Alias %s removed\n", args[1])
+		return nil
+
+	case "list":
+		synonyms, err := tagCmd.store.TagAliases(tagCmd.username, tagCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		if len(synonyms) == 0 {
+			fmt.Println("No tag aliases found!")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ALIAS\tTAG")
+		fmt.Fprintln(w, "-----\t---")
+		for _, alias := range slices.Sorted(maps.Keys(synonyms)) {
+			fmt.Fprintf(w, "%s\t%s\n", alias, synonyms[alias])
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown subcommand: alias %s", args[0])
+	}
+}
+
+// printSuggestions shows clusters of tags that look like synonyms, with
+// the commands that would alias them
+func (tagCmd *TagCommand) printSuggestions() error {
+	tags, err := tagCmd.store.Tags(tagCmd.username, tagCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	suggestions := note.SuggestTagAliases(tags)
+	if len(suggestions) == 0 {
+		fmt.Println("No likely synonyms found!")
+		return nil
+	}
+	for _, suggestion := range suggestions {
+		counts := make([]string, 0, len(suggestion.Aliases))
+		for _, alias := range suggestion.Aliases {
+			counts = append(counts, fmt.Sprintf("%s (%d)", alias, len(tags[alias])))
+		}
+		fmt.Printf("%s (%d) <- %s\n", suggestion.Canonical, len(tags[suggestion.Canonical]), strings.Join(counts, ", "))
+		for _, alias := range suggestion.Aliases {
+			fmt.Printf("  pkm tag alias add %s %s\n", alias, suggestion.Canonical)
+		}
+	}
+	return nil
+}
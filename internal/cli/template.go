@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// templateSkeleton is what `template add` opens the editor on
+const templateSkeleton = `---
+# Placeholders, in double braces: date, time, datetime, title, user and
+# prompt:<question>, which asks while the note is created.
+# type: fleeting, literature, permanent or structure
+tags: []
+links: []
+---
+`
+
+type TemplateCommand struct {
+	*Cli
+}
+
+func (templateCmd *TemplateCommand) Name() string {
+	return "template"
+}
+
+func (templateCmd *TemplateCommand) Description() string {
+	return "Manage encrypted templates for new notes"
+}
+
+func (templateCmd *TemplateCommand) Run(args []string) error {
+	if len(args) < 1 {
+		templateCmd.Help()
+		return errors.New("missing arguments")
+	}
+	cmd := args[0]
+	templateArgs := args[1:]
+	switch cmd {
+	case "list":
+		return templateCmd.printList()
+
+	case "add":
+		if len(templateArgs) < 1 {
+			return errors.New("usage: template add <name>")
+		}
+		name := templateArgs[0]
+		if err := note.ValidateTemplateName(name); err != nil {
+			return err
+		}
+		if _, err := templateCmd.store.LoadTemplate(name, templateCmd.username, templateCmd.keyProvider); err == nil {
+			return fmt.Errorf("template %s already exists (use 'template edit')", name)
+		}
+		text, err := templateCmd.editUntilValid(templateSkeleton, validTemplate)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			return errors.New("empty template")
+		}
+		template := &note.Template{Name: name, Text: text}
+		if err := templateCmd.store.SaveTemplate(template, templateCmd.username, templateCmd.keyProvider); err != nil {
+			return keepEdit(text, err)
+		}
+		fmt.Printf("✓ Template %s added\n", name)
+
+	case "edit":
+		if len(templateArgs) < 1 {
+			return errors.New("usage: template edit <name>")
+		}
+		template, err := templateCmd.store.LoadTemplate(templateArgs[0], templateCmd.username, templateCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		text, err := templateCmd.editUntilValid(template.Text, validTemplate)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == "" {
+			return errors.New("empty template (use 'template rm' to remove it)")
+		}
+		template.Text = text
+		if err := templateCmd.store.SaveTemplate(template, templateCmd.username, templateCmd.keyProvider); err != nil {
+			return keepEdit(text, err)
+		}
+		fmt.Printf("✓ Template %s saved\n", template.Name)
+
+	case "show":
+		if len(templateArgs) < 1 {
+			return errors.New("usage: template show <name>")
+		}
+		template, err := templateCmd.store.LoadTemplate(templateArgs[0], templateCmd.username, templateCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Print(template.Text)
+
+	case "rm", "remove":
+		if len(templateArgs) < 1 {
+			return errors.New("usage: template rm <name>")
+		}
+		if err := templateCmd.store.DeleteTemplate(templateArgs[0], templateCmd.username); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Template %s removed\n", templateArgs[0])
+
+	default:
+		return fmt.Errorf("unknown subcommand: %s", cmd)
+	}
+	return nil
+}
+
+func (templateCmd *TemplateCommand) printList() error {
+	templates, err := templateCmd.store.ListTemplates(templateCmd.username, templateCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Println("No templates found!")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tUPDATED\tFIRST LINE")
+	fmt.Fprintln(w, "----\t-------\t----------")
+	for _, template := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", template.Name, template.UpdatedAt.Local().Format(time.DateTime), firstContentLine(template.Text))
+	}
+	return w.Flush()
+}
+
+// firstContentLine returns the first non-empty line after the frontmatter
+func firstContentLine(text string) string {
+	if _, content, err := note.SplitFrontmatter(text); err == nil {
+		text = content
+	}
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// validTemplate accepts edited template text that expands. An empty text
+// is left to the caller, which gives up on it
+func validTemplate(edited string) error {
+	if strings.TrimSpace(edited) == "" {
+		return nil
+	}
+	return note.ValidateTemplate(edited)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+type TrashCommand struct {
+	*Cli
+}
+
+func (trashCmd *TrashCommand) Name() string {
+	return "trash"
+}
+
+func (trashCmd *TrashCommand) Description() string {
+	return "List, restore and empty deleted notes"
+}
+
+func (trashCmd *TrashCommand) Run(args []string) error {
+	if len(args) < 1 {
+		trashCmd.Help()
+		return errors.New("missing arguments")
+	}
+	cmd := args[0]
+	trashArgs := args[1:]
+	switch cmd {
+	case "list":
+		return trashCmd.printList()
+
+	case "restore":
+		noteId, err := trashCmd.resolveTrashed(trashArgs)
+		if err != nil {
+			return err
+		}
+		if err := trashCmd.store.Restore(noteId, trashCmd.username, trashCmd.keyProvider); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Note %s restored\n", noteId)
+
+	case "empty":
+		flagSet := newFlagSet("trash empty")
+		olderThan := flagSet.String("older-than", "0", "Only purge notes deleted longer ago (e.g. 30d)")
+		if _, err := parseFlags(flagSet, trashArgs); err != nil {
+			return err
+		}
+		age, err := note.ParseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		purged, err := trashCmd.store.EmptyTrash(age, trashCmd.username, trashCmd.keyProvider)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ %d note(s) permanently deleted\n", purged)
+
+	default:
+		return fmt.Errorf("unknown subcommand: %s", cmd)
+	}
+	return nil
+}
+
+// resolveTrashed resolves the note of a restore, named by id, id prefix or
+// --title, against the trashed notes.
+func (trashCmd *TrashCommand) resolveTrashed(args []string) (string, error) {
+	flagSet := newFlagSet("trash restore")
+	title := flagSet.String("title", "", "Restore the trashed note with this title")
+	positional, err := parseFlags(flagSet, args)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case *title != "" && len(positional) == 0:
+		return trashCmd.store.ResolveTrashedTitle(*title, trashCmd.username, trashCmd.keyProvider)
+	case *title == "" && len(positional) == 1:
+		return trashCmd.store.ResolveTrashed(positional[0], trashCmd.username, trashCmd.keyProvider)
+	}
+	return "", errors.New("usage: trash restore <id> | --title <title>")
+}
+
+func (trashCmd *TrashCommand) printList() error {
+	entries, err := trashCmd.store.ListTrash(trashCmd.username, trashCmd.keyProvider)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Trash is empty!")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tTITLE\tDELETED\tLINKED FROM")
+	fmt.Fprintln(w, "---\t-----\t-------\t-----------")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			entry.Note.Id,
+			entry.Note.Title,
+			entry.DeletedAt.Local().Format(time.DateTime),
+			strings.Join(entry.InboundLinks, ","))
+	}
+	return w.Flush()
+}
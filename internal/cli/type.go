@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"bufio"
+	"io"
+
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
 )
@@ -14,8 +17,11 @@ type Command interface {
 
 type Cli struct {
 	store       *note.Store
+	storeDir    string
 	username    string
 	keyProvider *crypt.KeyProvider
+	// input answers prompts; nil reads from stdin
+	input *bufio.Reader
 }
 
 // GetStore returns the note store
@@ -47,3 +53,24 @@ func (c *Cli) SetUsername(u string) {
 func (c *Cli) SetKeyProvider(kp *crypt.KeyProvider) {
 	c.keyProvider = kp
 }
+
+// SetInput sets where prompts read their answers (for testing)
+func (c *Cli) SetInput(r io.Reader) {
+	c.input = bufio.NewReader(r)
+}
+
+// lockStore takes the user's store lock exclusively so that a
+// read-modify-write cycle is not interleaved with another pkm process.
+// Until unlocked the command's store is the locked view of it.
+func (c *Cli) lockStore() (func(), error) {
+	store, unlock, err := c.store.Locked(c.username, true)
+	if err != nil {
+		return nil, err
+	}
+	base := c.store
+	c.store = store
+	return func() {
+		c.store = base
+		unlock()
+	}, nil
+}
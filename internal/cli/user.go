@@ -61,7 +61,7 @@ func (userCmd *UserCommand) initUser(username string) error {
 		return err
 	}
 
-	if err := crypt.InitUser(userCmd.CLI.store.StoreLocation, username, password); err != nil {
+	if err := crypt.InitUser(userCmd.CLI.storeDir, username, password); err != nil {
 		return err
 	}
 
@@ -80,7 +80,7 @@ func (userCmd *UserCommand) changePassword(username string) error {
 		return err
 	}
 
-	if err := crypt.ChangePassword(userCmd.CLI.store.StoreLocation, username, oldPassword, newPassword); err != nil {
+	if err := crypt.ChangePassword(userCmd.CLI.storeDir, username, oldPassword, newPassword); err != nil {
 		return err
 	}
 
@@ -94,7 +94,7 @@ func (userCmd *UserCommand) importUser() error {
 		return err
 	}
 
-	if err := crypt.ImportUser(userCmd.CLI.store.StoreLocation, userDataString); err != nil {
+	if err := crypt.ImportUser(userCmd.CLI.storeDir, userDataString); err != nil {
 		return err
 	}
 
@@ -107,7 +107,7 @@ func (userCmd *UserCommand) exportUser(username string) error {
 		return err
 	}
 
-	if err := crypt.ExportUser(userCmd.CLI.store.StoreLocation, username, password); err != nil {
+	if err := crypt.ExportUser(userCmd.CLI.storeDir, username, password); err != nil {
 		return err
 	}
 
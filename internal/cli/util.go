@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 )
 
 func tempEditor(content *string) (string, error) {
@@ -36,3 +42,118 @@ func tempEditor(content *string) (string, error) {
 	}
 	return string(newContent), nil
 }
+
+// editUntilValid opens the editor on buffer until accept takes what was
+// written. After a rejected edit it offers to edit again; declined, the
+// edit is kept in a file named by the returned error
+func (c *Cli) editUntilValid(buffer string, accept func(edited string) error) (string, error) {
+	for {
+		edited, err := tempEditor(&buffer)
+		if err != nil {
+			return "", err
+		}
+		if err = accept(edited); err == nil {
+			return edited, nil
+		}
+		buffer = edited
+		fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+		answer, promptErr := c.prompt("Edit again? [Y/n] ")
+		if promptErr != nil || !(answer == "" || strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes")) {
+			return "", keepEdit(edited, err)
+		}
+	}
+}
+
+// keepEdit saves an edit that could not be stored to a file and adds its
+// path to err, so the edit is not lost
+func keepEdit(edited string, err error) error {
+	file, createErr := os.CreateTemp("", "pkm-edit-*.txt")
+	if createErr != nil {
+		return fmt.Errorf("%w (edit lost: %v)", err, createErr)
+	}
+	defer file.Close()
+	if _, writeErr := file.WriteString(edited); writeErr != nil {
+		return fmt.Errorf("%w (edit lost: %v)", err, writeErr)
+	}
+	return fmt.Errorf("%w; edit kept in %s", err, file.Name())
+}
+
+// prompt prints question and returns the trimmed line typed in answer. It
+// returns io.EOF once the input is exhausted
+func (c *Cli) prompt(question string) (string, error) {
+	if c.input == nil {
+		c.input = bufio.NewReader(os.Stdin)
+	}
+	fmt.Print(question)
+	line, err := c.input.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// newFlagSet returns a flag set for subcommand flags that reports errors
+// instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	return flagSet
+}
+
+// parseFlags parses flags given anywhere among args, unlike flag.Parse
+// which stops at the first positional argument, and returns the positionals
+func parseFlags(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flagSet.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// expandTitles replaces every `--title <title>` in args with the id of the
+// note it names, keeping its position so it can stand in for any note id
+func (c *Cli) expandTitles(args []string) ([]string, error) {
+	var expanded []string
+	for i := 0; i < len(args); i++ {
+		title, ok := strings.CutPrefix(args[i], "--title=")
+		if !ok {
+			if args[i] != "--title" {
+				expanded = append(expanded, args[i])
+				continue
+			}
+			if i+1 == len(args) {
+				return nil, errors.New("--title needs a value")
+			}
+			i++
+			title = args[i]
+		}
+		id, err := c.store.ResolveTitle(title, c.username, c.keyProvider)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, id)
+	}
+	return expanded, nil
+}
+
+// resolveIds resolves the first n of args as note ids, short prefixes or
+// --title lookups and returns args with the full ids in place
+func (c *Cli) resolveIds(args []string, n int) ([]string, error) {
+	args, err := c.expandTitles(args)
+	if err != nil {
+		return nil, err
+	}
+	for i := range min(n, len(args)) {
+		if args[i], err = c.store.Resolve(args[i], c.username, c.keyProvider); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
@@ -3,8 +3,10 @@ package crypt
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -43,7 +45,7 @@ func NewKeyProvider(pkmDir string, username string, password string) (*KeyProvid
 	kek := pbkdf2.Key([]byte(password), salt, PBKDFIter, KEKSize, sha256.New)
 
 	// Decrypt DEK
-	dek, err := decryptAESGCM(kek, nonce, encryptedDEK)
+	dek, err := decryptAESGCM(kek, nonce, encryptedDEK, nil)
 	if err != nil {
 		return nil, fmt.Errorf("decrypt DEK failed (wrong password?): %w", err)
 	}
@@ -54,6 +56,23 @@ func NewKeyProvider(pkmDir string, username string, password string) (*KeyProvid
 	}, nil
 
 }
+
+// NewKeyProviderWithDEK returns a provider for an already unwrapped DEK,
+// bypassing .crypt (for tests and embedding)
+func NewKeyProviderWithDEK(username string, dek []byte) (*KeyProvider, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username required")
+	}
+	if len(dek) != DEKSize {
+		return nil, fmt.Errorf("DEK must be %d bytes", DEKSize)
+	}
+
+	return &KeyProvider{
+		username: username,
+		dek:      append([]byte{}, dek...),
+	}, nil
+}
+
 func InitUser(pkmDir, username, password string) error {
 	if username == "" || password == "" {
 		return fmt.Errorf("username and password required")
@@ -93,7 +112,7 @@ func InitUser(pkmDir, username, password string) error {
 		return err
 	}
 
-	encryptedDEK, err := encryptAESGCM(kek, nonce, dek)
+	encryptedDEK, err := encryptAESGCM(kek, nonce, dek, nil)
 	if err != nil {
 		return err
 	}
@@ -164,7 +183,7 @@ func ChangePassword(pkmDir, username, oldPassword, newPassword string) error {
 		return err
 	}
 
-	newEncryptedDEK, err := encryptAESGCM(newKEK, newNonce, kp.dek)
+	newEncryptedDEK, err := encryptAESGCM(newKEK, newNonce, kp.dek, nil)
 	if err != nil {
 		return err
 	}
@@ -186,12 +205,23 @@ func (kp *KeyProvider) DEK() []byte {
 
 // Encrypt encrypts plaintext with session DEK
 func (kp *KeyProvider) Encrypt(plaintext []byte) ([]byte, error) {
+	return kp.EncryptWithAAD(plaintext, nil)
+}
+
+// Decrypt decrypts ciphertext with session DEK
+func (kp *KeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	return kp.DecryptWithAAD(ciphertext, nil)
+}
+
+// EncryptWithAAD encrypts plaintext with session DEK and authenticates aad
+// alongside it, so that aad cannot be altered without Decrypt failing
+func (kp *KeyProvider) EncryptWithAAD(plaintext []byte, aad []byte) ([]byte, error) {
 	nonce := make([]byte, 12)
 	if _, err := rand.Read(nonce); err != nil {
 		return nil, err
 	}
 
-	ciphertext, err := encryptAESGCM(kp.dek, nonce, plaintext)
+	ciphertext, err := encryptAESGCM(kp.dek, nonce, plaintext, aad)
 	if err != nil {
 		return nil, err
 	}
@@ -199,8 +229,8 @@ func (kp *KeyProvider) Encrypt(plaintext []byte) ([]byte, error) {
 	return append(nonce, ciphertext...), nil
 }
 
-// Decrypt decrypts ciphertext with session DEK
-func (kp *KeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+// DecryptWithAAD decrypts ciphertext sealed by EncryptWithAAD with the same aad
+func (kp *KeyProvider) DecryptWithAAD(ciphertext []byte, aad []byte) ([]byte, error) {
 	if len(ciphertext) < 12 {
 		return nil, fmt.Errorf("ciphertext too short")
 	}
@@ -208,11 +238,31 @@ func (kp *KeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
 	nonce := ciphertext[:12]
 	encrypted := ciphertext[12:]
 
-	return decryptAESGCM(kp.dek, nonce, encrypted)
+	return decryptAESGCM(kp.dek, nonce, encrypted, aad)
+}
+
+// KeyID identifies the DEK without revealing it, so files can record
+// which key sealed them
+func (kp *KeyProvider) KeyID() []byte {
+	mac := hmac.New(sha256.New, kp.dek)
+	mac.Write([]byte("pkm key id"))
+	return mac.Sum(nil)[:KeyIDSize]
+}
+
+// ContentHash returns a keyed hash of data for content addressing. It is
+// an HMAC under a key derived from the DEK, so equal files get equal names
+// without revealing their contents to anyone lacking the key.
+func (kp *KeyProvider) ContentHash(data []byte) string {
+	subkey := hmac.New(sha256.New, kp.dek)
+	subkey.Write([]byte("pkm content hash"))
+
+	mac := hmac.New(sha256.New, subkey.Sum(nil))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // Helper: encrypt with AES-GCM
-func encryptAESGCM(key, nonce, plaintext []byte) ([]byte, error) {
+func encryptAESGCM(key, nonce, plaintext, aad []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -223,11 +273,11 @@ func encryptAESGCM(key, nonce, plaintext []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return aesgcm.Seal(nil, nonce, plaintext, nil), nil
+	return aesgcm.Seal(nil, nonce, plaintext, aad), nil
 }
 
 // Helper: decrypt with AES-GCM
-func decryptAESGCM(key, nonce, ciphertext []byte) ([]byte, error) {
+func decryptAESGCM(key, nonce, ciphertext, aad []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -238,7 +288,7 @@ func decryptAESGCM(key, nonce, ciphertext []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return aesgcm.Open(nil, nonce, ciphertext, nil)
+	return aesgcm.Open(nil, nonce, ciphertext, aad)
 }
 
 func (kp *KeyProvider) GetDEK() []byte {
@@ -5,6 +5,7 @@ const (
 	KEKSize   = 32 // AES-256
 	SaltSize  = 16
 	PBKDFIter = 100000
+	KeyIDSize = 8
 )
 
 type KeyProvider struct {
@@ -55,6 +55,10 @@ func normalize(s string) string {
 		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == ' ' {
 			return r
 		}
+		// Keep words on separate lines apart
+		if unicode.IsSpace(r) {
+			return ' '
+		}
 		return -1
 	}, s)
 }
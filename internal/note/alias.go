@@ -0,0 +1,174 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const aliasesBlob = ".aliases.pkm"
+
+// MaxAliasLength bounds aliases derived from long titles.
+const MaxAliasLength = 64
+
+// ProblemAlias is reported when the alias map no longer matches the notes.
+const ProblemAlias = "alias"
+
+// Slugify derives an alias from title: lowercase letters and digits with
+// single hyphens between words, e.g. "Graph Theory 101" -> "graph-theory-101".
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case ('a' <= r && r <= 'z') || ('0' <= r && r <= '9'):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+		default:
+			hyphen = true
+		}
+		if b.Len() >= MaxAliasLength {
+			break
+		}
+	}
+	return strings.TrimRight(b.String()[:min(b.Len(), MaxAliasLength)], "-")
+}
+
+// ValidateAlias accepts what Slugify produces, except ids: Resolve tries
+// ids before aliases, so such an alias would name another note.
+func ValidateAlias(alias string) error {
+	if alias == "" || len(alias) > MaxAliasLength || Slugify(alias) != alias {
+		return fmt.Errorf("invalid alias %q: use lowercase letters, digits and single hyphens (at most %d characters)", alias, MaxAliasLength)
+	}
+	if _, err := uuid.Parse(alias); err == nil {
+		return fmt.Errorf("invalid alias %q: looks like a note id", alias)
+	}
+	return nil
+}
+
+// SetAlias sets the note's alias, or clears it when alias is empty.
+func (n *Note) SetAlias(alias string) error {
+	if alias != "" {
+		if err := ValidateAlias(alias); err != nil {
+			return err
+		}
+	}
+	n.Alias = alias
+	return nil
+}
+
+// readAliases returns the user's alias -> note id map. When it is missing
+// or unreadable it is rebuilt from the manifest.
+func (store *Store) readAliases(username string, kp *crypt.KeyProvider, manifest *Manifest) (map[string]string, error) {
+	fileData, err := store.blobs.Get(username, aliasesBlob)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	aliases := make(map[string]string)
+	if err == nil && openJSON(fileData, kp, &aliases, "aliases") == nil {
+		if aliases == nil {
+			aliases = make(map[string]string)
+		}
+		return aliases, nil
+	}
+
+	aliases = make(map[string]string)
+	for id, entry := range manifest.Notes {
+		if entry.Alias != "" {
+			aliases[entry.Alias] = id
+		}
+	}
+	return aliases, nil
+}
+
+// stageAliases adds the encrypted alias map to b.
+func stageAliases(b *Batch, aliases map[string]string, kp *crypt.KeyProvider, compress bool) error {
+	payload, err := sealJSON(kp, aliases, compress)
+	if err != nil {
+		return err
+	}
+	b.Put(aliasesBlob, payload)
+	return nil
+}
+
+// SuggestAlias returns an alias derived from title that no note other
+// than noteId uses, adding -2, -3... to the slug as needed. It returns ""
+// when title has no letters or digits or is a note id.
+func (store *Store) SuggestAlias(title string, noteId string, username string, kp *crypt.KeyProvider) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return "", err
+	}
+	aliases, err := store.readAliases(username, kp, manifest)
+	if err != nil {
+		return "", err
+	}
+	base := Slugify(title)
+	if ValidateAlias(base) != nil {
+		return "", nil
+	}
+	alias := base
+	for i := 2; ; i++ {
+		if owner, taken := aliases[alias]; !taken || owner == noteId {
+			return alias, nil
+		}
+		suffix := "-" + strconv.Itoa(i)
+		alias = strings.TrimRight(base[:min(len(base), MaxAliasLength-len(suffix))], "-") + suffix
+	}
+}
+
+// checkAliases compares the stored alias map against the readable notes.
+func (store *Store) checkAliases(username string, kp *crypt.KeyProvider, notes map[string]*Note) ([]Problem, error) {
+	fileData, err := store.blobs.Get(username, aliasesBlob)
+	if errors.Is(err, fs.ErrNotExist) {
+		for _, note := range notes {
+			if note.Alias != "" {
+				return []Problem{{Kind: ProblemAlias, Name: aliasesBlob, Detail: "alias map missing"}}, nil
+			}
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	aliases := make(map[string]string)
+	if err := openJSON(fileData, kp, &aliases, "aliases"); err != nil {
+		return []Problem{{Kind: ProblemAlias, Name: aliasesBlob, Detail: err.Error()}}, nil
+	}
+
+	var problems []Problem
+	for alias, id := range aliases {
+		if note, ok := notes[id]; !ok || note.Alias != alias {
+			problems = append(problems, Problem{Kind: ProblemAlias, Name: id, Detail: fmt.Sprintf("alias %q is stale", alias)})
+		}
+	}
+	for id, note := range notes {
+		if note.Alias == "" {
+			continue
+		}
+		owner := aliases[note.Alias]
+		if other, ok := notes[owner]; ok && owner != id && other.Alias == note.Alias {
+			// A duplicate, reported by Fsck
+			continue
+		}
+		if owner != id {
+			problems = append(problems, Problem{Kind: ProblemAlias, Name: id, Detail: fmt.Sprintf("alias %q not mapped to the note", note.Alias)})
+		}
+	}
+	sortProblems(problems)
+	return problems, nil
+}
@@ -0,0 +1,177 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const blobsDir = ".blobs"
+
+func attachmentBlob(hash string) string {
+	return path.Join(blobsDir, hash+".pkm")
+}
+
+func (n *Note) attachmentIndex(name string) int {
+	return slices.IndexFunc(n.Attachments, func(a Attachment) bool { return a.Name == name })
+}
+
+// Attach stores data as an encrypted content-addressed blob and records it
+// on the note under name. Identical files share one blob across notes.
+func (store *Store) Attach(noteId string, name string, data []byte, username string, kp *crypt.KeyProvider) (*Attachment, error) {
+	name = filepath.Base(name)
+	if name == "." || name == string(filepath.Separator) {
+		return nil, errors.New("attachment name required")
+	}
+
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	note, err := store.Load(noteId, username, kp)
+	if err != nil {
+		return nil, err
+	}
+	if note.attachmentIndex(name) != -1 {
+		return nil, fmt.Errorf("attachment %q already present", name)
+	}
+
+	attachment := Attachment{
+		Name:      name,
+		Hash:      kp.ContentHash(data),
+		Size:      int64(len(data)),
+		MediaType: mime.TypeByExtension(filepath.Ext(name)),
+		AddedAt:   time.Now().UTC(),
+	}
+	note.Attachments = append(note.Attachments, attachment)
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := store.blobs.Get(username, attachmentBlob(attachment.Hash)); errors.Is(err, fs.ErrNotExist) {
+		payload, err := seal(kp, data, tx.settings.Compressed())
+		if err != nil {
+			return nil, err
+		}
+		tx.b.Put(attachmentBlob(attachment.Hash), payload)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := tx.save(note); err != nil {
+		return nil, err
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// Attachment returns the decrypted contents of a note's attachment.
+func (store *Store) Attachment(noteId string, name string, username string, kp *crypt.KeyProvider) ([]byte, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	note, err := store.Load(noteId, username, kp)
+	if err != nil {
+		return nil, err
+	}
+	i := note.attachmentIndex(name)
+	if i == -1 {
+		return nil, fmt.Errorf("attachment %q not found", name)
+	}
+
+	fileData, err := store.blobs.Get(username, attachmentBlob(note.Attachments[i].Hash))
+	if err != nil {
+		return nil, err
+	}
+	if !hasHeader(fileData) {
+		return nil, errors.New("attachment corrupted")
+	}
+	return open(fileData, kp)
+}
+
+// Detach removes an attachment from a note and deletes its blob once no
+// other note refers to it.
+func (store *Store) Detach(noteId string, name string, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	note, err := store.Load(noteId, username, kp)
+	if err != nil {
+		return err
+	}
+	i := note.attachmentIndex(name)
+	if i == -1 {
+		return fmt.Errorf("attachment %q not found", name)
+	}
+	note.Attachments = slices.Delete(note.Attachments, i, i+1)
+
+	if err := store.Save(note, username, kp); err != nil {
+		return err
+	}
+	_, err = store.collectGarbage(username, kp)
+	return err
+}
+
+// collectGarbage deletes attachment blobs that no note, trashed note or
+// archived revision refers to, and returns how many were deleted.
+func (store *Store) collectGarbage(username string, kp *crypt.KeyProvider) (int, error) {
+	names, err := store.blobs.List(username, blobsDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := store.trashEntries(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		notes = append(notes, entry.Note)
+	}
+	revisions, err := store.archivedNotes(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	notes = append(notes, revisions...)
+	referenced := make(map[string]struct{})
+	for _, note := range notes {
+		for _, attachment := range note.Attachments {
+			referenced[attachment.Hash] = struct{}{}
+		}
+	}
+
+	var b Batch
+	for _, name := range names {
+		if _, ok := referenced[strings.TrimSuffix(name, ".pkm")]; !ok {
+			b.Remove(path.Join(blobsDir, name))
+		}
+	}
+	if err := store.blobs.Commit(username, &b); err != nil {
+		return 0, err
+	}
+	return len(b.Removes), nil
+}
@@ -0,0 +1,25 @@
+package note
+
+// Batch is a set of blob writes and removals that a Backend applies as
+// one unit: either every operation lands or none does.
+type Batch struct {
+	Puts    []BlobPut
+	Removes []string
+}
+
+type BlobPut struct {
+	Name string
+	Data []byte
+}
+
+func (b *Batch) Put(name string, data []byte) {
+	b.Puts = append(b.Puts, BlobPut{Name: name, Data: data})
+}
+
+func (b *Batch) Remove(name string) {
+	b.Removes = append(b.Removes, name)
+}
+
+func (b *Batch) Empty() bool {
+	return len(b.Puts) == 0 && len(b.Removes) == 0
+}
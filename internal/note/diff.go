@@ -0,0 +1,123 @@
+package note
+
+// DiffOp is one line (or word) of a diff: ' ' kept, '-' removed, '+' added.
+type DiffOp struct {
+	Kind byte
+	Text string
+}
+
+// Diff returns the shortest edit turning before into after, computed from
+// their longest common subsequence. Removals come before the additions
+// replacing them.
+func Diff(before []string, after []string) []DiffOp {
+	var ops []DiffOp
+	// The common prefix and suffix need no LCS, and usually are most of it
+	start := 0
+	for start < len(before) && start < len(after) && before[start] == after[start] {
+		ops = append(ops, DiffOp{Kind: ' ', Text: before[start]})
+		start++
+	}
+	end := 0
+	for end < len(before)-start && end < len(after)-start &&
+		before[len(before)-1-end] == after[len(after)-1-end] {
+		end++
+	}
+	ops = diffMiddle(ops, before[start:len(before)-end], after[start:len(after)-end])
+	for _, text := range before[len(before)-end:] {
+		ops = append(ops, DiffOp{Kind: ' ', Text: text})
+	}
+	return removalsFirst(ops)
+}
+
+// diffMiddle appends the diff of before and after to ops, splitting them
+// as Hirschberg does so that only two rows of LCS lengths are ever held.
+func diffMiddle(ops []DiffOp, before []string, after []string) []DiffOp {
+	switch {
+	case len(before) == 0:
+		for _, text := range after {
+			ops = append(ops, DiffOp{Kind: '+', Text: text})
+		}
+		return ops
+	case len(after) == 0:
+		for _, text := range before {
+			ops = append(ops, DiffOp{Kind: '-', Text: text})
+		}
+		return ops
+	case len(before) == 1:
+		for j, text := range after {
+			if text == before[0] {
+				ops = diffMiddle(ops, nil, after[:j])
+				ops = append(ops, DiffOp{Kind: ' ', Text: text})
+				return diffMiddle(ops, nil, after[j+1:])
+			}
+		}
+		ops = append(ops, DiffOp{Kind: '-', Text: before[0]})
+		return diffMiddle(ops, nil, after)
+	}
+
+	// Split after where the LCS of the two halves of before adds up best
+	mid := len(before) / 2
+	head := lcsLengths(before[:mid], after)
+	// tail[len(after)-j] is the LCS length of before[mid:] and after[j:]
+	tail := lcsLengths(reversed(before[mid:]), reversed(after))
+	split := 0
+	for j := range len(after) + 1 {
+		if head[j]+tail[len(after)-j] > head[split]+tail[len(after)-split] {
+			split = j
+		}
+	}
+	ops = diffMiddle(ops, before[:mid], after[:split])
+	return diffMiddle(ops, before[mid:], after[split:])
+}
+
+// lcsLengths returns, for every j, the LCS length of before and after[:j].
+func lcsLengths(before []string, after []string) []int {
+	previous := make([]int, len(after)+1)
+	current := make([]int, len(after)+1)
+	for _, text := range before {
+		for j := 1; j <= len(after); j++ {
+			if text == after[j-1] {
+				current[j] = previous[j-1] + 1
+			} else {
+				current[j] = max(previous[j], current[j-1])
+			}
+		}
+		previous, current = current, previous
+	}
+	return previous
+}
+
+func reversed(lines []string) []string {
+	r := make([]string, len(lines))
+	for i, line := range lines {
+		r[len(lines)-1-i] = line
+	}
+	return r
+}
+
+// removalsFirst orders each run of changes as its removals followed by its
+// additions.
+func removalsFirst(ops []DiffOp) []DiffOp {
+	for start := 0; start < len(ops); {
+		if ops[start].Kind == ' ' {
+			start++
+			continue
+		}
+		end := start
+		var added []DiffOp
+		for _, op := range ops[start:] {
+			if op.Kind == ' ' {
+				break
+			}
+			if op.Kind == '-' {
+				ops[end] = op
+				end++
+			} else {
+				added = append(added, op)
+			}
+		}
+		end += copy(ops[end:], added)
+		start = end
+	}
+	return ops
+}
@@ -0,0 +1,227 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	journalBlob = ".journal"
+	tempSuffix  = ".tmp"
+)
+
+// FileBackend stores blobs as files under <root>/<user>/. Every write goes
+// through a temp file, fsync and rename so a crash never leaves a partial
+// blob behind.
+type FileBackend struct {
+	root string
+}
+
+func NewFileBackend(root string) *FileBackend {
+	return &FileBackend{root: root}
+}
+
+// journal records the renames and removals of a committed batch so that
+// Recover can finish applying it after a crash.
+type journal struct {
+	Puts    []string `json:"puts"`
+	Removes []string `json:"removes"`
+}
+
+func (f *FileBackend) path(username string, name string) string {
+	return filepath.Join(f.root, username, filepath.FromSlash(name))
+}
+
+func (f *FileBackend) Get(username string, name string) ([]byte, error) {
+	return os.ReadFile(f.path(username, name))
+}
+
+func (f *FileBackend) Put(username string, name string, data []byte) error {
+	blobPath := f.path(username, name)
+	if err := writeFileSync(blobPath+tempSuffix, data); err != nil {
+		return err
+	}
+	if err := os.Rename(blobPath+tempSuffix, blobPath); err != nil {
+		os.Remove(blobPath + tempSuffix)
+		return err
+	}
+	return syncDir(filepath.Dir(blobPath))
+}
+
+func (f *FileBackend) Remove(username string, name string) error {
+	return os.Remove(f.path(username, name))
+}
+
+func (f *FileBackend) List(username string, dir string) ([]string, error) {
+	entries, err := os.ReadDir(f.path(username, dir))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), tempSuffix) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// Walk returns the names of every blob in the user's space, in lexical
+// order.
+func (f *FileBackend) Walk(username string) ([]string, error) {
+	userDir := f.path(username, "")
+	var names []string
+	err := filepath.WalkDir(userDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == userDir {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), tempSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(userDir, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	return names, err
+}
+
+// Commit writes every blob of b to a temp file, then atomically publishes a
+// journal naming them. Once the journal is on disk the batch counts as
+// committed; the renames that follow are replayed by Recover if interrupted.
+func (f *FileBackend) Commit(username string, b *Batch) error {
+	if b.Empty() {
+		return nil
+	}
+	if len(b.Puts) == 1 && len(b.Removes) == 0 {
+		return f.Put(username, b.Puts[0].Name, b.Puts[0].Data)
+	}
+
+	var jrnl journal
+	for _, p := range b.Puts {
+		if err := writeFileSync(f.path(username, p.Name)+tempSuffix, p.Data); err != nil {
+			f.discard(username, jrnl.Puts)
+			return err
+		}
+		jrnl.Puts = append(jrnl.Puts, p.Name)
+	}
+	jrnl.Removes = b.Removes
+
+	jrnlData, err := json.Marshal(jrnl)
+	if err != nil {
+		f.discard(username, jrnl.Puts)
+		return err
+	}
+	if err := f.Put(username, journalBlob, jrnlData); err != nil {
+		f.discard(username, jrnl.Puts)
+		return err
+	}
+
+	return f.replay(username, &jrnl)
+}
+
+// Recover finishes a batch interrupted after its journal was written and
+// removes temp files left by writes that never committed.
+func (f *FileBackend) Recover(username string) error {
+	jrnlData, err := f.Get(username, journalBlob)
+	switch {
+	case err == nil:
+		var jrnl journal
+		if err := json.Unmarshal(jrnlData, &jrnl); err != nil {
+			return err
+		}
+		if err := f.replay(username, &jrnl); err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	userDir := f.path(username, "")
+	return filepath.WalkDir(userDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == userDir {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), tempSuffix) {
+			return os.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (f *FileBackend) replay(username string, jrnl *journal) error {
+	dirs := make(map[string]struct{})
+	for _, name := range jrnl.Puts {
+		blobPath := f.path(username, name)
+		if err := os.Rename(blobPath+tempSuffix, blobPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		dirs[filepath.Dir(blobPath)] = struct{}{}
+	}
+	for _, name := range jrnl.Removes {
+		blobPath := f.path(username, name)
+		if err := os.Remove(blobPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		dirs[filepath.Dir(blobPath)] = struct{}{}
+	}
+	for dir := range dirs {
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(f.path(username, journalBlob)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return syncDir(f.path(username, ""))
+}
+
+func (f *FileBackend) discard(username string, names []string) {
+	for _, name := range names {
+		os.Remove(f.path(username, name) + tempSuffix)
+	}
+}
+
+// writeFileSync writes data to path and flushes it to stable storage.
+func writeFileSync(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir flushes directory entries so a rename survives a crash.
+func syncDir(dir string) error {
+	dirFS, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer dirFS.Close()
+	return dirFS.Sync()
+}
@@ -0,0 +1,193 @@
+package note
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// Every encrypted blob starts with a fixed-size header:
+//
+//	magic    4 bytes  "PKMF"
+//	version  1 byte   formatVersion
+//	alg      1 byte   cipher, algAES256GCM
+//	flags    2 bytes  big endian, flagGzip
+//	key id   8 bytes  crypt.KeyProvider.KeyID of the sealing DEK
+//
+// followed by nonce and ciphertext. The header is authenticated as
+// additional data, so it cannot be altered without decryption failing.
+// With flagGzip the plaintext was gzipped before encryption.
+//
+// Vaults written before the header existed start with "PKM\n" directly
+// followed by nonce and ciphertext; they read as version 0 until
+// `pkm migrate` upgrades them.
+const (
+	formatVersion = 1
+	algAES256GCM  = 1
+	headerSize    = 8 + crypt.KeyIDSize
+
+	flagGzip   uint16 = 1 << 0
+	knownFlags        = flagGzip
+)
+
+var (
+	formatMagic = []byte("PKMF")
+	legacyMagic = []byte("PKM\n")
+)
+
+// header is the parsed header of an encrypted blob.
+type header struct {
+	version byte
+	alg     byte
+	flags   uint16
+	keyID   []byte
+}
+
+func (h header) bytes() []byte {
+	buf := make([]byte, 0, headerSize)
+	buf = append(buf, formatMagic...)
+	buf = append(buf, h.version, h.alg)
+	buf = binary.BigEndian.AppendUint16(buf, h.flags)
+	return append(buf, h.keyID...)
+}
+
+// parseHeader splits data into its header and payload. Legacy blobs get a
+// version 0 header.
+func parseHeader(data []byte) (header, []byte, error) {
+	switch {
+	case len(data) > headerSize && bytes.Equal(data[:len(formatMagic)], formatMagic):
+		h := header{
+			version: data[4],
+			alg:     data[5],
+			flags:   binary.BigEndian.Uint16(data[6:8]),
+			keyID:   data[8:headerSize],
+		}
+		return h, data[headerSize:], nil
+	case len(data) > len(legacyMagic) && bytes.Equal(data[:len(legacyMagic)], legacyMagic):
+		return header{alg: algAES256GCM}, data[len(legacyMagic):], nil
+	default:
+		return header{}, nil, errors.New("missing PKM header")
+	}
+}
+
+func hasHeader(data []byte) bool {
+	_, _, err := parseHeader(data)
+	return err == nil
+}
+
+// isLegacy reports whether data still uses the pre-versioning layout.
+func isLegacy(data []byte) bool {
+	h, _, err := parseHeader(data)
+	return err == nil && h.version == 0
+}
+
+// seal encrypts plaintext behind a current-version header. With compress
+// the plaintext is gzipped first, unless that would not make it smaller.
+func seal(kp *crypt.KeyProvider, plaintext []byte, compress bool) ([]byte, error) {
+	h := header{
+		version: formatVersion,
+		alg:     algAES256GCM,
+		keyID:   kp.KeyID(),
+	}
+	if compress {
+		compressed, err := gzipBytes(plaintext)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(plaintext) {
+			plaintext = compressed
+			h.flags |= flagGzip
+		}
+	}
+	prefix := h.bytes()
+	encrypted, err := kp.EncryptWithAAD(plaintext, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, encrypted...), nil
+}
+
+// open is the single reader for encrypted blobs of any known version.
+func open(data []byte, kp *crypt.KeyProvider) ([]byte, error) {
+	h, payload, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.version == 0 {
+		return kp.Decrypt(payload)
+	}
+	if h.version > formatVersion {
+		return nil, fmt.Errorf("unsupported format version %d, upgrade pkm", h.version)
+	}
+	if h.alg != algAES256GCM {
+		return nil, fmt.Errorf("unsupported cipher %d", h.alg)
+	}
+	if h.flags&^knownFlags != 0 {
+		return nil, fmt.Errorf("unsupported format flags %#x", h.flags)
+	}
+	if !bytes.Equal(h.keyID, kp.KeyID()) {
+		return nil, errors.New("sealed with a different key")
+	}
+	plaintext, err := kp.DecryptWithAAD(payload, data[:headerSize])
+	if err != nil {
+		return nil, err
+	}
+	if h.flags&flagGzip != 0 {
+		return gunzipBytes(plaintext)
+	}
+	return plaintext, nil
+}
+
+// isCompressed reports whether data holds a gzipped payload.
+func isCompressed(data []byte) bool {
+	h, _, err := parseHeader(data)
+	return err == nil && h.flags&flagGzip != 0
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// sealJSON marshals v and seals it.
+func sealJSON(kp *crypt.KeyProvider, v any, compress bool) ([]byte, error) {
+	jsonBody, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return seal(kp, jsonBody, compress)
+}
+
+// openJSON decrypts a sealed blob into v; what names the blob in errors.
+func openJSON(data []byte, kp *crypt.KeyProvider, v any, what string) error {
+	if !hasHeader(data) {
+		return errors.New(what + " corrupted")
+	}
+	jsonData, err := open(data, kp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, v)
+}
@@ -0,0 +1,109 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+)
+
+const frontmatterFence = "---"
+
+// JoinFrontmatter returns the editor buffer for a note: its properties as a
+// YAML-style frontmatter block between "---" lines, then its content.
+//
+//	---
+//	status: draft
+//	rating: 4
+//	due: 2025-03-01
+//	authors: [Knuth, Tarjan]
+//	---
+//	content
+func JoinFrontmatter(properties map[string]Property, content string) string {
+	// Keep content that itself opens with a fence from reading as properties
+	if len(properties) == 0 && !strings.HasPrefix(content, frontmatterFence) {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(frontmatterFence + "\n")
+	for _, key := range PropertyKeys(properties) {
+		fmt.Fprintf(&b, "%s: %s\n", key, properties[key])
+	}
+	b.WriteString(frontmatterFence + "\n")
+	b.WriteString(content)
+	return b.String()
+}
+
+// SplitFrontmatter reads an editor buffer back into properties and
+// content. A buffer whose first line is not "---" has no properties.
+// Besides key: value lines the block may hold blank lines, # comments and
+// block lists:
+//
+//	authors:
+//	  - Knuth
+//	  - Tarjan
+func SplitFrontmatter(buffer string) (map[string]Property, string, error) {
+	first, rest, found := strings.Cut(buffer, "\n")
+	if !found || strings.TrimRight(first, " \r") != frontmatterFence {
+		return nil, buffer, nil
+	}
+
+	properties := make(map[string]Property)
+	var listKey string
+	for lineNo := 2; ; lineNo++ {
+		if rest == "" {
+			return nil, "", fmt.Errorf("frontmatter: missing closing %s", frontmatterFence)
+		}
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		line = strings.TrimRight(line, " \r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case line == frontmatterFence:
+			if len(properties) == 0 {
+				properties = nil
+			}
+			return properties, rest, nil
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "- ") || trimmed == "-":
+			if listKey == "" {
+				return nil, "", fmt.Errorf("frontmatter line %d: list item without a key", lineNo)
+			}
+			item, err := ParseValue(strings.TrimPrefix(trimmed, "-"))
+			if err != nil {
+				return nil, "", fmt.Errorf("frontmatter line %d: %w", lineNo, err)
+			}
+			if item.Type == PropertyList {
+				return nil, "", fmt.Errorf("frontmatter line %d: nested lists are not supported", lineNo)
+			}
+			list := properties[listKey]
+			list.Type = PropertyList
+			list.Value = ""
+			list.Items = append(list.Items, item.Value)
+			properties[listKey] = list
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || line[0] == ' ' || line[0] == '\t' {
+			return nil, "", fmt.Errorf("frontmatter line %d: expected key: value, got %q", lineNo, line)
+		}
+		key = strings.TrimSpace(key)
+		if err := ValidatePropertyKey(key); err != nil {
+			return nil, "", fmt.Errorf("frontmatter line %d: %w", lineNo, err)
+		}
+		if _, dup := properties[key]; dup {
+			return nil, "", fmt.Errorf("frontmatter line %d: duplicate key %s", lineNo, key)
+		}
+		property, err := ParseValue(value)
+		if err != nil {
+			return nil, "", fmt.Errorf("frontmatter line %d: %w", lineNo, err)
+		}
+		properties[key] = property
+		// An empty value may be followed by block list items
+		listKey = ""
+		if strings.TrimSpace(value) == "" {
+			listKey = key
+		}
+	}
+}
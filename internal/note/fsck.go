@@ -0,0 +1,274 @@
+package note
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const quarantineDir = ".quarantine"
+
+// Kinds of problems reported by Fsck.
+const (
+	ProblemUndecryptable = "undecryptable"
+	ProblemBadJSON       = "bad-json"
+	ProblemIdMismatch    = "id-mismatch"
+	ProblemDanglingLink  = "dangling-link"
+	ProblemOneSidedLink  = "one-sided-link"
+	ProblemIndex         = "index"
+)
+
+// Fsck checks every note of the user and the index, manifest and aliases
+// built from them. With repair it rebuilds the index, manifest and aliases,
+// drops dangling links, adds missing back-links, clears aliases used twice
+// and moves notes it cannot read to .quarantine/, all in one write.
+func (store *Store) Fsck(repair bool, username string, kp *crypt.KeyProvider) (*FsckReport, error) {
+	store, unlock, err := store.Locked(username, repair)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	report := &FsckReport{}
+	var quarantined Batch
+	quarantine := func(problem Problem, name string, data []byte) {
+		if repair {
+			quarantined.Put(path.Join(quarantineDir, name), data)
+			quarantined.Remove(name)
+			problem.Repair = "moved to " + quarantineDir
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+
+	ids, err := store.noteIds(username)
+	if err != nil {
+		return nil, err
+	}
+	checked, errs, err := parallel(context.Background(), len(ids), store.workers(), func(i int) (fsckCheck, error) {
+		return store.checkNote(username, ids[i], kp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]*Note, len(ids))
+	for i, check := range checked {
+		if errs[i] != nil {
+			return nil, &NoteError{Id: ids[i], Err: errs[i]}
+		}
+		report.Checked++
+		if check.problem != nil {
+			quarantine(*check.problem, ids[i]+".pkm", check.data)
+			continue
+		}
+		notes[ids[i]] = check.note
+	}
+
+	// Walk notes in a stable order so reports and repairs are reproducible
+	sorted := make([]string, 0, len(notes))
+	for id := range notes {
+		sorted = append(sorted, id)
+	}
+	slices.Sort(sorted)
+
+	changed := make(map[string]bool)
+	for _, id := range sorted {
+		note := notes[id]
+		for _, link := range slices.Clone(note.Links) {
+			target, ok := notes[link.Target]
+			switch {
+			case !ok:
+				problem := Problem{Kind: ProblemDanglingLink, Name: id, Detail: "links to missing note " + link.Target}
+				if repair {
+					note.RemoveLink(link.Target)
+					changed[id] = true
+					problem.Repair = "link dropped"
+				}
+				report.Problems = append(report.Problems, problem)
+			case link.Target != id && !link.OneWay && !target.HasLink(id):
+				problem := Problem{Kind: ProblemOneSidedLink, Name: id, Detail: "no back-link from " + link.Target}
+				if repair {
+					target.Links = append(target.Links, link.Inverse(id))
+					changed[link.Target] = true
+					problem.Repair = "back-link added"
+				}
+				report.Problems = append(report.Problems, problem)
+			}
+		}
+	}
+
+	indexProblems, err := store.checkIndex(username, kp, notes)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, indexProblems...)
+	manifestProblems, err := store.checkManifest(username, kp, notes)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, manifestProblems...)
+	aliasProblems, err := store.checkAliases(username, kp, notes)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, aliasProblems...)
+
+	// Checked last so that clearing an alias is not reported as drift too
+	owners := make(map[string]string)
+	for _, id := range sorted {
+		note := notes[id]
+		if note.Alias == "" {
+			continue
+		}
+		owner, taken := owners[note.Alias]
+		if !taken {
+			owners[note.Alias] = id
+			continue
+		}
+		problem := Problem{Kind: ProblemAlias, Name: id, Detail: fmt.Sprintf("alias %q also used by note %s", note.Alias, owner)}
+		if repair {
+			note.Alias = ""
+			changed[id] = true
+			problem.Repair = "alias cleared"
+		}
+		report.Problems = append(report.Problems, problem)
+	}
+
+	if !repair || len(report.Problems) == 0 {
+		return report, nil
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	tx.b = quarantined
+	readable := make([]*Note, 0, len(sorted))
+	for _, id := range sorted {
+		readable = append(readable, notes[id])
+	}
+	if err := tx.rebuild(readable); err != nil {
+		return nil, err
+	}
+	for _, id := range sorted {
+		if changed[id] {
+			if err := tx.save(notes[id]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := range report.Problems {
+		switch report.Problems[i].Kind {
+		case ProblemIndex:
+			report.Problems[i].Repair = "index rebuilt"
+		case ProblemManifest:
+			report.Problems[i].Repair = "manifest rebuilt"
+		case ProblemAlias:
+			if report.Problems[i].Repair == "" {
+				report.Problems[i].Repair = "aliases rebuilt"
+			}
+		}
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// fsckCheck is the outcome of reading one note blob: either the note or
+// the problem that keeps it from being read, with the raw blob.
+type fsckCheck struct {
+	note    *Note
+	problem *Problem
+	data    []byte
+}
+
+func (store *Store) checkNote(username string, id string, kp *crypt.KeyProvider) (fsckCheck, error) {
+	fileData, err := store.blobs.Get(username, id+".pkm")
+	if err != nil {
+		return fsckCheck{}, err
+	}
+	check := fsckCheck{data: fileData}
+	if !hasHeader(fileData) {
+		check.problem = &Problem{Kind: ProblemUndecryptable, Name: id, Detail: "missing PKM header"}
+		return check, nil
+	}
+	jsonData, err := open(fileData, kp)
+	if err != nil {
+		check.problem = &Problem{Kind: ProblemUndecryptable, Name: id, Detail: err.Error()}
+		return check, nil
+	}
+	var note Note
+	if err := json.Unmarshal(jsonData, &note); err != nil {
+		check.problem = &Problem{Kind: ProblemBadJSON, Name: id, Detail: err.Error()}
+		return check, nil
+	}
+	if note.Id != id {
+		check.problem = &Problem{Kind: ProblemIdMismatch, Name: id, Detail: fmt.Sprintf("file holds note %q", note.Id)}
+		return check, nil
+	}
+	check.note = &note
+	return check, nil
+}
+
+func sortProblems(problems []Problem) {
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Name < problems[j].Name
+	})
+}
+
+// checkIndex compares the stored index against the readable notes.
+func (store *Store) checkIndex(username string, kp *crypt.KeyProvider, notes map[string]*Note) ([]Problem, error) {
+	index, err := store.readIndex(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	if index.missing {
+		if len(notes) == 0 {
+			return nil, nil
+		}
+		return []Problem{{Kind: ProblemIndex, Name: indexDir, Detail: "index missing"}}, nil
+	}
+
+	var problems []Problem
+	broken := make(map[string]bool)
+	shardProblem := func(err error) {
+		// Report each unreadable shard once
+		if !broken[err.Error()] {
+			broken[err.Error()] = true
+			problems = append(problems, Problem{Kind: ProblemIndex, Name: indexDir, Detail: err.Error()})
+		}
+	}
+
+	for id := range index.docs.ids {
+		if _, ok := notes[id]; !ok {
+			problems = append(problems, Problem{Kind: ProblemIndex, Name: id, Detail: "index refers to missing note"})
+		}
+	}
+	for id, note := range notes {
+		terms, ok, err := index.termsOf(id)
+		if err != nil {
+			shardProblem(err)
+			continue
+		}
+		want := indexTerms(note)
+		if !ok || !sameTerms(terms, want) {
+			problems = append(problems, Problem{Kind: ProblemIndex, Name: id, Detail: "note not indexed under its current terms"})
+			continue
+		}
+		if err := index.checkPostings(id, want); err != nil {
+			if errors.Is(err, errNotPosted) {
+				problems = append(problems, Problem{Kind: ProblemIndex, Name: id, Detail: err.Error()})
+				continue
+			}
+			shardProblem(err)
+		}
+	}
+	sortProblems(problems)
+	return problems, nil
+}
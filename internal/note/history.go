@@ -0,0 +1,210 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const historyDir = ".history"
+
+// revisionRef locates one archived revision blob.
+type revisionRef struct {
+	rev        int
+	replacedAt time.Time
+	name       string
+}
+
+func historyName(noteId string, rev int, replacedAt time.Time) string {
+	return path.Join(historyDir, noteId, fmt.Sprintf("%06d-%d.pkm", rev, replacedAt.Unix()))
+}
+
+// revisions returns the archived revisions of a note, oldest first.
+func (store *Store) revisions(username string, noteId string) ([]revisionRef, error) {
+	dir := path.Join(historyDir, noteId)
+	names, err := store.blobs.List(username, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []revisionRef
+	for _, name := range names {
+		var rev int
+		var unix int64
+		if _, err := fmt.Sscanf(name, "%06d-%d.pkm", &rev, &unix); err != nil {
+			continue
+		}
+		refs = append(refs, revisionRef{rev: rev, replacedAt: time.Unix(unix, 0).UTC(), name: path.Join(dir, name)})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].rev < refs[j].rev })
+	return refs, nil
+}
+
+// archive adds the previous encrypted blob of a note to b as a new
+// revision, and prunes revisions the retention policy no longer keeps. It
+// reports whether it pruned any.
+func (store *Store) archive(b *Batch, username string, noteId string, previous []byte, settings *Settings) (bool, error) {
+	refs, err := store.revisions(username, noteId)
+	if err != nil {
+		return false, err
+	}
+	next := 1
+	if len(refs) > 0 {
+		next = refs[len(refs)-1].rev + 1
+	}
+	now := time.Now().UTC()
+	name := historyName(noteId, next, now)
+	b.Put(name, previous)
+	refs = append(refs, revisionRef{rev: next, replacedAt: now, name: name})
+
+	pruned := false
+	for i, ref := range refs {
+		tooMany := settings.HistoryKeep > 0 && len(refs)-i > settings.HistoryKeep
+		tooOld := settings.HistoryMaxAge > 0 && now.Sub(ref.replacedAt) > settings.HistoryMaxAge
+		if tooMany || tooOld {
+			b.Remove(ref.name)
+			pruned = true
+		}
+	}
+	return pruned, nil
+}
+
+// stageHistoryRemoval adds the removal of every revision of a note to b.
+func (store *Store) stageHistoryRemoval(b *Batch, username string, noteId string) error {
+	refs, err := store.revisions(username, noteId)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		b.Remove(ref.name)
+	}
+	return nil
+}
+
+// archivedNotes decrypts every archived revision of every note. It fails
+// on a revision it cannot decrypt, whose attachments are then unknown.
+func (store *Store) archivedNotes(username string, kp *crypt.KeyProvider) ([]*Note, error) {
+	names, err := store.blobs.Walk(username)
+	if err != nil {
+		return nil, err
+	}
+	var notes []*Note
+	for _, name := range names {
+		if !strings.HasPrefix(name, historyDir+"/") {
+			continue
+		}
+		fileData, err := store.blobs.Get(username, name)
+		if err != nil {
+			return nil, err
+		}
+		revNote, err := openNote(fileData, kp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		notes = append(notes, revNote)
+	}
+	return notes, nil
+}
+
+// History returns the archived revisions of a note, oldest first.
+func (store *Store) History(noteId string, username string, kp *crypt.KeyProvider) ([]Revision, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	refs, err := store.revisions(username, noteId)
+	if err != nil {
+		return nil, err
+	}
+	var history []Revision
+	for _, ref := range refs {
+		fileData, err := store.blobs.Get(username, ref.name)
+		if err != nil {
+			return nil, err
+		}
+		revNote, err := openNote(fileData, kp)
+		if err != nil {
+			return nil, fmt.Errorf("revision %d: %w", ref.rev, err)
+		}
+		history = append(history, Revision{Rev: ref.rev, ReplacedAt: ref.replacedAt, Note: revNote})
+	}
+	return history, nil
+}
+
+// LoadRevision decrypts a single archived revision of a note.
+func (store *Store) LoadRevision(noteId string, rev int, username string, kp *crypt.KeyProvider) (*Note, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	refs, err := store.revisions(username, noteId)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if ref.rev != rev {
+			continue
+		}
+		fileData, err := store.blobs.Get(username, ref.name)
+		if err != nil {
+			return nil, err
+		}
+		return openNote(fileData, kp)
+	}
+	return nil, fmt.Errorf("revision %d of note %s not found", rev, noteId)
+}
+
+// Revert makes an archived revision the current note. The note being
+// replaced is itself archived, so a revert can be undone. Links of the
+// revision to notes deleted since are dropped and its targets get their
+// back-links again, in the same write.
+func (store *Store) Revert(noteId string, rev int, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	revNote, err := store.LoadRevision(noteId, rev, username, kp)
+	if err != nil {
+		return err
+	}
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return err
+	}
+	live := make(map[string]*Note, len(notes))
+	for _, other := range notes {
+		if other.Id != noteId {
+			live[other.Id] = other
+		}
+	}
+	relinked := linkBack(revNote, live)
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	if err := tx.save(revNote); err != nil {
+		return err
+	}
+	for _, other := range relinked {
+		if err := tx.save(other); err != nil {
+			return err
+		}
+	}
+	return tx.commit()
+}
@@ -0,0 +1,481 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// The search index lives under .index/ as separately encrypted shards:
+//
+//	docs.pkm       note id <-> small integer doc id
+//	terms-NN.pkm   terms each doc is indexed under, by doc id % termShards
+//	k-C.pkm        keyword posting lists for keywords starting with C
+//	t-C.pkm        tag posting lists for tags starting with C
+//	p-C.pkm        property posting lists for keys starting with C
+//
+// A search decrypts the doc table and the posting shards of its terms
+// only, and a save rewrites only the shards whose contents changed.
+const (
+	indexDir        = ".index"
+	docsShard       = "docs.pkm"
+	termShards      = 16
+	legacyIndexBlob = ".index.pkm"
+)
+
+// Kinds of posting shards.
+const (
+	keywordPostings  = "k"
+	tagPostings      = "t"
+	propertyPostings = "p"
+)
+
+// postingKinds lists every kind of posting list with the terms of it a
+// note is indexed under.
+var postingKinds = []struct {
+	kind  string
+	terms func(IndexTerms) []string
+}{
+	{keywordPostings, func(terms IndexTerms) []string { return terms.Keywords }},
+	{tagPostings, func(terms IndexTerms) []string { return terms.Tags }},
+	{propertyPostings, func(terms IndexTerms) []string { return terms.Props }},
+}
+
+// docTable maps note ids to the doc ids used in posting lists.
+type docTable struct {
+	next  uint32
+	ids   map[string]uint32
+	uuids map[uint32]string
+}
+
+func newDocTable() *docTable {
+	return &docTable{
+		next:  1,
+		ids:   make(map[string]uint32),
+		uuids: make(map[uint32]string),
+	}
+}
+
+func (docs *docTable) set(noteId string, id uint32) {
+	docs.ids[noteId] = id
+	docs.uuids[id] = noteId
+	docs.next = max(docs.next, id+1)
+}
+
+func (docs *docTable) remove(noteId string) {
+	delete(docs.uuids, docs.ids[noteId])
+	delete(docs.ids, noteId)
+}
+
+// searchIndex is the sharded search index of one user. Shards are decrypted on
+// first use and only the ones changed since are written back by stage.
+type searchIndex struct {
+	store    *Store
+	username string
+	kp       *crypt.KeyProvider
+
+	docs *docTable
+	// missing is set when no readable doc table was found
+	missing bool
+	// outdated is set when the doc table has an older layout
+	outdated bool
+	// fresh indexes start empty and replace every stored shard
+	fresh bool
+
+	terms    map[string]map[uint32]IndexTerms
+	postings map[string]map[string][]uint32
+	dirty    map[string]bool
+}
+
+func (store *Store) newIndex(username string, kp *crypt.KeyProvider) *searchIndex {
+	return &searchIndex{
+		store:    store,
+		username: username,
+		kp:       kp,
+		docs:     newDocTable(),
+		fresh:    true,
+		terms:    make(map[string]map[uint32]IndexTerms),
+		postings: make(map[string]map[string][]uint32),
+		dirty:    make(map[string]bool),
+	}
+}
+
+// readIndex opens the user's index, decrypting only its doc table. A
+// missing or unreadable doc table yields an empty index marked missing, so
+// that writes can always proceed.
+func (store *Store) readIndex(username string, kp *crypt.KeyProvider) (*searchIndex, error) {
+	index := store.newIndex(username, kp)
+	fileData, err := store.blobs.Get(username, path.Join(indexDir, docsShard))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err == nil && hasHeader(fileData) {
+		if plaintext, err := open(fileData, kp); err == nil {
+			docs, err := decodeDocs(plaintext)
+			if err == nil {
+				index.docs = docs
+				index.fresh = false
+				return index, nil
+			}
+			index.outdated = errors.Is(err, errIndexVersion)
+		}
+	}
+	index.missing = true
+	index.dirty[docsShard] = true
+	return index, nil
+}
+
+// load decrypts one shard.
+func (index *searchIndex) load(shard string) ([]byte, error) {
+	fileData, err := index.store.blobs.Get(index.username, path.Join(indexDir, shard))
+	if err != nil {
+		return nil, err
+	}
+	return open(fileData, index.kp)
+}
+
+func termShard(id uint32) string {
+	return fmt.Sprintf("terms-%02d.pkm", id%termShards)
+}
+
+// postingShard names the shard holding term, by its first character.
+func postingShard(kind string, term string) string {
+	c := byte('_')
+	if term != "" {
+		first := term[0]
+		if 'A' <= first && first <= 'Z' {
+			first += 'a' - 'A'
+		}
+		if ('a' <= first && first <= 'z') || ('0' <= first && first <= '9') {
+			c = first
+		}
+	}
+	return kind + "-" + string(c) + ".pkm"
+}
+
+func shardError(shard string, err error) error {
+	return fmt.Errorf("index shard %s: %w (run `pkm index rebuild`)", shard, err)
+}
+
+func (index *searchIndex) termShard(shard string) (map[uint32]IndexTerms, error) {
+	if loaded, ok := index.terms[shard]; ok {
+		return loaded, nil
+	}
+	loaded := make(map[uint32]IndexTerms)
+	if !index.fresh {
+		plaintext, err := index.load(shard)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, shardError(shard, err)
+		}
+		if err == nil {
+			if loaded, err = decodeTerms(plaintext); err != nil {
+				return nil, shardError(shard, err)
+			}
+		}
+	}
+	index.terms[shard] = loaded
+	return loaded, nil
+}
+
+func (index *searchIndex) postingShard(shard string) (map[string][]uint32, error) {
+	if loaded, ok := index.postings[shard]; ok {
+		return loaded, nil
+	}
+	loaded := make(map[string][]uint32)
+	if !index.fresh {
+		plaintext, err := index.load(shard)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, shardError(shard, err)
+		}
+		if err == nil {
+			if loaded, err = decodePostings(plaintext); err != nil {
+				return nil, shardError(shard, err)
+			}
+		}
+	}
+	index.postings[shard] = loaded
+	return loaded, nil
+}
+
+// indexTerms returns the de-duplicated keywords, tags and property terms
+// note is indexed under.
+func indexTerms(note *Note) IndexTerms {
+	words := filterStopwords(normalize(note.Title + " " + note.Content))
+	slices.Sort(words)
+	tags := slices.Clone(note.Tags)
+	slices.Sort(tags)
+	return IndexTerms{
+		Keywords: slices.Compact(words),
+		Tags:     slices.Compact(tags),
+		Props:    propertyTerms(note.Properties),
+	}
+}
+
+func sameTerms(a IndexTerms, b IndexTerms) bool {
+	for _, postings := range postingKinds {
+		if !slices.Equal(postings.terms(a), postings.terms(b)) {
+			return false
+		}
+	}
+	return true
+}
+
+// termsOf returns what a note is currently indexed under.
+func (index *searchIndex) termsOf(noteId string) (IndexTerms, bool, error) {
+	id, ok := index.docs.ids[noteId]
+	if !ok {
+		return IndexTerms{}, false, nil
+	}
+	shard, err := index.termShard(termShard(id))
+	if err != nil {
+		return IndexTerms{}, false, err
+	}
+	terms, ok := shard[id]
+	return terms, ok, nil
+}
+
+// add indexes note, touching only the postings of terms it gained or lost.
+func (index *searchIndex) add(note *Note) error {
+	id, known := index.docs.ids[note.Id]
+	if !known {
+		id = index.docs.next
+		index.docs.set(note.Id, id)
+		index.dirty[docsShard] = true
+	}
+	terms, err := index.termShard(termShard(id))
+	if err != nil {
+		return err
+	}
+	before := terms[id]
+	after := indexTerms(note)
+
+	for _, postings := range postingKinds {
+		if err := index.update(postings.kind, id, postings.terms(before), postings.terms(after)); err != nil {
+			return err
+		}
+	}
+	if _, ok := terms[id]; !ok || !sameTerms(before, after) {
+		terms[id] = after
+		index.dirty[termShard(id)] = true
+	}
+	return nil
+}
+
+// drop removes every posting of noteId.
+func (index *searchIndex) drop(noteId string) error {
+	id, ok := index.docs.ids[noteId]
+	if !ok {
+		return nil
+	}
+	terms, err := index.termShard(termShard(id))
+	if err != nil {
+		return err
+	}
+	before := terms[id]
+	for _, postings := range postingKinds {
+		if err := index.update(postings.kind, id, postings.terms(before), nil); err != nil {
+			return err
+		}
+	}
+	delete(terms, id)
+	index.dirty[termShard(id)] = true
+	index.docs.remove(noteId)
+	index.dirty[docsShard] = true
+	return nil
+}
+
+// update moves doc id from the postings of terms in before but not after
+// to those in after but not before. Both lists are sorted.
+func (index *searchIndex) update(kind string, id uint32, before []string, after []string) error {
+	for _, term := range before {
+		if _, found := slices.BinarySearch(after, term); found {
+			continue
+		}
+		shard := postingShard(kind, term)
+		postings, err := index.postingShard(shard)
+		if err != nil {
+			return err
+		}
+		if i, found := slices.BinarySearch(postings[term], id); found {
+			postings[term] = slices.Delete(postings[term], i, i+1)
+			if len(postings[term]) == 0 {
+				delete(postings, term)
+			}
+			index.dirty[shard] = true
+		}
+	}
+	for _, term := range after {
+		if _, found := slices.BinarySearch(before, term); found {
+			continue
+		}
+		shard := postingShard(kind, term)
+		postings, err := index.postingShard(shard)
+		if err != nil {
+			return err
+		}
+		if i, found := slices.BinarySearch(postings[term], id); !found {
+			postings[term] = slices.Insert(postings[term], i, id)
+			index.dirty[shard] = true
+		}
+	}
+	return nil
+}
+
+// lookup returns the ids of notes indexed under term.
+func (index *searchIndex) lookup(kind string, term string) ([]string, error) {
+	postings, err := index.postingShard(postingShard(kind, term))
+	if err != nil {
+		return nil, err
+	}
+	var noteIds []string
+	for _, id := range postings[term] {
+		if noteId, ok := index.docs.uuids[id]; ok {
+			noteIds = append(noteIds, noteId)
+		}
+	}
+	return noteIds, nil
+}
+
+// lookupTree returns the ids of notes indexed under term or a term below
+// it in the tag hierarchy. Descendants start like term, so they share its
+// shard.
+func (index *searchIndex) lookupTree(kind string, term string) ([]string, error) {
+	postings, err := index.postingShard(postingShard(kind, term))
+	if err != nil {
+		return nil, err
+	}
+	var noteIds []string
+	for posted, ids := range postings {
+		if !TagUnder(posted, term) {
+			continue
+		}
+		for _, id := range ids {
+			if noteId, ok := index.docs.uuids[id]; ok && !slices.Contains(noteIds, noteId) {
+				noteIds = append(noteIds, noteId)
+			}
+		}
+	}
+	return noteIds, nil
+}
+
+var errNotPosted = errors.New("note missing from the posting lists of its terms")
+
+// checkPostings reports whether noteId appears in the postings of terms.
+func (index *searchIndex) checkPostings(noteId string, terms IndexTerms) error {
+	for _, kind := range postingKinds {
+		for _, term := range kind.terms(terms) {
+			postings, err := index.postingShard(postingShard(kind.kind, term))
+			if err != nil {
+				return err
+			}
+			if _, found := slices.BinarySearch(postings[term], index.docs.ids[noteId]); !found {
+				return errNotPosted
+			}
+		}
+	}
+	return nil
+}
+
+// stage adds the encrypted shards changed since the index was read to b.
+// A fresh index also removes every stored shard it did not write, and the
+// JSON index of older versions.
+func (index *searchIndex) stage(b *Batch, compress bool) error {
+	written := make(map[string]bool)
+	for shard := range index.dirty {
+		var plaintext []byte
+		switch {
+		case shard == docsShard:
+			plaintext = encodeDocs(index.docs)
+		case strings.HasPrefix(shard, "terms-"):
+			if len(index.terms[shard]) == 0 {
+				b.Remove(path.Join(indexDir, shard))
+				continue
+			}
+			plaintext = encodeTerms(index.terms[shard])
+		default:
+			if len(index.postings[shard]) == 0 {
+				b.Remove(path.Join(indexDir, shard))
+				continue
+			}
+			plaintext = encodePostings(index.postings[shard])
+		}
+		payload, err := seal(index.kp, plaintext, compress)
+		if err != nil {
+			return err
+		}
+		b.Put(path.Join(indexDir, shard), payload)
+		written[shard] = true
+	}
+	if !index.fresh {
+		return nil
+	}
+
+	stored, err := index.store.blobs.List(index.username, indexDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, shard := range stored {
+		if !written[shard] && !index.dirty[shard] {
+			b.Remove(path.Join(indexDir, shard))
+		}
+	}
+	if _, err := index.store.blobs.Get(index.username, legacyIndexBlob); err == nil {
+		b.Remove(legacyIndexBlob)
+	}
+	return nil
+}
+
+// rebuildIndex returns a fresh index of notes that replaces the stored one
+// when staged.
+func (store *Store) rebuildIndex(notes []*Note, username string, kp *crypt.KeyProvider) (*searchIndex, error) {
+	index := store.newIndex(username, kp)
+	index.dirty[docsShard] = true
+	for _, note := range notes {
+		if err := index.add(note); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
+
+// rebuildReadable returns a fresh index of every note that can be read.
+func (store *Store) rebuildReadable(username string, kp *crypt.KeyProvider) (*searchIndex, error) {
+	notes, err := store.loadAll(username, kp)
+	var noteErr *NoteError
+	if err != nil && !errors.As(err, &noteErr) {
+		return nil, err
+	}
+	return store.rebuildIndex(notes, username, kp)
+}
+
+// RebuildIndex regenerates the user's index and manifest from scratch by
+// decrypting every note, and returns how many notes were indexed.
+func (store *Store) RebuildIndex(username string, kp *crypt.KeyProvider) (int, error) {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.rebuild(notes); err != nil {
+		return 0, err
+	}
+	if err := tx.commit(); err != nil {
+		return 0, err
+	}
+	return len(notes), nil
+}
@@ -0,0 +1,177 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// JournalTag marks journal entries. An entry's title and alias are its
+// date, YYYY-MM-DD, so `pkm note edit 2026-10-16` finds it too.
+const JournalTag = "journal"
+
+// DefaultJournalSkeleton starts entries when the journal.template setting
+// names no template.
+const DefaultJournalSkeleton = "# {{date}}\n\n"
+
+// ParseJournalDate reads YYYY-MM-DD, "today", "yesterday" or "tomorrow"
+// relative to now, and returns the day in now's location.
+func ParseJournalDate(value string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch value {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, today, yesterday or tomorrow", value)
+	}
+	return date, nil
+}
+
+// JournalAlias is the alias of the entry of date.
+func JournalAlias(date time.Time) string {
+	return date.Format(time.DateOnly)
+}
+
+// NewJournal returns an unsaved entry for date, tagged journal.
+func NewJournal(date time.Time, content string) *Note {
+	entry := NewNote(JournalAlias(date), content)
+	entry.Alias = JournalAlias(date)
+	entry.Tags = []string{JournalTag}
+	return entry
+}
+
+// isJournal reports whether the note summarized is a journal entry.
+func isJournal(summary NoteSummary) bool {
+	if !slices.Contains(summary.Tags, JournalTag) {
+		return false
+	}
+	_, err := time.Parse(time.DateOnly, summary.Alias)
+	return err == nil
+}
+
+// JournalEntries returns the summaries of every journal entry ordered by
+// date, from the manifest.
+func (store *Store) JournalEntries(username string, kp *crypt.KeyProvider) ([]NoteSummary, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	var entries []NoteSummary
+	for _, entry := range manifest.Notes {
+		if isJournal(entry.NoteSummary) {
+			entries = append(entries, entry.NoteSummary)
+		}
+	}
+	// Dates sort as strings
+	slices.SortFunc(entries, func(a, b NoteSummary) int { return strings.Compare(a.Alias, b.Alias) })
+	return entries, nil
+}
+
+// AddJournal saves entry, a new journal entry, and links it both ways to
+// the entries just before and after its date in place of their link to
+// each other, in one write. The targets of its other links get back-links.
+func (store *Store) AddJournal(entry *Note, username string, kp *crypt.KeyProvider) error {
+	if _, err := time.Parse(time.DateOnly, entry.Alias); err != nil {
+		return fmt.Errorf("journal entry alias %q is not a date", entry.Alias)
+	}
+	if !slices.Contains(entry.Tags, JournalTag) {
+		return errors.New("journal entry is not tagged " + JournalTag)
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := store.JournalEntries(username, kp)
+	if err != nil {
+		return err
+	}
+	i, found := slices.BinarySearchFunc(entries, entry.Alias, func(s NoteSummary, alias string) int {
+		return strings.Compare(s.Alias, alias)
+	})
+	if found {
+		return fmt.Errorf("journal entry %s already exists as note %s", entry.Alias, entries[i].Id)
+	}
+	var neighbours []*Note
+	for _, j := range []int{i - 1, i} {
+		if j < 0 || j >= len(entries) {
+			continue
+		}
+		neighbour, err := store.Load(entries[j].Id, username, kp)
+		if err != nil {
+			return err
+		}
+		neighbours = append(neighbours, neighbour)
+	}
+
+	changed := make([]bool, len(neighbours))
+	if len(neighbours) == 2 {
+		// The entry goes between two days that were linked to each other
+		changed[0] = unchain(neighbours[0], neighbours[1].Id)
+		changed[1] = unchain(neighbours[1], neighbours[0].Id)
+	}
+
+	var relinked []*Note
+	loaded := make(map[string]*Note, len(neighbours))
+	for i, neighbour := range neighbours {
+		loaded[neighbour.Id] = neighbour
+		if !entry.HasLink(neighbour.Id) {
+			entry.Links = append(entry.Links, Link{Target: neighbour.Id})
+		}
+		if neighbour.AddLink(entry.Id) == nil || changed[i] {
+			relinked = append(relinked, neighbour)
+		}
+	}
+	// Links the entry's template gave it are linked back too
+	targets, err := store.linkTargets(entry, loaded, username, kp)
+	if err != nil {
+		return err
+	}
+	for _, target := range linkBack(entry, targets) {
+		if !slices.Contains(relinked, target) {
+			relinked = append(relinked, target)
+		}
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	for _, other := range relinked {
+		if err := tx.save(other); err != nil {
+			return err
+		}
+	}
+	if err := tx.save(entry); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+// unchain removes the plain link from entry to targetId that kept the
+// journal's days in order, and reports whether there was one. Typed links
+// were made by hand and stay.
+func unchain(entry *Note, targetId string) bool {
+	link, ok := entry.LinkTo(targetId)
+	if !ok || link.Relation != "" {
+		return false
+	}
+	return entry.RemoveLink(targetId) == nil
+}
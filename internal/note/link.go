@@ -0,0 +1,372 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// relationInverses pairs each built-in relation with the relation of the
+// back-link its target gets. Other relations are their own inverse.
+var relationInverses = map[string]string{
+	"supports":    "supported-by",
+	"contradicts": "contradicts",
+	"example-of":  "has-example",
+	"source-of":   "derived-from",
+	"part-of":     "has-part",
+}
+
+// Relations lists the built-in relations and their inverses, sorted.
+func Relations() []string {
+	var relations []string
+	for relation, inverse := range relationInverses {
+		relations = append(relations, relation)
+		if inverse != relation {
+			relations = append(relations, inverse)
+		}
+	}
+	slices.Sort(relations)
+	return relations
+}
+
+// InverseRelation returns the relation a back-link to a link of relation
+// has: supports gives supported-by and supported-by gives supports.
+func InverseRelation(relation string) string {
+	if inverse, ok := relationInverses[relation]; ok {
+		return inverse
+	}
+	for forward, inverse := range relationInverses {
+		if inverse == relation {
+			return forward
+		}
+	}
+	return relation
+}
+
+// ValidateRelation accepts what Slugify produces, like aliases. Empty is a
+// plain link.
+func ValidateRelation(relation string) error {
+	if relation != "" && (len(relation) > MaxAliasLength || Slugify(relation) != relation) {
+		return fmt.Errorf("invalid relation %q: use lowercase letters, digits and single hyphens", relation)
+	}
+	return nil
+}
+
+// Inverse returns the back-link to from that the target of link gets,
+// or a zero Link for one-way links.
+func (link Link) Inverse(from string) Link {
+	if link.OneWay {
+		return Link{}
+	}
+	return Link{Target: from, Relation: InverseRelation(link.Relation), Annotation: link.Annotation}
+}
+
+// plain reports whether link is stored as its bare target id.
+func (link Link) plain() bool {
+	return link.Relation == "" && link.Annotation == "" && !link.OneWay
+}
+
+func (link Link) MarshalJSON() ([]byte, error) {
+	if link.plain() {
+		return json.Marshal(link.Target)
+	}
+	type object Link
+	return json.Marshal(object(link))
+}
+
+// UnmarshalJSON reads both typed links and the bare ids of plain links,
+// which is all notes saved before links had types hold.
+func (link *Link) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*link = Link{}
+		return json.Unmarshal(data, &link.Target)
+	}
+	type object Link
+	return json.Unmarshal(data, (*object)(link))
+}
+
+// linkIndex returns the position of the link to targetID, or -1.
+func (n *Note) linkIndex(targetID string) int {
+	return slices.IndexFunc(n.Links, func(link Link) bool { return link.Target == targetID })
+}
+
+// HasLink reports whether the note links to targetID.
+func (n *Note) HasLink(targetID string) bool {
+	return n.linkIndex(targetID) != -1
+}
+
+// LinkTo returns the note's link to targetID.
+func (n *Note) LinkTo(targetID string) (Link, bool) {
+	if i := n.linkIndex(targetID); i != -1 {
+		return n.Links[i], true
+	}
+	return Link{}, false
+}
+
+// LinkTargets returns the ids the note links to, in order.
+func (n *Note) LinkTargets() []string {
+	targets := make([]string, 0, len(n.Links))
+	for _, link := range n.Links {
+		targets = append(targets, link.Target)
+	}
+	return targets
+}
+
+// AddLink adds a plain link to targetID.
+func (n *Note) AddLink(targetID string) error {
+	return n.AddTypedLink(Link{Target: targetID})
+}
+
+// AddTypedLink adds link. A note links to each target once and never to
+// itself.
+func (n *Note) AddTypedLink(link Link) error {
+	if err := ValidateRelation(link.Relation); err != nil {
+		return err
+	}
+	if link.Target == n.Id {
+		return errors.New("a note cannot link to itself")
+	}
+	if n.HasLink(link.Target) {
+		return errors.New("link already present")
+	}
+	n.Links = append(n.Links, link)
+	return nil
+}
+
+func (n *Note) RemoveLink(targetID string) error {
+	// If note id not found in n.Links list return error else remove
+	index := n.linkIndex(targetID)
+	if index == -1 {
+		return errors.New(targetID + "link not found")
+	}
+	n.Links = slices.Delete(n.Links, index, index+1)
+	return nil
+}
+
+// MoveLink moves the link to targetID to position, counting from 1, so
+// that structure notes can order their children.
+func (n *Note) MoveLink(targetID string, position int) error {
+	index := n.linkIndex(targetID)
+	if index == -1 {
+		return errors.New(targetID + " link not found")
+	}
+	if position < 1 || position > len(n.Links) {
+		return fmt.Errorf("position %d out of range 1-%d", position, len(n.Links))
+	}
+	link := n.Links[index]
+	n.Links = slices.Delete(n.Links, index, index+1)
+	n.Links = slices.Insert(n.Links, position-1, link)
+	return nil
+}
+
+// LinkNotes links fromId to the target of link and, unless the link is
+// one-way or the target links back already, the target back to fromId, in
+// one write. The back-link has the inverse relation of link, or inverse
+// when it is not empty. The target has to exist.
+func (store *Store) LinkNotes(fromId string, link Link, inverse string, username string, kp *crypt.KeyProvider) error {
+	if fromId == link.Target {
+		return errors.New("a note cannot link to itself")
+	}
+	if err := ValidateRelation(inverse); err != nil {
+		return err
+	}
+	if inverse != "" && link.OneWay {
+		return errors.New("one-way links have no back-link to give a relation")
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	source, err := store.Load(fromId, username, kp)
+	if err != nil {
+		return err
+	}
+	target, err := store.Load(link.Target, username, kp)
+	if err != nil {
+		return fmt.Errorf("link target %s: %w", link.Target, err)
+	}
+	if err := source.AddTypedLink(link); err != nil {
+		return err
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	if err := tx.save(source); err != nil {
+		return err
+	}
+	if back := link.Inverse(fromId); back.Target != "" && !target.HasLink(fromId) {
+		if inverse != "" {
+			back.Relation = inverse
+		}
+		target.Links = append(target.Links, back)
+		if err := tx.save(target); err != nil {
+			return err
+		}
+	}
+	return tx.commit()
+}
+
+// UnlinkNotes removes the link from fromId to targetId and, when it was
+// two-way, the back-link it gave the target, in one write. A back-link the
+// target made on its own, or changed since, stays.
+func (store *Store) UnlinkNotes(fromId string, targetId string, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	source, err := store.Load(fromId, username, kp)
+	if err != nil {
+		return err
+	}
+	link, ok := source.LinkTo(targetId)
+	if !ok {
+		return fmt.Errorf("%s links to no note %s", fromId, targetId)
+	}
+	source.RemoveLink(targetId)
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	if err := tx.save(source); err != nil {
+		return err
+	}
+	// The target may be gone already
+	target, err := store.Load(targetId, username, kp)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tx.commit()
+	}
+	if err != nil {
+		return err
+	}
+	if back, ok := target.LinkTo(fromId); ok && !link.OneWay && back == link.Inverse(fromId) {
+		target.RemoveLink(fromId)
+		if err := tx.save(target); err != nil {
+			return err
+		}
+	}
+	return tx.commit()
+}
+
+// Create saves note, a new note, and gives the target of each of its
+// two-way links a back-link, in one write. The targets have to exist.
+func (store *Store) Create(note *Note, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := store.blobs.Get(username, note.Id+".pkm"); err == nil {
+		return fmt.Errorf("note %s already exists", note.Id)
+	}
+	targets, err := store.linkTargets(note, nil, username, kp)
+	if err != nil {
+		return err
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	if err := tx.save(note); err != nil {
+		return err
+	}
+	for _, target := range linkBack(note, targets) {
+		if err := tx.save(target); err != nil {
+			return err
+		}
+	}
+	return tx.commit()
+}
+
+// linkTargets returns the notes n links to by id, taken from loaded when
+// there and loaded from the store otherwise. Every target has to exist.
+func (store *Store) linkTargets(n *Note, loaded map[string]*Note, username string, kp *crypt.KeyProvider) (map[string]*Note, error) {
+	targets := make(map[string]*Note, len(n.Links))
+	for _, link := range n.Links {
+		if target, ok := loaded[link.Target]; ok {
+			targets[link.Target] = target
+			continue
+		}
+		target, err := store.Load(link.Target, username, kp)
+		if err != nil {
+			return nil, fmt.Errorf("link target %s: %w", link.Target, err)
+		}
+		targets[link.Target] = target
+	}
+	return targets, nil
+}
+
+// Edge is a link together with the note it starts from.
+type Edge struct {
+	From string
+	Link
+}
+
+// Edges returns the links between notes, only those of relation unless it
+// is empty, ordered by source note and then link order.
+func Edges(notes []*Note, relation string) []Edge {
+	sorted := slices.Clone(notes)
+	slices.SortFunc(sorted, func(a, b *Note) int { return strings.Compare(a.Id, b.Id) })
+	var edges []Edge
+	for _, note := range sorted {
+		for _, link := range note.Links {
+			if relation == "" || link.Relation == relation {
+				edges = append(edges, Edge{From: note.Id, Link: link})
+			}
+		}
+	}
+	return edges
+}
+
+// Step is a note reached by Walk through the edge Via, Depth links away
+// from where the walk started.
+type Step struct {
+	Depth int
+	Via   Edge
+}
+
+// Walk follows links breadth first from start, at most depth links deep,
+// and returns every note reached once, in the order reached. Only links
+// of relation are followed unless it is empty.
+func Walk(notes []*Note, start string, depth int, relation string) []Step {
+	byId := make(map[string]*Note, len(notes))
+	for _, note := range notes {
+		byId[note.Id] = note
+	}
+	seen := map[string]bool{start: true}
+	frontier := []string{start}
+	var steps []Step
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			note, ok := byId[id]
+			if !ok {
+				continue
+			}
+			for _, link := range note.Links {
+				if seen[link.Target] || (relation != "" && link.Relation != relation) {
+					continue
+				}
+				seen[link.Target] = true
+				steps = append(steps, Step{Depth: d, Via: Edge{From: id, Link: link}})
+				next = append(next, link.Target)
+			}
+		}
+		frontier = next
+	}
+	return steps
+}
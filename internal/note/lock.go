@@ -0,0 +1,184 @@
+package note
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	lockBlob           = ".lock"
+	DefaultLockTimeout = 10 * time.Second
+	lockPollInterval   = 50 * time.Millisecond
+)
+
+var errWouldBlock = errors.New("lock would block")
+
+// LockedError reports that another process holds the user's store lock.
+type LockedError struct {
+	Pid int
+}
+
+func (e *LockedError) Error() string {
+	if e.Pid == 0 {
+		return "store is locked by another process"
+	}
+	return fmt.Sprintf("store is locked by pid %d", e.Pid)
+}
+
+// lockTable holds a read-write mutex per user, so that goroutines of this
+// process take turns the way the flock makes processes take turns.
+type lockTable struct {
+	mu    sync.Mutex
+	users map[string]*sync.RWMutex
+}
+
+func (lt *lockTable) user(username string) *sync.RWMutex {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+
+	if lt.users == nil {
+		lt.users = make(map[string]*sync.RWMutex)
+	}
+	mu, ok := lt.users[username]
+	if !ok {
+		mu = new(sync.RWMutex)
+		lt.users[username] = mu
+	}
+	return mu
+}
+
+// heldLock is the user's store lock a view returned by Locked runs under.
+type heldLock struct {
+	username  string
+	exclusive bool
+}
+
+// Locked takes the user's store lock, shared for readers or exclusive for
+// writers, and returns a view of the store that runs under it. Calls for
+// username through the view do not lock again, so a read-modify-write
+// cycle goes through it; a shared view refuses to write. Goroutines of this
+// process wait for each other, other processes for up to the lock timeout.
+// The lock is not reentrant: calling the store itself while holding it
+// deadlocks.
+func (store *Store) Locked(username string, exclusive bool) (*Store, func(), error) {
+	if held := store.held; held != nil && held.username == username {
+		if exclusive && !held.exclusive {
+			return nil, nil, errors.New("cannot upgrade a shared store lock")
+		}
+		return store, func() {}, nil
+	}
+
+	mu := store.locks.user(username)
+	if exclusive {
+		mu.Lock()
+	} else {
+		mu.RLock()
+	}
+	unlockLocal := func() {
+		if exclusive {
+			mu.Unlock()
+		} else {
+			mu.RUnlock()
+		}
+	}
+	release, err := store.blobs.Lock(username, exclusive, store.lockTimeout)
+	if err != nil {
+		unlockLocal()
+		return nil, nil, err
+	}
+
+	view := *store
+	view.held = &heldLock{username: username, exclusive: exclusive}
+	var once sync.Once
+	return &view, func() {
+		once.Do(func() {
+			release()
+			unlockLocal()
+		})
+	}, nil
+}
+
+// SetLockTimeout sets how long Lock waits for another process; zero fails
+// immediately.
+func (store *Store) SetLockTimeout(timeout time.Duration) {
+	store.lockTimeout = timeout
+}
+
+// Fingerprint identifies the stored revision of a note, so callers can tell
+// whether it changed since they read it.
+func (store *Store) Fingerprint(noteId string, username string) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	fileData, err := store.blobs.Get(username, noteId+".pkm")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(fileData)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Lock takes an flock on <user>/.lock. Exclusive holders record their pid
+// so that waiting processes can say who holds it.
+func (f *FileBackend) Lock(username string, exclusive bool, timeout time.Duration) (func(), error) {
+	lockPath := f.path(username, lockBlob)
+	if !exclusive {
+		// Nothing to read yet, nothing to protect
+		if _, err := os.Stat(f.path(username, "")); errors.Is(err, fs.ErrNotExist) {
+			return func() {}, nil
+		}
+	}
+	if err := os.MkdirAll(f.path(username, ""), 0755); err != nil {
+		return nil, err
+	}
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(lockFile, exclusive)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) {
+			lockFile.Close()
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			lockFile.Close()
+			return nil, lockHolder(lockPath)
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	if exclusive {
+		lockFile.Truncate(0)
+		lockFile.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+	}
+	return func() {
+		if exclusive {
+			lockFile.Truncate(0)
+		}
+		unlockFile(lockFile)
+		lockFile.Close()
+	}, nil
+}
+
+func lockHolder(lockPath string) error {
+	data, _ := os.ReadFile(lockPath)
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return &LockedError{Pid: pid}
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package note
+
+import "os"
+
+// Advisory locking is only implemented on Linux and macOS; elsewhere the
+// store is not protected against concurrent processes.
+func tryLock(file *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,170 @@
+package note
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const manifestBlob = ".manifest.pkm"
+
+// ProblemManifest is reported when the manifest no longer matches a note.
+const ProblemManifest = "manifest"
+
+func newManifest() *Manifest {
+	return &Manifest{Notes: make(map[string]ManifestEntry)}
+}
+
+// noteHash is the keyed hash of a note's JSON recorded in the manifest.
+func noteHash(note *Note, kp *crypt.KeyProvider) (string, error) {
+	jsonBody, err := json.Marshal(note)
+	if err != nil {
+		return "", err
+	}
+	return kp.ContentHash(jsonBody), nil
+}
+
+// add records note's summary. Its update time comes from the note; for
+// notes written before notes kept one it is updatedAt, or when that is zero
+// the time of an earlier entry.
+func (manifest *Manifest) add(note *Note, updatedAt time.Time, kp *crypt.KeyProvider) error {
+	hash, err := noteHash(note, kp)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !note.UpdatedAt.IsZero():
+		updatedAt = note.UpdatedAt
+	case updatedAt.IsZero():
+		updatedAt = note.CreatedAt
+		if previous, ok := manifest.Notes[note.Id]; ok {
+			updatedAt = previous.UpdatedAt
+		}
+	}
+	manifest.Notes[note.Id] = ManifestEntry{
+		NoteSummary: NoteSummary{
+			Id:        note.Id,
+			Title:     note.Title,
+			Alias:     note.Alias,
+			Type:      note.Type,
+			Tags:      note.Tags,
+			CreatedAt: note.CreatedAt,
+			UpdatedAt: updatedAt,
+			Edits:     note.Edits,
+			Words:     note.Words,
+
+			Properties: note.Properties,
+		},
+		Hash: hash,
+	}
+	return nil
+}
+
+func (manifest *Manifest) drop(noteId string) {
+	delete(manifest.Notes, noteId)
+}
+
+// readManifest returns the user's manifest. Vaults written before the
+// manifest existed, or whose manifest cannot be read, get one built from
+// every note that can be decrypted.
+func (store *Store) readManifest(username string, kp *crypt.KeyProvider) (*Manifest, error) {
+	fileData, err := store.blobs.Get(username, manifestBlob)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	manifest := newManifest()
+	if err == nil && openJSON(fileData, kp, manifest, "manifest") == nil {
+		if manifest.Notes == nil {
+			manifest.Notes = make(map[string]ManifestEntry)
+		}
+		return manifest, nil
+	}
+
+	manifest = newManifest()
+	notes, err := store.loadAll(username, kp)
+	var noteErr *NoteError
+	if err != nil && !errors.As(err, &noteErr) {
+		return nil, err
+	}
+	// Notes that cannot be read are left to fsck
+	for _, note := range notes {
+		if err := manifest.add(note, time.Time{}, kp); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// stageManifest adds the encrypted manifest to b.
+func stageManifest(b *Batch, manifest *Manifest, kp *crypt.KeyProvider, compress bool) error {
+	payload, err := sealJSON(kp, manifest, compress)
+	if err != nil {
+		return err
+	}
+	b.Put(manifestBlob, payload)
+	return nil
+}
+
+// VerifyManifest compares the stored manifest against every note and
+// reports entries that drifted from them.
+func (store *Store) VerifyManifest(username string, kp *crypt.KeyProvider) ([]Problem, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*Note, len(notes))
+	for _, note := range notes {
+		byId[note.Id] = note
+	}
+	return store.checkManifest(username, kp, byId)
+}
+
+// checkManifest compares the stored manifest against the readable notes.
+func (store *Store) checkManifest(username string, kp *crypt.KeyProvider, notes map[string]*Note) ([]Problem, error) {
+	fileData, err := store.blobs.Get(username, manifestBlob)
+	if errors.Is(err, fs.ErrNotExist) {
+		if len(notes) == 0 {
+			return nil, nil
+		}
+		return []Problem{{Kind: ProblemManifest, Name: manifestBlob, Detail: "manifest missing"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest := newManifest()
+	if err := openJSON(fileData, kp, manifest, "manifest"); err != nil {
+		return []Problem{{Kind: ProblemManifest, Name: manifestBlob, Detail: err.Error()}}, nil
+	}
+
+	var problems []Problem
+	for id := range manifest.Notes {
+		if _, ok := notes[id]; !ok {
+			problems = append(problems, Problem{Kind: ProblemManifest, Name: id, Detail: "manifest lists missing note"})
+		}
+	}
+	for id, note := range notes {
+		entry, ok := manifest.Notes[id]
+		if !ok {
+			problems = append(problems, Problem{Kind: ProblemManifest, Name: id, Detail: "note missing from manifest"})
+			continue
+		}
+		hash, err := noteHash(note, kp)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Hash != hash {
+			problems = append(problems, Problem{Kind: ProblemManifest, Name: id, Detail: "manifest entry out of date"})
+		}
+	}
+	sortProblems(problems)
+	return problems, nil
+}
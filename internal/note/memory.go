@@ -0,0 +1,113 @@
+package note
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"time"
+)
+
+// NewMemoryStore returns an empty Store held entirely in memory.
+func NewMemoryStore() *Store {
+	return NewStore(NewMemoryBackend())
+}
+
+// MemoryBackend is a Backend that never touches disk, for tests and
+// embedding.
+type MemoryBackend struct {
+	mu    sync.RWMutex
+	users map[string]map[string][]byte
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{users: make(map[string]map[string][]byte)}
+}
+
+func (m *MemoryBackend) Get(username string, name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.users[username][name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path.Join(username, name), Err: fs.ErrNotExist}
+	}
+	return slices.Clone(data), nil
+}
+
+func (m *MemoryBackend) Put(username string, name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.users[username] == nil {
+		m.users[username] = make(map[string][]byte)
+	}
+	m.users[username][name] = slices.Clone(data)
+	return nil
+}
+
+func (m *MemoryBackend) Remove(username string, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[username][name]; !ok {
+		return &fs.PathError{Op: "remove", Path: path.Join(username, name), Err: fs.ErrNotExist}
+	}
+	delete(m.users[username], name)
+	return nil
+}
+
+func (m *MemoryBackend) List(username string, dir string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if dir == "" {
+		dir = "."
+	}
+	var names []string
+	for name := range m.users[username] {
+		if path.Dir(name) == dir {
+			names = append(names, path.Base(name))
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (m *MemoryBackend) Walk(username string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var names []string
+	for name := range m.users[username] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (m *MemoryBackend) Commit(username string, b *Batch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.users[username] == nil {
+		m.users[username] = make(map[string][]byte)
+	}
+	for _, p := range b.Puts {
+		m.users[username][p.Name] = slices.Clone(p.Data)
+	}
+	for _, name := range b.Removes {
+		delete(m.users[username], name)
+	}
+	return nil
+}
+
+// Recover is a no-op: memory writes are never partially applied.
+func (m *MemoryBackend) Recover(username string) error {
+	return nil
+}
+
+// Lock has nothing to coordinate: a memory store lives in one process.
+func (m *MemoryBackend) Lock(username string, exclusive bool, timeout time.Duration) (func(), error) {
+	return func() {}, nil
+}
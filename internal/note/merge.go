@@ -0,0 +1,111 @@
+package note
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// Merge folds the note fromId into intoId and moves it to the trash, in
+// one write. The content of fromId is appended to that of intoId, which
+// gains its tags, links, attachments and any properties it does not set
+// itself, and notes linking to fromId link to intoId instead.
+func (store *Store) Merge(fromId string, intoId string, username string, kp *crypt.KeyProvider) (*Note, error) {
+	if fromId == intoId {
+		return nil, errors.New("cannot merge a note into itself")
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	from, err := store.Load(fromId, username, kp)
+	if err != nil {
+		return nil, err
+	}
+	into, err := store.Load(intoId, username, kp)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return nil, err
+	}
+
+	into.Content = joinContent(into.Content, from.Content)
+	for _, tag := range from.Tags {
+		if !slices.Contains(into.Tags, tag) {
+			into.Tags = append(into.Tags, tag)
+		}
+	}
+	for key, property := range from.Properties {
+		if _, ok := into.Properties[key]; !ok {
+			if into.Properties == nil {
+				into.Properties = make(map[string]Property)
+			}
+			into.Properties[key] = property
+		}
+	}
+	for _, attachment := range from.Attachments {
+		if !slices.ContainsFunc(into.Attachments, func(a Attachment) bool { return a.Name == attachment.Name }) {
+			into.Attachments = append(into.Attachments, attachment)
+		}
+	}
+	into.Links = slices.DeleteFunc(into.Links, func(link Link) bool { return link.Target == fromId })
+	for _, link := range from.Links {
+		if link.Target != intoId && !into.HasLink(link.Target) {
+			into.Links = append(into.Links, link)
+		}
+	}
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range notes {
+		if other.Id == fromId || other.Id == intoId || !other.HasLink(fromId) {
+			continue
+		}
+		other.Links = redirectLink(other.Links, fromId, intoId)
+		if err := tx.save(other); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.save(into); err != nil {
+		return nil, err
+	}
+	if err := tx.trash(TrashEntry{Note: from, DeletedAt: time.Now().UTC()}); err != nil {
+		return nil, err
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return into, nil
+}
+
+// joinContent appends addition to content after a blank line.
+func joinContent(content string, addition string) string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return addition
+	}
+	if addition == "" {
+		return content + "\n"
+	}
+	return content + "\n\n" + addition
+}
+
+// redirectLink points the link to from at to instead, keeping its
+// position and relation unless links already holds one to to.
+func redirectLink(links []Link, from string, to string) []Link {
+	i := slices.IndexFunc(links, func(link Link) bool { return link.Target == from })
+	if slices.ContainsFunc(links, func(link Link) bool { return link.Target == to }) {
+		return slices.Delete(links, i, i+1)
+	}
+	links[i].Target = to
+	return links
+}
@@ -0,0 +1,44 @@
+package note
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// stamp maintains the metadata Save keeps on note: the word count and
+// content hash always, the update time and edit counter whenever the note
+// differs from before, the stored revision it replaces (nil for a new
+// note). It reports whether the note changed.
+func stamp(note *Note, before *Note, now time.Time) bool {
+	note.Words = len(strings.Fields(note.Content))
+	sum := sha256.Sum256([]byte(note.Content))
+	note.ContentHash = hex.EncodeToString(sum[:])
+	if before == nil {
+		note.UpdatedAt = now
+		return true
+	}
+
+	note.Edits = before.Edits
+	note.UpdatedAt = before.UpdatedAt
+	if sameNote(note, before) {
+		return false
+	}
+	note.Edits++
+	note.UpdatedAt = now
+	return true
+}
+
+// sameNote compares two notes ignoring the metadata stamp maintains, so
+// that notes written before it existed do not count as edited.
+func sameNote(a *Note, b *Note) bool {
+	strip := func(n *Note) []byte {
+		c := *n
+		c.UpdatedAt, c.Edits, c.ContentHash, c.Words = time.Time{}, 0, "", 0
+		jsonBody, _ := json.Marshal(&c)
+		return jsonBody
+	}
+	return string(strip(a)) == string(strip(b))
+}
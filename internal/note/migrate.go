@@ -0,0 +1,126 @@
+package note
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const backupDir = ".backup"
+
+// Migrate rewrites every blob still in the legacy "PKM\n" layout with the
+// current versioned header. The original blobs are kept under
+// .backup/migrate-<time>/ and everything lands in one write. A JSON index
+// of older versions, or a sharded one of an older layout, is then replaced
+// by a rebuilt one. With dryRun
+// nothing is written and the report lists what would be upgraded.
+func (store *Store) Migrate(dryRun bool, username string, kp *crypt.KeyProvider) (*MigrateReport, error) {
+	store, unlock, err := store.Locked(username, !dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	backup := path.Join(backupDir, "migrate-"+time.Now().UTC().Format("20060102T150405Z"))
+	report, err := store.rewrite(dryRun, backup, username, kp, func(data []byte, compress bool) bool {
+		return isLegacy(data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	index, err := store.readIndex(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	stale := indexDir
+	if _, err := store.blobs.Get(username, legacyIndexBlob); err == nil {
+		stale = legacyIndexBlob
+	} else if !index.outdated {
+		return report, nil
+	}
+	if !slices.Contains(report.Upgraded, stale) {
+		report.Upgraded = append(report.Upgraded, stale)
+	}
+	if !dryRun {
+		if _, err := store.RebuildIndex(username, kp); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// Recompress rewrites every blob whose compression differs from the
+// compression setting, in one write. Blobs that gzip would not shrink stay
+// uncompressed.
+func (store *Store) Recompress(dryRun bool, username string, kp *crypt.KeyProvider) (*MigrateReport, error) {
+	return store.rewrite(dryRun, "", username, kp, func(data []byte, compress bool) bool {
+		return hasHeader(data) && isCompressed(data) != compress
+	})
+}
+
+// rewrite reseals every blob stale reports true for with the current
+// format and compression setting. Originals are kept under backup unless
+// it is empty.
+func (store *Store) rewrite(dryRun bool, backup string, username string, kp *crypt.KeyProvider, stale func(data []byte, compress bool) bool) (*MigrateReport, error) {
+	store, unlock, err := store.Locked(username, !dryRun)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	settings, err := store.readSettings(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	names, err := store.blobs.Walk(username)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrateReport{}
+	var b Batch
+	for _, name := range names {
+		if strings.HasPrefix(name, backupDir+"/") || strings.HasPrefix(name, quarantineDir+"/") {
+			continue
+		}
+		fileData, err := store.blobs.Get(username, name)
+		if err != nil {
+			return nil, err
+		}
+		compress := settings.Compressed() && name != settingsBlob
+		if !stale(fileData, compress) {
+			continue
+		}
+		plaintext, err := open(fileData, kp)
+		if err != nil {
+			report.Skipped = append(report.Skipped, name)
+			continue
+		}
+		payload, err := seal(kp, plaintext, compress)
+		if err != nil {
+			return nil, err
+		}
+		if !isLegacy(fileData) && isCompressed(payload) == isCompressed(fileData) {
+			// Would not change, e.g. gzip cannot shrink it
+			continue
+		}
+
+		report.Upgraded = append(report.Upgraded, name)
+		if backup != "" {
+			b.Put(path.Join(backup, name), fileData)
+		}
+		b.Put(name, payload)
+	}
+
+	if dryRun || b.Empty() {
+		return report, nil
+	}
+	if err := store.blobs.Commit(username, &b); err != nil {
+		return nil, err
+	}
+	report.Backup = backup
+	return report, nil
+}
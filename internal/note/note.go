@@ -2,6 +2,7 @@ package note
 
 import (
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -18,53 +19,69 @@ func NewNote(title string, content string) *Note {
 	}
 }
 
-func (n *Note) AddLink(targetID string) error {
-	// If note id not found return error
-	if slices.Contains(n.Links, targetID) {
-		return errors.New("link already present")
+// TagSeparator splits hierarchical tags: cs/algorithms is below cs.
+const TagSeparator = "/"
+
+// NormalizeTag lowercases tag and trims whitespace around it and around
+// each level of a hierarchical tag, so "CS / Graphs" becomes cs/graphs.
+// Empty tags or levels and tags holding commas are rejected.
+func NormalizeTag(tag string) (string, error) {
+	levels := strings.Split(strings.ToLower(tag), TagSeparator)
+	for i, level := range levels {
+		levels[i] = strings.TrimSpace(level)
+		if levels[i] == "" {
+			if len(levels) == 1 {
+				return "", errors.New("empty tag")
+			}
+			return "", fmt.Errorf("invalid tag %q: empty level", tag)
+		}
 	}
-	n.Links = append(n.Links, targetID)
-	return nil
+	tag = strings.Join(levels, TagSeparator)
+	if strings.Contains(tag, ",") {
+		return "", fmt.Errorf("invalid tag %q: tags cannot contain commas", tag)
+	}
+	return tag, nil
 }
 
-func (n *Note) RemoveLink(targetID string) error {
-	// If note id not found in n.Links list return error else remove
-	index := slices.Index(n.Links, targetID)
-	if index == -1 {
-		return errors.New(targetID + "link not found")
-	}
-	n.Links = slices.Delete(n.Links, index, index+1)
-	return nil
+// TagUnder reports whether tag is parent or one of its descendants.
+func TagUnder(tag string, parent string) bool {
+	return tag == parent || strings.HasPrefix(tag, parent+TagSeparator)
 }
 
+// AddTag adds the comma-separated tags of tagList. It fails, adding
+// none, when a tag is empty or already present.
 func (n *Note) AddTag(tagList string) error {
-	// if tags has some issues return error
-	tags := strings.Split(strings.ToLower(tagList), ",")
-	for _, tag := range tags {
-		if slices.Contains(n.Tags, strings.ToLower(tag)) {
+	var tags []string
+	for _, tag := range strings.Split(tagList, ",") {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(n.Tags, tag) || slices.Contains(tags, tag) {
 			return errors.New("tag already present")
 		}
+		tags = append(tags, tag)
 	}
 
 	n.Tags = append(n.Tags, tags...)
 	return nil
 }
 
+// RemoveTag removes the comma-separated tags of tagList, read as AddTag
+// reads them. It fails, removing none, when a tag is not on the note.
 func (n *Note) RemoveTag(tagList string) error {
-	tags := strings.Split(strings.ToLower(tagList), ",")
-	var indexes []int
-	for _, tag := range tags {
-		tag = strings.TrimSpace(tag) // trim whitespace
-		index := slices.Index(n.Tags, tag)
-		if index == -1 {
+	var tags []string
+	for _, tag := range strings.Split(tagList, ",") {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(n.Tags, tag) {
 			return errors.New("tag not found")
 		}
-		indexes = append(indexes, index)
+		tags = append(tags, tag)
 	}
 
-	// Delete in reverse order to avoid index shifting
-	for i := len(indexes) - 1; i >= 0; i-- {
-		n.Tags = slices.Delete(n.Tags, indexes[i], indexes[i]+1)
-	}
+	n.Tags = slices.DeleteFunc(n.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
 	return nil
 }
@@ -0,0 +1,67 @@
+package note
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Zettelkasten note types. Fleeting notes are quick captures waiting in
+// the inbox, literature notes summarize a source, permanent notes hold one
+// idea in your own words and structure notes order links to other notes.
+const (
+	TypeFleeting   = "fleeting"
+	TypeLiterature = "literature"
+	TypePermanent  = "permanent"
+	TypeStructure  = "structure"
+)
+
+// NoteTypes lists every note type.
+var NoteTypes = []string{TypeFleeting, TypeLiterature, TypePermanent, TypeStructure}
+
+// SourceProperty is the property every literature note must have.
+const SourceProperty = "source"
+
+// TypeOf returns the type a note stored with noteType has: notes written
+// before types existed are permanent.
+func TypeOf(noteType string) string {
+	if noteType == "" {
+		return TypePermanent
+	}
+	return noteType
+}
+
+// ValidateType accepts the names in NoteTypes.
+func ValidateType(noteType string) error {
+	if !slices.Contains(NoteTypes, noteType) {
+		return fmt.Errorf("unknown note type %q (use %s)", noteType, strings.Join(NoteTypes, ", "))
+	}
+	return nil
+}
+
+// SetType changes the note's type.
+func (n *Note) SetType(noteType string) error {
+	if err := ValidateType(noteType); err != nil {
+		return err
+	}
+	n.Type = noteType
+	return nil
+}
+
+// CheckType enforces what a note's type requires; Save refuses notes
+// failing it.
+func CheckType(note *Note) error {
+	if note.Type == "" {
+		return nil
+	}
+	if err := ValidateType(note.Type); err != nil {
+		return err
+	}
+	if note.Type == TypeLiterature {
+		source, ok := note.Properties[SourceProperty]
+		if !ok || (strings.TrimSpace(source.Value) == "" && len(source.Items) == 0) {
+			return fmt.Errorf("literature note %s needs a %s property", note.Id, SourceProperty)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// NoteError is a note that a bulk read could not load.
+type NoteError struct {
+	Id  string
+	Err error
+}
+
+func (e *NoteError) Error() string {
+	return "note " + e.Id + ": " + e.Err.Error()
+}
+
+func (e *NoteError) Unwrap() error {
+	return e.Err
+}
+
+// SetParallelism sets how many notes bulk operations decrypt at once;
+// zero or less uses one worker per CPU.
+func (store *Store) SetParallelism(workers int) {
+	store.parallelism = workers
+}
+
+func (store *Store) workers() int {
+	if store.parallelism > 0 {
+		return store.parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallel runs work for every i in [0, n) on at most workers goroutines
+// and returns results and errors in input order. Once ctx is cancelled no
+// new work starts and ctx.Err() is returned.
+func parallel[T any](ctx context.Context, n int, workers int, work func(i int) (T, error)) ([]T, []error, error) {
+	results := make([]T, n)
+	errs := make([]error, n)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i], errs[i] = work(i)
+			}
+		}()
+	}
+
+feed:
+	for i := range n {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs, ctx.Err()
+}
+
+// LoadAll decrypts every note of the user concurrently, ordered by id, and
+// skips files without the PKM header. Notes that fail to load are left out
+// and reported together as *NoteError values joined into the returned
+// error.
+func (store *Store) LoadAll(ctx context.Context, username string, kp *crypt.KeyProvider) ([]*Note, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return store.readNotes(ctx, username, kp)
+}
+
+func (store *Store) readNotes(ctx context.Context, username string, kp *crypt.KeyProvider) ([]*Note, error) {
+	ids, err := store.noteIds(username)
+	if err != nil {
+		return nil, err
+	}
+	loaded, errs, err := parallel(ctx, len(ids), store.workers(), func(i int) (*Note, error) {
+		fileData, err := store.blobs.Get(username, ids[i]+".pkm")
+		if err != nil {
+			return nil, err
+		}
+		if !hasHeader(fileData) {
+			// Not a note; fsck reports it
+			return nil, nil
+		}
+		return openNote(fileData, kp)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []*Note
+	var noteErrs []error
+	for i, note := range loaded {
+		if errs[i] != nil {
+			noteErrs = append(noteErrs, &NoteError{Id: ids[i], Err: errs[i]})
+			continue
+		}
+		if note != nil {
+			notes = append(notes, note)
+		}
+	}
+	return notes, errors.Join(noteErrs...)
+}
@@ -0,0 +1,206 @@
+package note
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// The index shards are stored as compact binary records inside the usual
+// sealed blob. Integers are unsigned varints, strings are a length
+// followed by their bytes, and posting lists are sorted doc ids stored as
+// deltas from the previous id.
+//
+// The doc table starts with a zero and the layout version of every shard.
+// Tables without it predate property terms and are rebuilt. Version 3
+// splits words at line breaks.
+const indexVersion = 3
+
+var (
+	errShortRecord  = errors.New("truncated index record")
+	errIndexVersion = errors.New("index uses an older layout")
+)
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) uvarint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) strings(list []string) {
+	e.uvarint(uint64(len(list)))
+	for _, s := range list {
+		e.string(s)
+	}
+}
+
+// postings writes sorted doc ids as deltas.
+func (e *encoder) postings(ids []uint32) {
+	e.uvarint(uint64(len(ids)))
+	previous := uint32(0)
+	for _, id := range ids {
+		e.uvarint(uint64(id - previous))
+		previous = id
+	}
+}
+
+// decoder reads what encoder wrote; the first error sticks and zero values
+// are returned from then on.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errShortRecord
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// count reads a length and checks that at least that many bytes remain,
+// so corrupt input cannot make us allocate huge slices.
+func (d *decoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.data)) {
+		d.err = errShortRecord
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() string {
+	n := d.count()
+	if d.err != nil {
+		return ""
+	}
+	s := string(d.data[:n])
+	d.data = d.data[n:]
+	return s
+}
+
+func (d *decoder) strings() []string {
+	n := d.count()
+	var list []string
+	for range n {
+		list = append(list, d.string())
+	}
+	return list
+}
+
+func (d *decoder) postings() []uint32 {
+	n := d.count()
+	ids := make([]uint32, 0, n)
+	previous := uint32(0)
+	for range n {
+		previous += uint32(d.uvarint())
+		ids = append(ids, previous)
+	}
+	return ids
+}
+
+// encodeDocs serializes the doc table: zero and the version, next id, then
+// uuid and doc id pairs ordered by doc id.
+func encodeDocs(docs *docTable) []byte {
+	var e encoder
+	e.uvarint(0)
+	e.uvarint(indexVersion)
+	e.uvarint(uint64(docs.next))
+	ids := make([]uint32, 0, len(docs.uuids))
+	for id := range docs.uuids {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	e.uvarint(uint64(len(ids)))
+	for _, id := range ids {
+		e.string(docs.uuids[id])
+		e.uvarint(uint64(id))
+	}
+	return e.buf
+}
+
+func decodeDocs(data []byte) (*docTable, error) {
+	d := decoder{data: data}
+	if d.uvarint() != 0 {
+		return nil, errIndexVersion
+	}
+	if version := d.uvarint(); version != indexVersion {
+		return nil, fmt.Errorf("%w: version %d", errIndexVersion, version)
+	}
+	docs := newDocTable()
+	docs.next = uint32(d.uvarint())
+	for range d.count() {
+		noteId := d.string()
+		docs.set(noteId, uint32(d.uvarint()))
+	}
+	return docs, d.err
+}
+
+// encodeTerms serializes a term shard: the keywords, tags and property
+// terms each doc is indexed under, ordered by doc id.
+func encodeTerms(shard map[uint32]IndexTerms) []byte {
+	var e encoder
+	ids := make([]uint32, 0, len(shard))
+	for id := range shard {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	e.uvarint(uint64(len(ids)))
+	for _, id := range ids {
+		e.uvarint(uint64(id))
+		e.strings(shard[id].Keywords)
+		e.strings(shard[id].Tags)
+		e.strings(shard[id].Props)
+	}
+	return e.buf
+}
+
+func decodeTerms(data []byte) (map[uint32]IndexTerms, error) {
+	d := decoder{data: data}
+	shard := make(map[uint32]IndexTerms)
+	for range d.count() {
+		id := uint32(d.uvarint())
+		shard[id] = IndexTerms{Keywords: d.strings(), Tags: d.strings(), Props: d.strings()}
+	}
+	return shard, d.err
+}
+
+// encodePostings serializes a posting shard ordered by term.
+func encodePostings(shard map[string][]uint32) []byte {
+	var e encoder
+	terms := make([]string, 0, len(shard))
+	for term := range shard {
+		terms = append(terms, term)
+	}
+	slices.Sort(terms)
+	e.uvarint(uint64(len(terms)))
+	for _, term := range terms {
+		e.string(term)
+		e.postings(shard[term])
+	}
+	return e.buf
+}
+
+func decodePostings(data []byte) (map[string][]uint32, error) {
+	d := decoder{data: data}
+	shard := make(map[string][]uint32)
+	for range d.count() {
+		term := d.string()
+		shard[term] = d.postings()
+	}
+	return shard, d.err
+}
@@ -0,0 +1,294 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of property values.
+const (
+	PropertyString = "string"
+	PropertyNumber = "number"
+	PropertyDate   = "date"
+	PropertyBool   = "bool"
+	PropertyList   = "list"
+)
+
+// DateLayout is how date properties are written.
+const DateLayout = time.DateOnly
+
+// Property is a typed value attached to a note under a key. Scalars keep
+// their text in Value, canonical except for numbers, which stay as written
+// (1.10, 01234); lists keep their items in Items.
+type Property struct {
+	Type  string   `json:"type"`
+	Value string   `json:"value,omitempty"`
+	Items []string `json:"items,omitempty"`
+}
+
+// ParseValue reads a property value written as in frontmatter: true and
+// false are bools, numbers and YYYY-MM-DD dates are recognized, [a, b] is
+// a list, and anything else, or anything quoted, is a string.
+func ParseValue(text string) (Property, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "["):
+		if !strings.HasSuffix(text, "]") {
+			return Property{}, fmt.Errorf("unterminated list %q", text)
+		}
+		items, err := splitList(text[1 : len(text)-1])
+		if err != nil {
+			return Property{}, err
+		}
+		return Property{Type: PropertyList, Items: items}, nil
+	case strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'"):
+		value, err := unquote(text)
+		if err != nil {
+			return Property{}, err
+		}
+		return Property{Type: PropertyString, Value: value}, nil
+	case text == "true" || text == "false":
+		return Property{Type: PropertyBool, Value: text}, nil
+	}
+	if number, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(number, 0) && !math.IsNaN(number) {
+		return Property{Type: PropertyNumber, Value: text}, nil
+	}
+	if date, err := time.Parse(DateLayout, text); err == nil {
+		return Property{Type: PropertyDate, Value: date.Format(DateLayout)}, nil
+	}
+	return Property{Type: PropertyString, Value: text}, nil
+}
+
+// String formats the value so that ParseValue reads it back unchanged.
+func (p Property) String() string {
+	if p.Type == PropertyList {
+		items := make([]string, len(p.Items))
+		for i, item := range p.Items {
+			items[i] = quoteIfNeeded(item, true)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	if p.Type == PropertyString {
+		return quoteIfNeeded(p.Value, false)
+	}
+	return p.Value
+}
+
+// Number returns the value of a number property.
+func (p Property) Number() (float64, bool) {
+	if p.Type != PropertyNumber {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(p.Value, 64)
+	return number, err == nil
+}
+
+// Date returns the value of a date property.
+func (p Property) Date() (time.Time, bool) {
+	if p.Type != PropertyDate {
+		return time.Time{}, false
+	}
+	date, err := time.Parse(DateLayout, p.Value)
+	return date, err == nil
+}
+
+// Bool returns the value of a bool property.
+func (p Property) Bool() (bool, bool) {
+	if p.Type != PropertyBool {
+		return false, false
+	}
+	return p.Value == "true", true
+}
+
+// quoteIfNeeded quotes s when ParseValue would not read it back as the
+// same string, or inList when it would break a list apart.
+func quoteIfNeeded(s string, inList bool) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\"'#\n") ||
+		(inList && strings.ContainsAny(s, ",[]")) {
+		return strconv.Quote(s)
+	}
+	if parsed, err := ParseValue(s); err != nil || parsed.Type != PropertyString || parsed.Value != s {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func unquote(text string) (string, error) {
+	if strings.HasPrefix(text, "'") {
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return "", fmt.Errorf("unterminated string %s", text)
+		}
+		// YAML single quotes escape a quote by doubling it
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	value, err := strconv.Unquote(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", text)
+	}
+	return value, nil
+}
+
+// splitList splits the inside of a flow list at commas outside quotes.
+func splitList(text string) ([]string, error) {
+	var items []string
+	for text = strings.TrimSpace(text); text != ""; {
+		var item string
+		if text[0] == '"' || text[0] == '\'' {
+			end := closingQuote(text)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in list: %s", text)
+			}
+			value, err := unquote(text[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			item, text = value, strings.TrimSpace(text[end+1:])
+			if text != "" && text[0] != ',' {
+				return nil, fmt.Errorf("expected a comma after %q", item)
+			}
+		} else {
+			end := strings.IndexByte(text, ',')
+			if end < 0 {
+				end = len(text)
+			}
+			item, text = strings.TrimSpace(text[:end]), text[end:]
+		}
+		items = append(items, item)
+		text = strings.TrimSpace(strings.TrimPrefix(text, ","))
+	}
+	return items, nil
+}
+
+// closingQuote returns the index of the quote ending the string text
+// starts with, or -1.
+func closingQuote(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidatePropertyKey accepts lowercase letters, digits, '_', '-' and '.'.
+func ValidatePropertyKey(key string) error {
+	if key == "" {
+		return errors.New("empty property key")
+	}
+	for _, r := range key {
+		if !('a' <= r && r <= 'z') && !('0' <= r && r <= '9') && !strings.ContainsRune("_-.", r) {
+			return fmt.Errorf("invalid property key %q: use lowercase letters, digits, '_', '-' and '.'", key)
+		}
+	}
+	return nil
+}
+
+// SetProperty parses value and stores it under key.
+func (n *Note) SetProperty(key string, value string) error {
+	if err := ValidatePropertyKey(key); err != nil {
+		return err
+	}
+	property, err := ParseValue(value)
+	if err != nil {
+		return fmt.Errorf("property %s: %w", key, err)
+	}
+	if n.Properties == nil {
+		n.Properties = make(map[string]Property)
+	}
+	n.Properties[key] = property
+	return nil
+}
+
+// RemoveProperty deletes the property stored under key.
+func (n *Note) RemoveProperty(key string) error {
+	if _, ok := n.Properties[key]; !ok {
+		return fmt.Errorf("property not found: %s", key)
+	}
+	delete(n.Properties, key)
+	if len(n.Properties) == 0 {
+		n.Properties = nil
+	}
+	return nil
+}
+
+// PropertyKeys returns the keys of properties in sorted order.
+func PropertyKeys(properties map[string]Property) []string {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// propertyTerms returns the index terms of properties: every key on its
+// own and key=value for each value, list items separately, lowercased.
+func propertyTerms(properties map[string]Property) []string {
+	var terms []string
+	for key, property := range properties {
+		terms = append(terms, key)
+		values := []string{property.termValue()}
+		if property.Type == PropertyList {
+			values = make([]string, len(property.Items))
+			for i, item := range property.Items {
+				values[i] = itemProperty(item).termValue()
+			}
+		}
+		for _, value := range values {
+			terms = append(terms, key+"="+strings.ToLower(value))
+		}
+	}
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
+
+// PropertyTerm returns the index term for a `search prop` query, either
+// key or key=value.
+func PropertyTerm(query string) (string, error) {
+	key, value, hasValue := strings.Cut(query, "=")
+	key = strings.ToLower(strings.TrimSpace(key))
+	if err := ValidatePropertyKey(key); err != nil {
+		return "", err
+	}
+	if !hasValue {
+		return key, nil
+	}
+	property, err := ParseValue(value)
+	if err != nil {
+		return "", err
+	}
+	if property.Type == PropertyList {
+		return "", errors.New("search one list item at a time")
+	}
+	return key + "=" + strings.ToLower(property.termValue()), nil
+}
+
+// itemProperty reads a list item as the scalar a query for it parses to,
+// so that list items are indexed like scalar values.
+func itemProperty(item string) Property {
+	property, err := ParseValue(item)
+	if err != nil || property.Type == PropertyList {
+		return Property{Type: PropertyString, Value: item}
+	}
+	return property
+}
+
+// termValue is the value as indexed: numbers in canonical form, so that
+// 2.50 finds 2.5.
+func (p Property) termValue() string {
+	if number, ok := p.Number(); ok {
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	return p.Value
+}
@@ -0,0 +1,225 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// MinPrefix is the shortest id prefix Resolve accepts, so that a typo
+// cannot silently pick some unrelated note.
+const MinPrefix = 4
+
+// AmbiguousError is returned when a reference matches several notes.
+type AmbiguousError struct {
+	Ref        string
+	Kind       string
+	Candidates []NoteSummary
+}
+
+func (e *AmbiguousError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ambiguous %s %q matches %d notes:", e.Kind, e.Ref, len(e.Candidates))
+	for _, candidate := range e.Candidates {
+		fmt.Fprintf(&b, "\n  %s  %s", candidate.Id, candidate.Title)
+	}
+	return b.String()
+}
+
+// Resolve returns the id of the note ref names: a full id, an alias or,
+// like git, a unique id prefix of at least MinPrefix characters. An alias
+// that is also a prefix of other notes' ids is ambiguous.
+func (store *Store) Resolve(ref string, username string, kp *crypt.KeyProvider) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	ids, err := store.noteIds(username)
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(ids, ref) {
+		return ref, nil
+	}
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return "", err
+	}
+	aliases, err := store.readAliases(username, kp, manifest)
+	if err != nil {
+		return "", err
+	}
+	matches := prefixMatches(ids, ref)
+	if id, ok := aliases[ref]; ok && slices.Contains(ids, id) {
+		// An alias that is also a prefix of other ids could mean either
+		if !slices.Contains(matches, id) {
+			matches = append(matches, id)
+		}
+		return pick(matches, ref, "alias", manifest.summary)
+	}
+	if len(ref) < MinPrefix {
+		return "", fmt.Errorf("note %q: %w", ref, fs.ErrNotExist)
+	}
+	return pick(matches, ref, "prefix", manifest.summary)
+}
+
+// ResolveTitle returns the id of the note titled title. Exact matches,
+// ignoring case, win over notes whose title merely contains title, which
+// win over titles holding its characters in order.
+func (store *Store) ResolveTitle(title string, username string, kp *crypt.KeyProvider) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return "", err
+	}
+	titles := make(map[string]string, len(manifest.Notes))
+	for id, entry := range manifest.Notes {
+		titles[id] = entry.Title
+	}
+	return titleMatch(titles, title, manifest.summary)
+}
+
+// ResolveTrashed returns the id of the trashed note ref names: a full id
+// or a unique id prefix of at least MinPrefix characters.
+func (store *Store) ResolveTrashed(ref string, username string, kp *crypt.KeyProvider) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	trashed, err := store.trashSummaries(username, kp)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := trashed[ref]; ok {
+		return ref, nil
+	}
+	if len(ref) < MinPrefix {
+		return "", fmt.Errorf("no trashed note %q: %w", ref, fs.ErrNotExist)
+	}
+	ids := slices.Collect(maps.Keys(trashed))
+	return pick(prefixMatches(ids, ref), ref, "prefix", func(id string) NoteSummary { return trashed[id] })
+}
+
+// ResolveTrashedTitle returns the id of the trashed note titled title,
+// matching as ResolveTitle does.
+func (store *Store) ResolveTrashedTitle(title string, username string, kp *crypt.KeyProvider) (string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	trashed, err := store.trashSummaries(username, kp)
+	if err != nil {
+		return "", err
+	}
+	titles := make(map[string]string, len(trashed))
+	for id, summary := range trashed {
+		titles[id] = summary.Title
+	}
+	return titleMatch(titles, title, func(id string) NoteSummary { return trashed[id] })
+}
+
+// trashSummaries returns the id and title of every trashed note, by id.
+func (store *Store) trashSummaries(username string, kp *crypt.KeyProvider) (map[string]NoteSummary, error) {
+	entries, err := store.trashEntries(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	trashed := make(map[string]NoteSummary, len(entries))
+	for _, entry := range entries {
+		trashed[entry.Note.Id] = NoteSummary{Id: entry.Note.Id, Title: entry.Note.Title}
+	}
+	return trashed, nil
+}
+
+// prefixMatches returns the ids starting with ref, none when ref is shorter
+// than MinPrefix.
+func prefixMatches(ids []string, ref string) []string {
+	var matches []string
+	if len(ref) >= MinPrefix {
+		for _, id := range ids {
+			if strings.HasPrefix(id, ref) {
+				matches = append(matches, id)
+			}
+		}
+	}
+	return matches
+}
+
+// titleMatch picks the note of titles, by id, best matching title.
+func titleMatch(titles map[string]string, title string, summary func(id string) NoteSummary) (string, error) {
+	want := strings.ToLower(strings.TrimSpace(title))
+	if want == "" {
+		return "", errors.New("empty title")
+	}
+	matchers := []func(string) bool{
+		func(t string) bool { return t == want },
+		func(t string) bool { return strings.Contains(t, want) },
+		func(t string) bool { return subsequence(t, want) },
+	}
+	for _, matches := range matchers {
+		var ids []string
+		for id, t := range titles {
+			if matches(strings.ToLower(t)) {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > 0 {
+			return pick(ids, title, "title", summary)
+		}
+	}
+	return "", fmt.Errorf("no note titled %q: %w", title, fs.ErrNotExist)
+}
+
+// pick returns the single match, or an error describing why there is not
+// exactly one. summary describes the candidates of an ambiguous match.
+func pick(matches []string, ref string, kind string, summary func(id string) NoteSummary) (string, error) {
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("note %q: %w", ref, fs.ErrNotExist)
+	case 1:
+		return matches[0], nil
+	}
+
+	slices.Sort(matches)
+	ambiguous := &AmbiguousError{Ref: ref, Kind: kind}
+	for _, id := range matches {
+		ambiguous.Candidates = append(ambiguous.Candidates, summary(id))
+	}
+	return "", ambiguous
+}
+
+// summary returns the manifest entry of a note, or just its id.
+func (manifest *Manifest) summary(id string) NoteSummary {
+	if entry, ok := manifest.Notes[id]; ok {
+		return entry.NoteSummary
+	}
+	return NoteSummary{Id: id}
+}
+
+// subsequence reports whether the characters of sub appear in s in order.
+func subsequence(s string, sub string) bool {
+	for _, r := range sub {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
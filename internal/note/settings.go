@@ -0,0 +1,164 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const settingsBlob = ".settings.pkm"
+
+// Values of the compression setting.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// DefaultSettings are used for users who never changed a setting.
+func DefaultSettings() *Settings {
+	return &Settings{
+		HistoryKeep: 20,
+	}
+}
+
+// SettingKeys lists the keys accepted by Settings.Get and Settings.Set.
+func SettingKeys() []string {
+	return []string{"history.keep", "history.max-age", "compression", "journal.template"}
+}
+
+// Compressed reports whether new files should be gzipped before encryption.
+func (settings *Settings) Compressed() bool {
+	return settings.Compression == CompressionGzip
+}
+
+func (settings *Settings) Get(key string) (string, error) {
+	switch key {
+	case "history.keep":
+		return strconv.Itoa(settings.HistoryKeep), nil
+	case "history.max-age":
+		return FormatAge(settings.HistoryMaxAge), nil
+	case "compression":
+		if settings.Compression == "" {
+			return CompressionNone, nil
+		}
+		return settings.Compression, nil
+	case "journal.template":
+		return settings.JournalTemplate, nil
+	default:
+		return "", fmt.Errorf("unknown setting: %s", key)
+	}
+}
+
+func (settings *Settings) Set(key string, value string) error {
+	switch key {
+	case "history.keep":
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 0 {
+			return fmt.Errorf("history.keep must be a non-negative number, got %q", value)
+		}
+		settings.HistoryKeep = keep
+	case "history.max-age":
+		age, err := ParseAge(value)
+		if err != nil {
+			return err
+		}
+		settings.HistoryMaxAge = age
+	case "compression":
+		if value != CompressionNone && value != CompressionGzip {
+			return fmt.Errorf("compression must be %s or %s, got %q", CompressionNone, CompressionGzip, value)
+		}
+		settings.Compression = value
+	case "journal.template":
+		// Empty goes back to the built-in skeleton
+		if value != "" {
+			if err := ValidateTemplateName(value); err != nil {
+				return err
+			}
+		}
+		settings.JournalTemplate = value
+	default:
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	return nil
+}
+
+// ParseAge parses a duration that may also be given in days ("30d") or
+// weeks ("2w"). "0" means no limit.
+func ParseAge(value string) (time.Duration, error) {
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		// Only the last letter is the unit: "5dw" is no age
+		count, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(count) * unit, nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q", value)
+	}
+	return age, nil
+}
+
+// FormatAge is the inverse of ParseAge, preferring whole days.
+func FormatAge(age time.Duration) string {
+	if age == 0 {
+		return "0"
+	}
+	if age%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", age/(24*time.Hour))
+	}
+	return age.String()
+}
+
+func (store *Store) LoadSettings(username string, kp *crypt.KeyProvider) (*Settings, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return store.readSettings(username, kp)
+}
+
+func (store *Store) SaveSettings(settings *Settings, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Settings are tiny and read before anything else, keep them plain
+	payload, err := sealJSON(kp, settings, false)
+	if err != nil {
+		return err
+	}
+	return store.blobs.Put(username, settingsBlob, payload)
+}
+
+func (store *Store) readSettings(username string, kp *crypt.KeyProvider) (*Settings, error) {
+	settings := DefaultSettings()
+	fileData, err := store.blobs.Get(username, settingsBlob)
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := openJSON(fileData, kp, settings, "settings"); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
@@ -1,12 +1,10 @@
 package note
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"io"
-	"os"
-	"path/filepath"
+	"fmt"
+	"io/fs"
 	"slices"
 	"sort"
 	"strings"
@@ -15,180 +13,200 @@ import (
 )
 
 func InitStore(storeDirectory string) *Store {
+	store := NewStore(NewFileBackend(storeDirectory))
+	store.StoreLocation = storeDirectory
+	return store
+}
+
+// NewStore keeps notes in backend.
+func NewStore(backend Backend) *Store {
 	return &Store{
-		StoreLocation: storeDirectory,
+		blobs:       backend,
+		locks:       &lockTable{},
+		lockTimeout: DefaultLockTimeout,
 	}
 }
 
-func (fileStore *Store) Save(note *Note, username string, kp *crypt.KeyProvider) error {
-	userdir := filepath.Join(fileStore.StoreLocation, username)
-	if err := os.MkdirAll(userdir, 0755); err != nil {
+func (store *Store) Save(note *Note, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	jsonBody, err := json.Marshal(note)
+	// Note, index, manifest and history land together or not at all
+	tx, err := store.begin(username, kp)
 	if err != nil {
 		return err
 	}
-
-	encryptedBody, err := kp.Encrypt(jsonBody)
-	if err != nil {
+	if err := tx.save(note); err != nil {
 		return err
 	}
-	payload := []byte("PKM\n")
-	payload = append(payload, encryptedBody...)
+	return tx.commit()
+}
 
-	noteFilePath := filepath.Join(userdir, note.Id+".pkm")
-	noteFileFS, err := os.OpenFile(noteFilePath, os.O_CREATE|os.O_WRONLY, 0644)
+func (store *Store) Load(noteLocation string, username string, kp *crypt.KeyProvider) (*Note, error) {
+	store, unlock, err := store.Locked(username, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer noteFileFS.Close()
+	defer unlock()
 
-	if err := noteFileFS.Truncate(0); err != nil {
-		return err
-	}
-	if _, err := noteFileFS.Seek(0, 0); err != nil {
-		return err
+	fileData, err := store.blobs.Get(username, noteLocation+".pkm")
+	if err != nil {
+		return nil, err
 	}
-	if _, err := noteFileFS.Write(payload); err != nil {
-		return err
+	return openNote(fileData, kp)
+}
+
+func openNote(fileData []byte, kp *crypt.KeyProvider) (*Note, error) {
+	note := Note{}
+	if err := openJSON(fileData, kp, &note, "note"); err != nil {
+		return nil, err
 	}
+	return &note, nil
+}
 
-	indexFilePath := filepath.Join(userdir, ".index.pkm")
-	indexFileFS, err := os.OpenFile(indexFilePath, os.O_CREATE|os.O_RDWR, 0644)
+// Delete removes the note and its history, the links other notes have to
+// it and drops it from the index and manifest in one write, then deletes
+// attachments no other note uses.
+func (store *Store) Delete(noteLocation string, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
 	if err != nil {
 		return err
 	}
-	defer indexFileFS.Close()
-	indexFile, err := io.ReadAll(indexFileFS)
-	if err != nil {
+	defer unlock()
+
+	if _, err := store.blobs.Get(username, noteLocation+".pkm"); err != nil {
 		return err
 	}
-	var index Index
-	index.KeywordIndex = make(map[string][]string)
-	index.TagIndex = make(map[string][]string)
-	if len(indexFile) > 4 && bytes.Equal(indexFile[:4], []byte("PKM\n")) {
-		encryptedIndex := indexFile[4:]
-		decryptedIndex, err := kp.Decrypt(encryptedIndex)
-		if err == nil {
-			if err := json.Unmarshal(decryptedIndex, &index); err != nil {
-				return err
-			}
-		}
-	}
-
-	allString := normalize(note.Title + " " + note.Content)
-	words := filterStopwords(allString)
 
-	for _, word := range words {
-		if !slices.Contains(index.KeywordIndex[word], note.Id) {
-			index.KeywordIndex[word] = append(index.KeywordIndex[word], note.Id)
-		}
-	}
-	for _, tag := range note.Tags {
-		if !slices.Contains(index.TagIndex[tag], note.Id) {
-			index.TagIndex[tag] = append(index.TagIndex[tag], note.Id)
-		}
-	}
-	indexJson, err := json.Marshal(index)
+	notes, err := store.loadAll(username, kp)
 	if err != nil {
 		return err
 	}
-
-	encryptedIndex, err := kp.Encrypt(indexJson)
+	tx, err := store.begin(username, kp)
 	if err != nil {
 		return err
 	}
-	indexPayload := []byte("PKM\n")
-	indexPayload = append(indexPayload, encryptedIndex...)
-
-	if err := indexFileFS.Truncate(0); err != nil {
+	if _, err := tx.unlinkInbound(notes, noteLocation); err != nil {
 		return err
 	}
-	if _, err := indexFileFS.Seek(0, 0); err != nil {
+	if err := tx.drop(noteLocation); err != nil {
 		return err
 	}
-	if _, err := indexFileFS.Write(indexPayload); err != nil {
+	if err := store.stageHistoryRemoval(&tx.b, username, noteLocation); err != nil {
 		return err
 	}
-
-	return nil
+	tx.collect = true
+	return tx.commit()
 }
 
-func (fileStore *Store) Load(noteLocation string, username string, kp *crypt.KeyProvider) (*Note, error) {
-	fileDataPath := filepath.Join(fileStore.StoreLocation, username, noteLocation+".pkm")
+// Search returns the notes matching every term. searchType is "keyword",
+// "prop" for key=value terms, "tag", which also matches the tags below
+// each term, or "tag-exact".
+func (store *Store) Search(searchType string, terms []string, username string, kp *crypt.KeyProvider) ([]string, error) {
+	return rebuildingOutdated(store, username, kp, func() ([]string, error) {
+		return store.search(searchType, terms, username, kp)
+	})
+}
 
-	fileDataFS, err := os.OpenFile(fileDataPath, os.O_RDONLY, 0644)
+func (store *Store) search(searchType string, terms []string, username string, kp *crypt.KeyProvider) ([]string, error) {
+	store, unlock, err := store.Locked(username, false)
 	if err != nil {
 		return nil, err
 	}
-	defer fileDataFS.Close()
-	fileData, err := io.ReadAll(fileDataFS)
+	defer unlock()
+
+	index, err := store.readIndex(username, kp)
 	if err != nil {
 		return nil, err
 	}
-	if len(fileData) < 4 || !bytes.Equal(fileData[:4], []byte("PKM\n")) {
-		return nil, errors.New("note corrupted")
+	if index.missing {
+		if err := store.checkIndexMissing(index, username); err != nil {
+			return nil, err
+		}
 	}
 
-	encrpyted := fileData[4:]
-	jsonData, err := kp.Decrypt(encrpyted)
-	if err != nil {
-		return nil, err
+	kind := keywordPostings
+	lookup := index.lookup
+	switch searchType {
+	case "tag":
+		kind = tagPostings
+		lookup = index.lookupTree
+	case "tag-exact":
+		kind = tagPostings
+	case "prop":
+		kind = propertyPostings
+		terms = slices.Clone(terms)
+		for i, query := range terms {
+			if terms[i], err = PropertyTerm(query); err != nil {
+				return nil, err
+			}
+		}
 	}
-	note := Note{}
-	if err := json.Unmarshal(jsonData, &note); err != nil {
-		return nil, err
+	synonyms := make(map[string]string)
+	if kind == tagPostings {
+		if synonyms, err = store.readTagAliases(username, kp); err != nil {
+			return nil, err
+		}
+	}
+	var candidates []string
+	for i, term := range terms {
+		// A tag matches its canonical tag and every alias of it
+		var noteIds []string
+		for _, tag := range expandTag(term, synonyms) {
+			tagged, err := lookup(kind, tag)
+			if err != nil {
+				return nil, err
+			}
+			noteIds = append(noteIds, tagged...)
+		}
+		if i == 0 {
+			candidates = noteIds
+			continue
+		}
+		candidates = intersect(candidates, noteIds)
 	}
-	return &note, nil
-}
-
-func (fileStore *Store) Delete(noteLocation string, username string) error {
-	fileLoc := filepath.Join(fileStore.StoreLocation, username, noteLocation+".pkm")
-	return os.Remove(fileLoc)
-}
 
-func (fileStore *Store) Search(searchType string, terms []string, username string, kp *crypt.KeyProvider) ([]string, error) {
-	indexPath := filepath.Join(fileStore.StoreLocation, username, ".index.pkm")
-	fileDataFS, err := os.OpenFile(indexPath, os.O_RDONLY, 0644)
+	// Never report notes removed behind the index's back
+	existing, err := store.noteIds(username)
 	if err != nil {
 		return nil, err
 	}
-	defer fileDataFS.Close()
-	fileData, err := io.ReadAll(fileDataFS)
-	if err != nil {
-		return nil, err
+	return intersect(candidates, existing), nil
+}
+
+// checkIndexMissing explains why a user with notes has no readable index.
+func (store *Store) checkIndexMissing(index *searchIndex, username string) error {
+	if _, err := store.blobs.Get(username, legacyIndexBlob); err == nil {
+		return errors.New("index uses an older format, run `pkm migrate`")
 	}
-	if len(fileData) < 4 || !bytes.Equal(fileData[:4], []byte("PKM\n")) {
-		return nil, errors.New("index corrupted")
+	if index.outdated {
+		return fmt.Errorf("%w, run `pkm index rebuild`", errIndexVersion)
 	}
-
-	encrpyted := fileData[4:]
-	jsonData, err := kp.Decrypt(encrpyted)
+	ids, err := store.noteIds(username)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	var index Index
-	if err := json.Unmarshal(jsonData, &index); err != nil {
-		return nil, err
+	if len(ids) > 0 {
+		return errors.New("index missing or unreadable, run `pkm index rebuild`")
 	}
+	return nil
+}
 
-	var candidates []string
-	switch searchType {
-	case "tag":
-		candidates = index.TagIndex[terms[0]]
-		for _, term := range terms {
-			candidates = intersect(candidates, index.TagIndex[term])
-		}
-	case "keyword":
-		candidates = index.KeywordIndex[terms[0]]
-		for _, term := range terms {
-			candidates = intersect(candidates, index.KeywordIndex[term])
-		}
+// rebuildingOutdated runs read and, when it finds an index laid out by an
+// older version of pkm, rebuilds the index and runs it again. Views
+// holding a shared lock cannot rebuild and get the error.
+func rebuildingOutdated[T any](store *Store, username string, kp *crypt.KeyProvider, read func() (T, error)) (T, error) {
+	result, err := read()
+	if !errors.Is(err, errIndexVersion) || (store.held != nil && !store.held.exclusive) {
+		return result, err
 	}
-
-	return candidates, nil
+	if _, err := store.RebuildIndex(username, kp); err != nil {
+		return result, err
+	}
+	return read()
 }
 
 func intersect[T comparable](a []T, b []T) []T {
@@ -209,70 +227,97 @@ func intersect[T comparable](a []T, b []T) []T {
 	return result
 }
 
-func (fileStore *Store) List(username string, kp *crypt.KeyProvider) ([]NoteSummary, error) {
-
-	fileDirPath := filepath.Join(fileStore.StoreLocation, username)
-	fileDirFS, err := os.ReadDir(fileDirPath)
+// noteIds returns the ids of every note blob in the user's space. Dot files
+// such as the index and settings are not notes. A user who never saved a
+// note has none.
+func (store *Store) noteIds(username string) ([]string, error) {
+	names, err := store.blobs.List(username, "")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-
-	var noteSummaryList []NoteSummary
-
-	for _, file := range fileDirFS {
-
-		if file.IsDir() {
+	var ids []string
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".pkm") || strings.HasPrefix(name, ".") {
 			continue
 		}
-		name := file.Name()
+		ids = append(ids, strings.TrimSuffix(name, ".pkm"))
+	}
+	return ids, nil
+}
 
-		if !strings.HasSuffix(name, ".pkm") || name == ".index.pkm" {
-			continue
-		}
+// loadAll decrypts every note of the user, see LoadAll.
+func (store *Store) loadAll(username string, kp *crypt.KeyProvider) ([]*Note, error) {
+	return store.readNotes(context.Background(), username, kp)
+}
 
-		fileDataPath := filepath.Join(fileDirPath, name)
-		fileDataFS, err := os.OpenFile(fileDataPath, os.O_RDONLY, 0644)
-		if err != nil {
-			return nil, err
-		}
+// List returns the summary of every note from the manifest, without
+// decrypting the notes themselves.
+func (store *Store) List(username string, kp *crypt.KeyProvider) ([]NoteSummary, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-		fileData, err := io.ReadAll(fileDataFS)
-		if err != nil {
-			return nil, err
-		}
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := fileDataFS.Close(); err != nil {
-			return nil, err
-		}
+	var noteSummaryList []NoteSummary
+	for _, entry := range manifest.Notes {
+		noteSummaryList = append(noteSummaryList, entry.NoteSummary)
+	}
 
-		if len(fileData) < 4 || !bytes.Equal(fileData[:4], []byte("PKM\n")) {
-			// Skip corrupted notes
-			continue
+	sort.Slice(noteSummaryList, func(i, j int) bool {
+		if noteSummaryList[i].Title != noteSummaryList[j].Title {
+			return noteSummaryList[i].Title < noteSummaryList[j].Title
 		}
+		return noteSummaryList[i].Id < noteSummaryList[j].Id
+	})
 
-		encrpyted := fileData[4:]
-		jsonData, err := kp.Decrypt(encrpyted)
-		if err != nil {
-			// Skip notes that fail to decrypt
-			// continue
-			return nil, err
-		}
+	return noteSummaryList, nil
+}
 
-		var note Note
-		if err := json.Unmarshal(jsonData, &note); err != nil {
-			return nil, err
-		}
+// GetBlob returns the raw bytes stored under name in the user's space.
+func (store *Store) GetBlob(username string, name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid blob name %q", name)
+	}
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return store.blobs.Get(username, name)
+}
 
-		noteSummaryList = append(noteSummaryList, NoteSummary{
-			Id:    note.Id,
-			Title: note.Title,
-			Tags:  note.Tags,
-		})
+// PutBlob stores data as is under name in the user's space.
+func (store *Store) PutBlob(username string, name string, data []byte) error {
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("invalid blob name %q", name)
 	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	sort.Slice(noteSummaryList, func(i, j int) bool {
-		return noteSummaryList[i].Title < noteSummaryList[j].Title
-	})
+	return store.blobs.Put(username, name, data)
+}
 
-	return noteSummaryList, nil
+// Recover completes or rolls back writes interrupted by a crash and removes
+// leftover temp files from the user's space.
+func (store *Store) Recover(username string) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return store.blobs.Recover(username)
 }
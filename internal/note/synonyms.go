@@ -0,0 +1,334 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// Tag aliases map synonyms such as ml to the canonical tag, here
+// machine-learning. Saves store the canonical tag and tag searches match
+// its aliases too. An alias covers the tags below it: ml/deep is
+// machine-learning/deep.
+const tagAliasesBlob = ".tag-aliases.pkm"
+
+// readTagAliases returns the user's alias -> canonical tag table, empty
+// when none was ever written.
+func (store *Store) readTagAliases(username string, kp *crypt.KeyProvider) (map[string]string, error) {
+	synonyms := make(map[string]string)
+	fileData, err := store.blobs.Get(username, tagAliasesBlob)
+	if errors.Is(err, fs.ErrNotExist) {
+		return synonyms, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := openJSON(fileData, kp, &synonyms, "tag aliases"); err != nil {
+		return nil, err
+	}
+	if synonyms == nil {
+		synonyms = make(map[string]string)
+	}
+	return synonyms, nil
+}
+
+// stageTagAliases adds the encrypted alias table to b.
+func stageTagAliases(b *Batch, synonyms map[string]string, kp *crypt.KeyProvider, compress bool) error {
+	payload, err := sealJSON(kp, synonyms, compress)
+	if err != nil {
+		return err
+	}
+	b.Put(tagAliasesBlob, payload)
+	return nil
+}
+
+// TagAliases returns the alias -> canonical tag table.
+func (store *Store) TagAliases(username string, kp *crypt.KeyProvider) (map[string]string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return store.readTagAliases(username, kp)
+}
+
+// AddTagAlias makes alias a synonym of canonical and retags the notes
+// tagged alias, or below it, in one write. It returns how many notes
+// changed. When canonical is an alias itself its own canonical tag is
+// used, and aliases of alias follow it to canonical.
+func (store *Store) AddTagAlias(alias string, canonical string, username string, kp *crypt.KeyProvider) (int, error) {
+	alias, err := NormalizeTag(alias)
+	if err != nil {
+		return 0, err
+	}
+	if canonical, err = NormalizeTag(canonical); err != nil {
+		return 0, err
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	canonical = canonicalTag(canonical, tx.synonyms)
+	if TagUnder(canonical, alias) {
+		return 0, fmt.Errorf("tag %q cannot be an alias of %q, which is itself or below it", alias, canonical)
+	}
+	for other, target := range tx.synonyms {
+		if TagUnder(target, alias) {
+			tx.synonyms[other] = canonical + target[len(alias):]
+		}
+	}
+	tx.synonyms[alias] = canonical
+	tx.synonymsChanged = true
+
+	// Saving canonicalizes the tags
+	tagged, err := tx.index.lookupTree(tagPostings, alias)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range tagged {
+		note, err := store.Load(id, username, kp)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.save(note); err != nil {
+			return 0, err
+		}
+	}
+	return len(tagged), tx.commit()
+}
+
+// RemoveTagAlias forgets alias. Notes keep the canonical tag.
+func (store *Store) RemoveTagAlias(alias string, username string, kp *crypt.KeyProvider) error {
+	alias, err := NormalizeTag(alias)
+	if err != nil {
+		return err
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	synonyms, err := store.readTagAliases(username, kp)
+	if err != nil {
+		return err
+	}
+	if _, ok := synonyms[alias]; !ok {
+		return fmt.Errorf("tag %q is not an alias", alias)
+	}
+	delete(synonyms, alias)
+	settings, err := store.readSettings(username, kp)
+	if err != nil {
+		return err
+	}
+	var b Batch
+	if err := stageTagAliases(&b, synonyms, kp, settings.Compressed()); err != nil {
+		return err
+	}
+	return store.blobs.Commit(username, &b)
+}
+
+// canonicalTag returns the canonical form of tag under the most specific
+// alias covering it, or tag itself.
+func canonicalTag(tag string, synonyms map[string]string) string {
+	best := ""
+	for alias := range synonyms {
+		if TagUnder(tag, alias) && len(alias) > len(best) {
+			best = alias
+		}
+	}
+	if best == "" {
+		return tag
+	}
+	return synonyms[best] + tag[len(best):]
+}
+
+// expandTag returns the canonical form of tag followed by every alias of
+// it, the tags a search for tag should match.
+func expandTag(tag string, synonyms map[string]string) []string {
+	canonical := canonicalTag(tag, synonyms)
+	tags := []string{canonical}
+	for _, alias := range slices.Sorted(maps.Keys(synonyms)) {
+		if target := synonyms[alias]; TagUnder(canonical, target) {
+			tags = append(tags, alias+canonical[len(target):])
+		}
+	}
+	return tags
+}
+
+// CanonicalTagList normalizes the comma-separated tags of tagList and
+// replaces aliases with their canonical tag, as notes are saved with them.
+func CanonicalTagList(tagList string, synonyms map[string]string) (string, error) {
+	tags := strings.Split(tagList, ",")
+	for i, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return "", err
+		}
+		tags[i] = canonicalTag(tag, synonyms)
+	}
+	return strings.Join(tags, ","), nil
+}
+
+// CanonicalizeTags replaces tags that are aliases with their canonical
+// tag, dropping the duplicates that leaves.
+func (n *Note) CanonicalizeTags(synonyms map[string]string) {
+	if len(synonyms) == 0 {
+		return
+	}
+	tags := make([]string, 0, len(n.Tags))
+	for _, tag := range n.Tags {
+		if tag = canonicalTag(tag, synonyms); !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	n.Tags = tags
+}
+
+// TagSuggestion is a cluster of tags that likely mean the same.
+type TagSuggestion struct {
+	// Canonical is the most used tag of the cluster
+	Canonical string
+	Aliases   []string
+}
+
+// SuggestTagAliases finds clusters of likely synonyms among tags, which
+// maps each tag to its notes. Two tags are candidates when no note carries
+// both and they are spelled alike once hyphens and underscores are
+// dropped, or one abbreviates the other and both appear next to a common
+// tag.
+func SuggestTagAliases(tags map[string][]string) []TagSuggestion {
+	names := slices.Sorted(maps.Keys(tags))
+	noteTags := make(map[string][]string)
+	for _, tag := range names {
+		for _, id := range tags[tag] {
+			noteTags[id] = append(noteTags[id], tag)
+		}
+	}
+	// neighbours holds the tags appearing on the same notes as each tag
+	neighbours := make(map[string]map[string]bool)
+	for _, tag := range names {
+		neighbours[tag] = make(map[string]bool)
+		for _, id := range tags[tag] {
+			for _, other := range noteTags[id] {
+				neighbours[tag][other] = true
+			}
+		}
+	}
+
+	parent := make(map[string]string)
+	paired := make(map[string]bool)
+	var find func(tag string) string
+	find = func(tag string) string {
+		if p, ok := parent[tag]; ok && p != tag {
+			parent[tag] = find(p)
+			return parent[tag]
+		}
+		return tag
+	}
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			if neighbours[a][b] || !likelySynonyms(a, b, neighbours) {
+				continue
+			}
+			parent[find(a)] = find(b)
+			paired[a], paired[b] = true, true
+		}
+	}
+
+	clusters := make(map[string][]string)
+	for _, tag := range names {
+		if paired[tag] {
+			root := find(tag)
+			clusters[root] = append(clusters[root], tag)
+		}
+	}
+	var suggestions []TagSuggestion
+	for _, members := range clusters {
+		// Most used first, then the longer, more descriptive name
+		slices.SortStableFunc(members, func(a, b string) int {
+			if len(tags[a]) != len(tags[b]) {
+				return len(tags[b]) - len(tags[a])
+			}
+			return len(b) - len(a)
+		})
+		aliases := slices.Clone(members[1:])
+		slices.Sort(aliases)
+		suggestions = append(suggestions, TagSuggestion{Canonical: members[0], Aliases: aliases})
+	}
+	slices.SortFunc(suggestions, func(a, b TagSuggestion) int { return strings.Compare(a.Canonical, b.Canonical) })
+	return suggestions
+}
+
+// likelySynonyms reports whether a and b look like two names of one tag.
+func likelySynonyms(a string, b string, neighbours map[string]map[string]bool) bool {
+	if TagUnder(a, b) || TagUnder(b, a) {
+		return false
+	}
+	ca, cb := compactTag(a), compactTag(b)
+	if editDistance(ca, cb) <= max(len(ca), len(cb))/5 {
+		return true
+	}
+	if !abbreviates(a, b) && !abbreviates(b, a) {
+		return false
+	}
+	for other := range neighbours[a] {
+		if other != a && other != b && neighbours[b][other] {
+			return true
+		}
+	}
+	return false
+}
+
+// compactTag drops the word separators of tag.
+func compactTag(tag string) string {
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(tag)
+}
+
+// abbreviates reports whether short is made of the initials of the words
+// of long, e.g. ml of machine-learning.
+func abbreviates(short string, long string) bool {
+	words := strings.FieldsFunc(long, func(r rune) bool { return r == '-' || r == '_' || r == ' ' })
+	if len(words) < 2 || len(short) != len(words) {
+		return false
+	}
+	for i, word := range words {
+		if word[0] != short[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// editDistance is the Levenshtein distance between a and b in bytes.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
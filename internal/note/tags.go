@@ -0,0 +1,166 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// Tags returns the ids of the notes under each tag in the vault, read from
+// the tag posting lists of the index.
+func (store *Store) Tags(username string, kp *crypt.KeyProvider) (map[string][]string, error) {
+	return rebuildingOutdated(store, username, kp, func() (map[string][]string, error) {
+		return store.tags(username, kp)
+	})
+}
+
+func (store *Store) tags(username string, kp *crypt.KeyProvider) (map[string][]string, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	index, err := store.readIndex(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string][]string)
+	if index.missing {
+		// A vault without notes has no index yet and no tags either
+		return tags, store.checkIndexMissing(index, username)
+	}
+	shards, err := store.blobs.List(username, indexDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	existing, err := store.noteIds(username)
+	if err != nil {
+		return nil, err
+	}
+	for _, shard := range shards {
+		if !strings.HasPrefix(shard, tagPostings+"-") {
+			continue
+		}
+		postings, err := index.postingShard(shard)
+		if err != nil {
+			return nil, err
+		}
+		for tag := range postings {
+			noteIds, err := index.lookup(tagPostings, tag)
+			if err != nil {
+				return nil, err
+			}
+			// Never report notes removed behind the index's back
+			if noteIds = intersect(noteIds, existing); len(noteIds) > 0 {
+				tags[tag] = noteIds
+			}
+		}
+	}
+	return tags, nil
+}
+
+// RenameTags replaces the tags from with to on every note carrying any of
+// them, in one write, and returns how many notes changed. Tags below a
+// renamed one move with it: renaming cs to comp turns cs/graphs into
+// comp/graphs. A note keeps to where its first replaced tag was.
+// Renaming onto a tag in use merges the two. Tag aliases stand for their
+// canonical tag.
+func (store *Store) RenameTags(from []string, to string, username string, kp *crypt.KeyProvider) (int, error) {
+	to, err := NormalizeTag(to)
+	if err != nil {
+		return 0, err
+	}
+	if len(from) == 0 {
+		return 0, errors.New("no tags to rename")
+	}
+	from = slices.Clone(from)
+	for i, tag := range from {
+		if from[i], err = NormalizeTag(tag); err != nil {
+			return 0, err
+		}
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return 0, err
+	}
+	// Notes carry canonical tags only, so aliases name their canonical tag
+	to = canonicalTag(to, tx.synonyms)
+	for i, tag := range from {
+		from[i] = canonicalTag(tag, tx.synonyms)
+	}
+	var noteIds []string
+	for _, tag := range from {
+		tagged, err := tx.index.lookupTree(tagPostings, tag)
+		if err != nil {
+			return 0, err
+		}
+		if len(tagged) == 0 && tag != to {
+			return 0, fmt.Errorf("no note is tagged %q", tag)
+		}
+		for _, id := range tagged {
+			if !slices.Contains(noteIds, id) {
+				noteIds = append(noteIds, id)
+			}
+		}
+	}
+
+	changed := 0
+	for _, id := range noteIds {
+		note, err := store.Load(id, username, kp)
+		if err != nil {
+			return 0, err
+		}
+		tags := renameTags(note.Tags, from, to)
+		if slices.Equal(tags, note.Tags) {
+			continue
+		}
+		note.Tags = tags
+		if err := tx.save(note); err != nil {
+			return 0, err
+		}
+		changed++
+	}
+	// Aliases of a renamed tag follow it
+	for alias, target := range tx.synonyms {
+		for _, parent := range from {
+			if TagUnder(target, parent) {
+				tx.synonyms[alias] = to + target[len(parent):]
+				tx.synonymsChanged = true
+				break
+			}
+		}
+	}
+	if changed == 0 && !tx.synonymsChanged {
+		return 0, nil
+	}
+	return changed, tx.commit()
+}
+
+// renameTags returns tags with every tag of from, and every tag below one,
+// moved to to. Each resulting tag appears once, at its first position.
+func renameTags(tags []string, from []string, to string) []string {
+	renamed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		for _, parent := range from {
+			if TagUnder(tag, parent) {
+				tag = to + tag[len(parent):]
+				break
+			}
+		}
+		if !slices.Contains(renamed, tag) {
+			renamed = append(renamed, tag)
+		}
+	}
+	return renamed
+}
@@ -0,0 +1,294 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const templatesDir = ".templates"
+
+func templateBlob(name string) string {
+	return path.Join(templatesDir, name+".pkm")
+}
+
+// Frontmatter keys of a template that set the note's type, tags and
+// links rather than a property.
+const (
+	templateTypeKey  = "type"
+	templateTagsKey  = "tags"
+	templateLinksKey = "links"
+)
+
+// TemplateVars are the values of a template's built-in placeholders.
+type TemplateVars struct {
+	Title string
+	User  string
+	Now   time.Time
+}
+
+// Expansion is a template filled in for one note. Links holds note
+// references as written in the template: ids, prefixes or aliases.
+type Expansion struct {
+	Type       string
+	Tags       []string
+	Links      []string
+	Properties map[string]Property
+	Content    string
+}
+
+// ValidateTemplateName accepts what Slugify produces, like aliases.
+func ValidateTemplateName(name string) error {
+	if name == "" || len(name) > MaxAliasLength || Slugify(name) != name {
+		return fmt.Errorf("invalid template name %q: use lowercase letters, digits and single hyphens", name)
+	}
+	return nil
+}
+
+// Expand fills in the placeholders of the template and reads its
+// frontmatter. Placeholders are written {{name}}:
+//
+//	{{date}}        today, YYYY-MM-DD
+//	{{time}}        the time, HH:MM
+//	{{datetime}}    both
+//	{{title}}       the note's title
+//	{{user}}        the user creating the note
+//	{{prompt:Who}}  an answer to the question "Who", asked once through ask
+//
+// Besides properties the frontmatter may hold the note's type and its
+// default tags and links:
+//
+//	---
+//	type: literature
+//	tags: [meeting, work]
+//	links: [standups]
+//	attendees: {{prompt:Attendees}}
+//	---
+func (t *Template) Expand(vars TemplateVars, ask func(question string) (string, error)) (*Expansion, error) {
+	expansion, err := expandTemplate(t.Text, vars, ask)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return expansion, nil
+}
+
+// ValidateTemplate checks that text expands: every placeholder is known
+// and the frontmatter parses.
+func ValidateTemplate(text string) error {
+	_, err := expandTemplate(text, TemplateVars{Title: "Title", User: "user", Now: time.Now()}, func(string) (string, error) {
+		return "answer", nil
+	})
+	return err
+}
+
+func expandTemplate(text string, vars TemplateVars, ask func(question string) (string, error)) (*Expansion, error) {
+	answers := make(map[string]string)
+	text, err := expandPlaceholders(text, func(name string) (string, error) {
+		now := vars.Now.Local()
+		switch name {
+		case "date":
+			return now.Format(time.DateOnly), nil
+		case "time":
+			return now.Format("15:04"), nil
+		case "datetime":
+			return now.Format("2006-01-02 15:04"), nil
+		case "title":
+			return vars.Title, nil
+		case "user":
+			return vars.User, nil
+		}
+		question, ok := strings.CutPrefix(name, "prompt:")
+		if !ok || strings.TrimSpace(question) == "" {
+			return "", fmt.Errorf("unknown placeholder {{%s}}", name)
+		}
+		question = strings.TrimSpace(question)
+		if answer, asked := answers[question]; asked {
+			return answer, nil
+		}
+		answer, err := ask(question)
+		if err != nil {
+			return "", err
+		}
+		answers[question] = answer
+		return answer, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	properties, content, err := SplitFrontmatter(text)
+	if err != nil {
+		return nil, err
+	}
+	expansion := &Expansion{Content: content}
+	if property, ok := properties[templateTypeKey]; ok {
+		if err := ValidateType(property.Value); err != nil {
+			return nil, err
+		}
+		expansion.Type = property.Value
+		delete(properties, templateTypeKey)
+	}
+	expansion.Tags = propertyItems(properties[templateTagsKey])
+	expansion.Links = propertyItems(properties[templateLinksKey])
+	delete(properties, templateTagsKey)
+	delete(properties, templateLinksKey)
+	if len(properties) > 0 {
+		expansion.Properties = properties
+	}
+	return expansion, nil
+}
+
+// expandPlaceholders replaces every {{name}} in text with value(name).
+func expandPlaceholders(text string, value func(name string) (string, error)) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			b.WriteString(text)
+			return b.String(), nil
+		}
+		end := strings.Index(text[start:], "}}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed placeholder %q", firstLine(text[start:]))
+		}
+		replacement, err := value(strings.TrimSpace(text[start+2 : start+end]))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text[:start])
+		b.WriteString(replacement)
+		text = text[start+end+2:]
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// propertyItems returns the items of a list, or the comma-separated parts
+// of a scalar.
+func propertyItems(property Property) []string {
+	items := property.Items
+	if property.Type != PropertyList {
+		items = strings.Split(property.Value, ",")
+	}
+	var trimmed []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" && !slices.Contains(trimmed, item) {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return trimmed
+}
+
+// SaveTemplate stores template encrypted under its name, replacing any
+// template of that name.
+func (store *Store) SaveTemplate(template *Template, username string, kp *crypt.KeyProvider) error {
+	if err := ValidateTemplateName(template.Name); err != nil {
+		return err
+	}
+	if err := ValidateTemplate(template.Text); err != nil {
+		return fmt.Errorf("template %s: %w", template.Name, err)
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	settings, err := store.readSettings(username, kp)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if template.CreatedAt.IsZero() {
+		template.CreatedAt = now
+	}
+	template.UpdatedAt = now
+	payload, err := sealJSON(kp, template, settings.Compressed())
+	if err != nil {
+		return err
+	}
+	return store.blobs.Put(username, templateBlob(template.Name), payload)
+}
+
+// LoadTemplate returns the template called name.
+func (store *Store) LoadTemplate(name string, username string, kp *crypt.KeyProvider) (*Template, error) {
+	if err := ValidateTemplateName(name); err != nil {
+		return nil, err
+	}
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	fileData, err := store.blobs.Get(username, templateBlob(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var template Template
+	if err := openJSON(fileData, kp, &template, "template"); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListTemplates returns every template ordered by name.
+func (store *Store) ListTemplates(username string, kp *crypt.KeyProvider) ([]Template, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names, err := store.blobs.List(username, templatesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var templates []Template
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".pkm") {
+			continue
+		}
+		template, err := store.LoadTemplate(strings.TrimSuffix(name, ".pkm"), username, kp)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	slices.SortFunc(templates, func(a, b Template) int { return strings.Compare(a.Name, b.Name) })
+	return templates, nil
+}
+
+// DeleteTemplate removes the template called name.
+func (store *Store) DeleteTemplate(name string, username string) error {
+	if err := ValidateTemplateName(name); err != nil {
+		return err
+	}
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := store.blobs.Get(username, templateBlob(name)); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	} else if err != nil {
+		return err
+	}
+	return store.blobs.Remove(username, templateBlob(name))
+}
@@ -0,0 +1,278 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+const trashDir = ".trash"
+
+func trashName(noteId string) string {
+	return path.Join(trashDir, noteId+".pkm")
+}
+
+// inboundLinks returns the ids of notes that link to noteId.
+func inboundLinks(notes []*Note, noteId string) []string {
+	var inbound []string
+	for _, other := range notes {
+		if other.Id != noteId && other.HasLink(noteId) {
+			inbound = append(inbound, other.Id)
+		}
+	}
+	return inbound
+}
+
+// unlinkInbound takes the links to noteId off the other notes and saves
+// them in tx. It returns the removed links by the id of the note they were
+// on.
+func (tx *txn) unlinkInbound(notes []*Note, noteId string) (map[string]Link, error) {
+	removed := make(map[string]Link)
+	for _, other := range notes {
+		link, ok := other.LinkTo(noteId)
+		if other.Id == noteId || !ok {
+			continue
+		}
+		if err := other.RemoveLink(noteId); err != nil {
+			return nil, err
+		}
+		if err := tx.save(other); err != nil {
+			return nil, err
+		}
+		removed[other.Id] = link
+	}
+	return removed, nil
+}
+
+// linkBack drops the links of n to notes not in live and adds the
+// back-link of each two-way link to its target when it has none. It returns
+// the targets it changed.
+func linkBack(n *Note, live map[string]*Note) []*Note {
+	n.Links = slices.DeleteFunc(n.Links, func(link Link) bool { return live[link.Target] == nil })
+	var changed []*Note
+	for _, link := range n.Links {
+		other := live[link.Target]
+		if link.OneWay || other.HasLink(n.Id) {
+			continue
+		}
+		other.Links = append(other.Links, link.Inverse(n.Id))
+		changed = append(changed, other)
+	}
+	return changed
+}
+
+// Trash moves a note into the encrypted trash and drops it from the index
+// and manifest. The links other notes have to it are removed with it and
+// come back on restore; with refuseLinked a linked note is not trashed.
+// It returns the notes that linked to it.
+func (store *Store) Trash(noteId string, refuseLinked bool, username string, kp *crypt.KeyProvider) ([]string, error) {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	trashed, err := store.Load(noteId, username, kp)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	entry := TrashEntry{
+		Note:         trashed,
+		DeletedAt:    time.Now().UTC(),
+		InboundLinks: inboundLinks(notes, noteId),
+	}
+	if refuseLinked && len(entry.InboundLinks) > 0 {
+		return entry.InboundLinks, fmt.Errorf("note %s is linked from %d note(s): %s", noteId, len(entry.InboundLinks), strings.Join(entry.InboundLinks, ", "))
+	}
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	if entry.RemovedLinks, err = tx.unlinkInbound(notes, noteId); err != nil {
+		return nil, err
+	}
+	if err := tx.trash(entry); err != nil {
+		return nil, err
+	}
+	if err := tx.commit(); err != nil {
+		return nil, err
+	}
+	return entry.InboundLinks, nil
+}
+
+// trash drops the entry's note and stages it in the trash.
+func (tx *txn) trash(entry TrashEntry) error {
+	payload, err := sealJSON(tx.kp, entry, tx.settings.Compressed())
+	if err != nil {
+		return err
+	}
+	if err := tx.drop(entry.Note.Id); err != nil {
+		return err
+	}
+	tx.b.Put(trashName(entry.Note.Id), payload)
+	return nil
+}
+
+// ListTrash returns the trashed notes, most recently deleted first.
+func (store *Store) ListTrash(username string, kp *crypt.KeyProvider) ([]TrashEntry, error) {
+	store, unlock, err := store.Locked(username, false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return store.trashEntries(username, kp)
+}
+
+func (store *Store) trashEntries(username string, kp *crypt.KeyProvider) ([]TrashEntry, error) {
+	names, err := store.blobs.List(username, trashDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TrashEntry
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".pkm") {
+			continue
+		}
+		fileData, err := store.blobs.Get(username, path.Join(trashDir, name))
+		if err != nil {
+			return nil, err
+		}
+		var entry TrashEntry
+		if err := openJSON(fileData, kp, &entry, "trash entry"); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+// Restore brings a trashed note back, re-indexes it and restores the links
+// between it and every note that still exists.
+func (store *Store) Restore(noteId string, username string, kp *crypt.KeyProvider) error {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	fileData, err := store.blobs.Get(username, trashName(noteId))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("note %s is not in the trash", noteId)
+	}
+	if err != nil {
+		return err
+	}
+	var entry TrashEntry
+	if err := openJSON(fileData, kp, &entry, "trash entry"); err != nil {
+		return err
+	}
+	if _, err := store.blobs.Get(username, noteId+".pkm"); err == nil {
+		return fmt.Errorf("note %s already exists", noteId)
+	}
+
+	notes, err := store.loadAll(username, kp)
+	if err != nil {
+		return err
+	}
+	live := make(map[string]*Note, len(notes))
+	for _, other := range notes {
+		live[other.Id] = other
+	}
+
+	// Put back the links removed from the notes that linked to it, keep
+	// its links to notes still around and link back along both
+	restored := entry.Note
+	var relinked []*Note
+	for _, id := range entry.InboundLinks {
+		other, ok := live[id]
+		if !ok {
+			continue
+		}
+		inbound, ok := entry.RemovedLinks[id]
+		if !ok {
+			inbound = Link{Target: noteId}
+		}
+		if !other.HasLink(noteId) {
+			other.Links = append(other.Links, inbound)
+			relinked = append(relinked, other)
+		}
+		if back := inbound.Inverse(id); back.Target != "" && !restored.HasLink(id) {
+			restored.Links = append(restored.Links, back)
+		}
+	}
+	relinked = append(relinked, linkBack(restored, live)...)
+
+	tx, err := store.begin(username, kp)
+	if err != nil {
+		return err
+	}
+	// Another note may have taken the alias meanwhile
+	if owner, taken := tx.aliases[restored.Alias]; taken && owner != noteId {
+		restored.Alias = ""
+	}
+	if err := tx.save(restored); err != nil {
+		return err
+	}
+	for _, other := range relinked {
+		if err := tx.save(other); err != nil {
+			return err
+		}
+	}
+	tx.b.Remove(trashName(noteId))
+	return tx.commit()
+}
+
+// EmptyTrash permanently deletes trashed notes, their history and orphaned
+// attachments. With a non-zero olderThan only notes deleted longer ago are
+// purged. It returns how many notes were purged.
+func (store *Store) EmptyTrash(olderThan time.Duration, username string, kp *crypt.KeyProvider) (int, error) {
+	store, unlock, err := store.Locked(username, true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	entries, err := store.trashEntries(username, kp)
+	if err != nil {
+		return 0, err
+	}
+
+	var b Batch
+	purged := 0
+	for _, entry := range entries {
+		if olderThan > 0 && time.Since(entry.DeletedAt) < olderThan {
+			continue
+		}
+		b.Remove(trashName(entry.Note.Id))
+		if err := store.stageHistoryRemoval(&b, username, entry.Note.Id); err != nil {
+			return 0, err
+		}
+		purged++
+	}
+	if err := store.blobs.Commit(username, &b); err != nil {
+		return 0, err
+	}
+	if _, err := store.collectGarbage(username, kp); err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
@@ -0,0 +1,200 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+)
+
+// txn gathers the writes of one store operation together with the index
+// and manifest they change, so that everything lands in a single batch.
+// The caller holds the exclusive store lock.
+type txn struct {
+	store    *Store
+	username string
+	kp       *crypt.KeyProvider
+	b        Batch
+	index    *searchIndex
+	manifest *Manifest
+	settings *Settings
+	// aliases maps each alias to its note; changed once modified
+	aliases        map[string]string
+	aliasesChanged bool
+	// synonyms maps tag aliases to canonical tags; changed once modified
+	synonyms        map[string]string
+	synonymsChanged bool
+	// collect is set once revisions are removed, which may have held the
+	// last reference to an attachment
+	collect bool
+}
+
+func (store *Store) begin(username string, kp *crypt.KeyProvider) (*txn, error) {
+	index, err := store.readIndex(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	if index.missing {
+		// Rebuild rather than write an index of only the notes this
+		// transaction touches
+		if index, err = store.rebuildReadable(username, kp); err != nil {
+			return nil, err
+		}
+	}
+	manifest, err := store.readManifest(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := store.readSettings(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := store.readAliases(username, kp, manifest)
+	if err != nil {
+		return nil, err
+	}
+	synonyms, err := store.readTagAliases(username, kp)
+	if err != nil {
+		return nil, err
+	}
+	return &txn{
+		store:    store,
+		username: username,
+		kp:       kp,
+		index:    index,
+		manifest: manifest,
+		settings: settings,
+		aliases:  aliases,
+		synonyms: synonyms,
+	}, nil
+}
+
+// save stamps the note's metadata, stages the encrypted note, archiving the
+// revision it replaces when it changed, and updates the index, manifest
+// and aliases. Tags that are aliases are stored as their canonical tag.
+// It fails when another note already uses the note's alias or the note
+// lacks what its type requires.
+func (tx *txn) save(note *Note) error {
+	note.CanonicalizeTags(tx.synonyms)
+	if err := CheckType(note); err != nil {
+		return err
+	}
+	if owner, taken := tx.aliases[note.Alias]; note.Alias != "" && taken && owner != note.Id {
+		return fmt.Errorf("alias %q is already used by note %s", note.Alias, owner)
+	}
+	previous, err := tx.store.blobs.Get(tx.username, note.Id+".pkm")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	exists := err == nil
+	var before *Note
+	if exists {
+		// A revision that cannot be read counts as changed
+		before, _ = openNote(previous, tx.kp)
+	}
+	changed := stamp(note, before, time.Now().UTC())
+
+	payload, err := sealJSON(tx.kp, note, tx.settings.Compressed())
+	if err != nil {
+		return err
+	}
+	if exists && changed {
+		pruned, err := tx.store.archive(&tx.b, tx.username, note.Id, previous, tx.settings)
+		if err != nil {
+			return err
+		}
+		tx.collect = tx.collect || pruned
+	}
+
+	tx.b.Put(note.Id+".pkm", payload)
+	if err := tx.index.add(note); err != nil {
+		return err
+	}
+	tx.setAlias(note.Id, note.Alias)
+	return tx.manifest.add(note, time.Time{}, tx.kp)
+}
+
+// setAlias points alias at noteId and forgets any other alias it had.
+func (tx *txn) setAlias(noteId string, alias string) {
+	for other, id := range tx.aliases {
+		if id == noteId && other != alias {
+			delete(tx.aliases, other)
+			tx.aliasesChanged = true
+		}
+	}
+	if alias != "" && tx.aliases[alias] != noteId {
+		tx.aliases[alias] = noteId
+		tx.aliasesChanged = true
+	}
+}
+
+// drop stages the removal of a note blob and forgets it in the index and
+// manifest.
+func (tx *txn) drop(noteId string) error {
+	tx.b.Remove(noteId + ".pkm")
+	if err := tx.index.drop(noteId); err != nil {
+		return err
+	}
+	tx.manifest.drop(noteId)
+	tx.setAlias(noteId, "")
+	return nil
+}
+
+// commit writes the staged blobs with the updated index and manifest, then
+// deletes the attachments no longer referenced when revisions went away.
+func (tx *txn) commit() error {
+	if err := tx.index.stage(&tx.b, tx.settings.Compressed()); err != nil {
+		return err
+	}
+	if err := stageManifest(&tx.b, tx.manifest, tx.kp, tx.settings.Compressed()); err != nil {
+		return err
+	}
+	if tx.aliasesChanged {
+		if err := stageAliases(&tx.b, tx.aliases, tx.kp, tx.settings.Compressed()); err != nil {
+			return err
+		}
+	}
+	if tx.synonymsChanged {
+		if err := stageTagAliases(&tx.b, tx.synonyms, tx.kp, tx.settings.Compressed()); err != nil {
+			return err
+		}
+	}
+	if err := tx.store.blobs.Commit(tx.username, &tx.b); err != nil {
+		return err
+	}
+	if tx.collect {
+		_, err := tx.store.collectGarbage(tx.username, tx.kp)
+		return err
+	}
+	return nil
+}
+
+// rebuild replaces the index, manifest and aliases with ones covering
+// exactly notes, keeping the update times the manifest already knows. When
+// notes share an alias the first one keeps it.
+func (tx *txn) rebuild(notes []*Note) error {
+	previous := tx.manifest
+	index, err := tx.store.rebuildIndex(notes, tx.username, tx.kp)
+	if err != nil {
+		return err
+	}
+	tx.index = index
+	tx.manifest = newManifest()
+	tx.aliases = make(map[string]string)
+	tx.aliasesChanged = true
+	for _, note := range notes {
+		if _, taken := tx.aliases[note.Alias]; note.Alias != "" && !taken {
+			tx.aliases[note.Alias] = note.Id
+		}
+		updatedAt := note.CreatedAt
+		if entry, ok := previous.Notes[note.Id]; ok {
+			updatedAt = entry.UpdatedAt
+		}
+		if err := tx.manifest.add(note, updatedAt, tx.kp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
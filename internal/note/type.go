@@ -7,23 +7,184 @@ import (
 type Note struct {
 	Id        string    `json:"id"`
 	Title     string    `json:"title"`
+	Alias     string    `json:"alias,omitempty"`
+	Type      string    `json:"type,omitempty"`
 	Content   string    `json:"content"`
-	Links     []string  `json:"links"`
+	Links     []Link    `json:"links"`
 	Tags      []string  `json:"tags"`
 	CreatedAt time.Time `json:"created_at"`
+
+	Properties map[string]Property `json:"properties,omitempty"`
+
+	// Maintained by Save; notes written before they existed leave them zero
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+	Edits       int       `json:"edits,omitempty"`
+	ContentHash string    `json:"content_hash,omitempty"`
+	Words       int       `json:"words,omitempty"`
+
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Link points from a note to another. Typed links name how the note
+// relates to their target, e.g. it supports it, and may carry an
+// annotation; plain links have neither. Plain links are stored as the bare
+// target id, as every link was before links had types.
+type Link struct {
+	Target     string `json:"target"`
+	Relation   string `json:"relation,omitempty"`
+	Annotation string `json:"annotation,omitempty"`
+	// OneWay links have no back-link on their target
+	OneWay bool `json:"oneway,omitempty"`
 }
 
+// Attachment describes a file kept next to a note. The file itself lives
+// in .blobs/<hash>.pkm, shared by every note attaching the same content.
+type Attachment struct {
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Size      int64     `json:"size"`
+	MediaType string    `json:"media_type,omitempty"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// Store keeps notes, their index and everything around them in a
+// Backend. InitStore uses the filesystem, NewMemoryStore process memory.
 type Store struct {
+	blobs Backend
+	locks *lockTable
+	// held is the lock a view returned by Locked runs under
+	held        *heldLock
+	lockTimeout time.Duration
+	parallelism int
+	// StoreLocation is the directory InitStore was given, empty otherwise
 	StoreLocation string
 }
 
-type Index struct {
-	TagIndex     map[string][]string `json:"tags"`
-	KeywordIndex map[string][]string `json:"keywords"`
+// Backend is the raw byte layer a Store keeps a user's notes in, and the
+// contract for plugging in other storage. Blob names are relative to the
+// user's space and use "/" as separator. Get, Remove and List of missing
+// blobs or directories fail with an error wrapping fs.ErrNotExist.
+//
+// The note operations, Save, Load, Delete, List and Search among them,
+// are methods of Store on top of a Backend: encryption, the index, the
+// manifest, history and transactions are the same for every storage, so a
+// Backend only moves sealed bytes and NewStore turns it into a Store.
+type Backend interface {
+	Get(username string, name string) ([]byte, error)
+	Put(username string, name string, data []byte) error
+	Remove(username string, name string) error
+	// List returns the names of the blobs directly in dir, "" for the root
+	List(username string, dir string) ([]string, error)
+	// Walk returns the names of every blob in the user's space
+	Walk(username string) ([]string, error)
+	// Commit applies every write of b or none of them
+	Commit(username string, b *Batch) error
+	// Recover completes or rolls back a Commit interrupted by a crash
+	Recover(username string) error
+	// Lock coordinates processes sharing the storage: shared for readers,
+	// exclusive for writers, failing after timeout with a LockedError
+	Lock(username string, exclusive bool, timeout time.Duration) (func(), error)
+}
+
+var (
+	_ Backend = (*FileBackend)(nil)
+	_ Backend = (*MemoryBackend)(nil)
+)
+
+// IndexTerms is what a note is indexed under. The index remembers it for
+// every note so that edits and deletes can drop stale postings.
+type IndexTerms struct {
+	Keywords []string `json:"keywords"`
+	Tags     []string `json:"tags"`
+	// Props holds property keys and key=value pairs, see propertyTerms
+	Props []string `json:"props,omitempty"`
+}
+
+// Revision is an archived version of a note, replaced by a later save.
+type Revision struct {
+	Rev        int
+	ReplacedAt time.Time
+	Note       *Note
+}
+
+// TrashEntry is a deleted note kept in .trash/ until the trash is emptied.
+type TrashEntry struct {
+	Note      *Note     `json:"note"`
+	DeletedAt time.Time `json:"deleted_at"`
+	// InboundLinks are the notes that linked to it when it was deleted
+	InboundLinks []string `json:"inbound_links"`
+	// RemovedLinks are the links to it taken off those notes, by note id.
+	// Entries trashed while notes kept their links have none.
+	RemovedLinks map[string]Link `json:"removed_links,omitempty"`
+}
+
+// Settings are the per-user store preferences kept in .settings.pkm.
+type Settings struct {
+	// HistoryKeep is how many revisions to keep per note, 0 keeps all
+	HistoryKeep int `json:"history_keep"`
+	// HistoryMaxAge drops revisions replaced longer ago, 0 keeps all
+	HistoryMaxAge time.Duration `json:"history_max_age"`
+	// Compression is applied to files before encryption, "none" or "gzip"
+	Compression string `json:"compression,omitempty"`
+	// JournalTemplate names the template new journal entries start from
+	JournalTemplate string `json:"journal_template,omitempty"`
+}
+
+// Template is a named skeleton for new notes, kept in .templates/. Its
+// text is a note buffer with placeholders, see Template.Expand.
+type Template struct {
+	Name      string    `json:"name"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Problem is one integrity issue found by Fsck. Repair describes what
+// --repair did about it and is empty when nothing was changed.
+type Problem struct {
+	Kind   string
+	Name   string
+	Detail string
+	Repair string
+}
+
+// FsckReport is the outcome of Fsck over a user's store.
+type FsckReport struct {
+	Checked  int
+	Problems []Problem
+}
+
+// MigrateReport lists the blobs Migrate or Recompress rewrote, or would
+// rewrite in a dry run, and those it could not decrypt.
+type MigrateReport struct {
+	Upgraded []string
+	Skipped  []string
+	// Backup is where the original blobs were kept, empty if none were
+	Backup string
+}
+
+// Manifest holds the summary of every note in .manifest.pkm so that
+// listing notes does not decrypt each of them.
+type Manifest struct {
+	Notes map[string]ManifestEntry `json:"notes"`
+}
+
+type ManifestEntry struct {
+	NoteSummary
+	// Hash is the keyed hash of the note's JSON, to detect drift
+	Hash string `json:"hash"`
 }
 
 type NoteSummary struct {
-	Id    string   `json:"id"`
-	Title string   `json:"title"`
-	Tags  []string `json:"tags"`
+	Id        string    `json:"id"`
+	Title     string    `json:"title"`
+	Alias     string    `json:"alias,omitempty"`
+	Type      string    `json:"type,omitempty"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Edits     int       `json:"edits,omitempty"`
+	Words     int       `json:"words,omitempty"`
+
+	Properties map[string]Property `json:"properties,omitempty"`
 }
@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestAttachCommandName tests AttachCommand.Name()
+func TestAttachCommandName(t *testing.T) {
+	attachCmd := &cli.AttachCommand{Cli: &cli.Cli{}}
+	if attachCmd.Name() != "attach" {
+		t.Errorf("Expected 'attach', got %q", attachCmd.Name())
+	}
+}
+
+// TestAttachCommandRoundtrip tests adding, listing, fetching and removing an attachment
+func TestAttachCommandRoundtrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	attachCmd := &cli.AttachCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("With file", "Content")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	input := filepath.Join(t.TempDir(), "diagram.svg")
+	os.WriteFile(input, []byte("<svg/>"), 0644)
+
+	if err := attachCmd.Run([]string{"add", n.Id, input}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := attachCmd.Run([]string{"list", n.Id}); err != nil {
+		t.Errorf("List failed: %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "out.svg")
+	if err := attachCmd.Run([]string{"get", n.Id, "diagram.svg", "-o", output}); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if data, _ := os.ReadFile(output); string(data) != "<svg/>" {
+		t.Errorf("Decrypted attachment mismatch: got %q", data)
+	}
+	if err := attachCmd.Run([]string{"get", n.Id, "diagram.svg", "-o", output}); err == nil {
+		t.Error("Expected get to refuse overwriting an existing file")
+	}
+
+	if err := attachCmd.Run([]string{"rm", n.Id, "diagram.svg"}); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if len(loaded.Attachments) != 0 {
+		t.Errorf("Attachment not removed: %v", loaded.Attachments)
+	}
+}
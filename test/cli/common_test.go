@@ -53,9 +53,8 @@ func TestCLIStoreGetter(t *testing.T) {
 
 	store := cliObj.GetStore()
 	if store == nil {
-		t.Error("CLI Store should not be nil")
+		t.Fatal("CLI Store should not be nil")
 	}
-
 	if store.StoreLocation != tmpDir {
 		t.Errorf("Store location mismatch: got %q, want %q", store.StoreLocation, tmpDir)
 	}
@@ -0,0 +1,56 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+)
+
+// TestConfigCommandName tests ConfigCommand.Name()
+func TestConfigCommandName(t *testing.T) {
+	configCmd := &cli.ConfigCommand{Cli: &cli.Cli{}}
+	if configCmd.Name() != "config" {
+		t.Errorf("Expected 'config', got %q", configCmd.Name())
+	}
+}
+
+// TestConfigCommandSet tests changing a setting
+func TestConfigCommandSet(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	configCmd := &cli.ConfigCommand{Cli: testCli.toCli()}
+
+	if err := configCmd.Run([]string{"set", "history.keep", "5"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	settings, err := testCli.Store.LoadSettings(testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("LoadSettings failed: %v", err)
+	}
+	if settings.HistoryKeep != 5 {
+		t.Errorf("want history.keep 5, got %d", settings.HistoryKeep)
+	}
+
+	if err := configCmd.Run([]string{"set", "history.keep", "many"}); err == nil {
+		t.Error("Expected error for invalid value")
+	}
+}
+
+// TestConfigCommandCompression tests switching compression on
+func TestConfigCommandCompression(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	configCmd := &cli.ConfigCommand{Cli: testCli.toCli()}
+
+	if err := configCmd.Run([]string{"set", "compression", "zip"}); err == nil {
+		t.Error("Expected error for unknown compression")
+	}
+	if err := configCmd.Run([]string{"set", "compression", "gzip"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	settings, _ := testCli.Store.LoadSettings(testCli.Username, testCli.KeyProvider)
+	if !settings.Compressed() {
+		t.Error("want compression enabled")
+	}
+}
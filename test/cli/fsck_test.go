@@ -0,0 +1,37 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestFsckCommandName tests FsckCommand.Name()
+func TestFsckCommandName(t *testing.T) {
+	fsckCmd := &cli.FsckCommand{Cli: &cli.Cli{}}
+	if fsckCmd.Name() != "fsck" {
+		t.Errorf("Expected 'fsck', got %q", fsckCmd.Name())
+	}
+}
+
+// TestFsckCommandRepair tests that fsck fails on problems until repaired
+func TestFsckCommandRepair(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	fsckCmd := &cli.FsckCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Lonely", "Content")
+	n.AddLink("missing-note")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	if err := fsckCmd.Run([]string{}); err == nil {
+		t.Error("Expected fsck to report the dangling link")
+	}
+	if err := fsckCmd.Run([]string{"--repair"}); err != nil {
+		t.Fatalf("Repair failed: %v", err)
+	}
+	if err := fsckCmd.Run([]string{}); err != nil {
+		t.Errorf("Expected clean store after repair: %v", err)
+	}
+}
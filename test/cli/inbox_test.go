@@ -0,0 +1,143 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestInboxCommandName tests InboxCommand.Name()
+func TestInboxCommandName(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+
+	inboxCmd := &cli.InboxCommand{Cli: testCli.toCli()}
+	if inboxCmd.Name() != "inbox" {
+		t.Errorf("Expected 'inbox', got %q", inboxCmd.Name())
+	}
+}
+
+// TestInboxCommandWalk tests promoting, merging, discarding and skipping fleeting notes
+func TestInboxCommandWalk(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+
+	target := note.NewNote("Spaced Repetition", "Review at growing intervals.")
+	if err := testCli.Store.Save(target, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	var inbox []*note.Note
+	for _, title := range []string{"Promote me", "Merge me", "Discard me", "Skip me"} {
+		n := note.NewNote(title, title+" content")
+		n.SetType(note.TypeFleeting)
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+		inbox = append(inbox, n)
+	}
+
+	cliObj := testCli.toCli()
+	// An unknown choice and a literature note without a source are asked again
+	cliObj.SetInput(strings.NewReader(strings.Join([]string{
+		"x", "p", "literature", "", "p", "literature", "Podcast",
+		"m", "spaced repetition",
+		"d",
+		"s",
+	}, "\n") + "\n"))
+	inboxCmd := &cli.InboxCommand{Cli: cliObj}
+	if err := inboxCmd.Run(nil); err != nil {
+		t.Fatalf("inbox failed: %v", err)
+	}
+
+	promoted, _ := testCli.Store.Load(inbox[0].Id, testCli.Username, testCli.KeyProvider)
+	if promoted.Type != note.TypeLiterature || promoted.Properties[note.SourceProperty].Value != "Podcast" {
+		t.Errorf("note not promoted: %+v", promoted)
+	}
+	if _, err := testCli.Store.Load(inbox[1].Id, testCli.Username, testCli.KeyProvider); err == nil {
+		t.Error("merged note should be gone")
+	}
+	merged, _ := testCli.Store.Load(target.Id, testCli.Username, testCli.KeyProvider)
+	if !strings.Contains(merged.Content, "Merge me content") {
+		t.Errorf("content not merged: %q", merged.Content)
+	}
+	if _, err := testCli.Store.Load(inbox[2].Id, testCli.Username, testCli.KeyProvider); err == nil {
+		t.Error("discarded note should be gone")
+	}
+	trash, _ := testCli.Store.ListTrash(testCli.Username, testCli.KeyProvider)
+	if len(trash) != 2 {
+		t.Errorf("want merged and discarded notes in the trash, got %d", len(trash))
+	}
+	skipped, _ := testCli.Store.Load(inbox[3].Id, testCli.Username, testCli.KeyProvider)
+	if skipped.Type != note.TypeFleeting {
+		t.Errorf("skipped note should stay fleeting, got %q", skipped.Type)
+	}
+
+	// Running out of input ends the walk
+	cliObj.SetInput(strings.NewReader(""))
+	if err := inboxCmd.Run(nil); err != nil {
+		t.Errorf("inbox at end of input failed: %v", err)
+	}
+	if err := inboxCmd.Run([]string{"list"}); err != nil {
+		t.Errorf("inbox list failed: %v", err)
+	}
+}
+
+// TestNoteCommandTypes tests filtering by type, changing types and ordering structure notes
+func TestNoteCommandTypes(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+	linkCmd := &cli.LinkCommand{Cli: testCli.toCli()}
+	searchCmd := &cli.SearchCommand{Cli: testCli.toCli()}
+
+	moc := note.NewNote("Graphs MOC", "Map of graph notes")
+	moc.SetType(note.TypeStructure)
+	bfs := note.NewNote("BFS", "graph search")
+	dfs := note.NewNote("DFS", "graph search")
+	for _, n := range []*note.Note{moc, bfs, dfs} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+	for _, child := range []*note.Note{bfs, dfs} {
+		if err := linkCmd.Run([]string{"add", moc.Id, child.Id}); err != nil {
+			t.Fatalf("link add failed: %v", err)
+		}
+	}
+	if err := linkCmd.Run([]string{"move", moc.Id, dfs.Id, "1"}); err != nil {
+		t.Fatalf("link move failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(moc.Id, testCli.Username, testCli.KeyProvider)
+	if len(loaded.Links) != 2 || loaded.Links[0].Target != dfs.Id {
+		t.Errorf("want DFS first, got %v", loaded.Links)
+	}
+	if err := linkCmd.Run([]string{"move", moc.Id, dfs.Id, "third"}); err == nil {
+		t.Error("Expected error for an invalid position")
+	}
+
+	for _, args := range [][]string{
+		{"info", moc.Id},
+		{"list", "--type", "structure"},
+		{"list", "--long", "--type=permanent"},
+		{"type", bfs.Id, "fleeting"},
+	} {
+		if err := noteCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	if err := noteCmd.Run([]string{"list", "--type", "draft"}); err == nil {
+		t.Error("Expected error for an unknown type")
+	}
+	if err := noteCmd.Run([]string{"type", dfs.Id, "literature"}); err == nil {
+		t.Error("Expected error for a literature note without a source")
+	}
+	if err := searchCmd.Run([]string{"keyword", "--type", "fleeting", "graph"}); err != nil {
+		t.Errorf("search --type failed: %v", err)
+	}
+	loaded, _ = testCli.Store.Load(bfs.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Type != note.TypeFleeting {
+		t.Errorf("type not changed: %q", loaded.Type)
+	}
+}
@@ -0,0 +1,63 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestIndexCommandName tests IndexCommand.Name()
+func TestIndexCommandName(t *testing.T) {
+	indexCmd := &cli.IndexCommand{Cli: &cli.Cli{}}
+	if indexCmd.Name() != "index" {
+		t.Errorf("Expected 'index', got %q", indexCmd.Name())
+	}
+}
+
+// TestIndexCommandRebuild tests regenerating the index
+func TestIndexCommandRebuild(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	indexCmd := &cli.IndexCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Rebuild me", "Indexed content")
+	n.AddTag("indexed")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	if err := indexCmd.Run([]string{"rebuild"}); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	matches, err := testCli.Store.Search("tag", []string{"indexed"}, testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0] != n.Id {
+		t.Errorf("want [%s], got %v", n.Id, matches)
+	}
+}
+
+// TestIndexCommandVerify tests checking the manifest
+func TestIndexCommandVerify(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	indexCmd := &cli.IndexCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Listed", "Content")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	if err := indexCmd.Run([]string{"verify"}); err != nil {
+		t.Errorf("Verify failed on a fresh manifest: %v", err)
+	}
+}
+
+// TestIndexCommandUnknown tests an unknown subcommand fails
+func TestIndexCommandUnknown(t *testing.T) {
+	indexCmd := &cli.IndexCommand{Cli: &cli.Cli{}}
+	if err := indexCmd.Run([]string{"bogus"}); err == nil {
+		t.Error("Expected error for unknown subcommand")
+	}
+}
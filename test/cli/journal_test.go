@@ -0,0 +1,155 @@
+package cli_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestJournalCommandName tests JournalCommand.Name()
+func TestJournalCommandName(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+
+	journalCmd := &cli.JournalCommand{Cli: testCli.toCli()}
+	if journalCmd.Name() != "journal" {
+		t.Errorf("Expected 'journal', got %q", journalCmd.Name())
+	}
+}
+
+// TestJournalCommandAddAndOpen tests journal add, journal <date> and journal list
+func TestJournalCommandAddAndOpen(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	journalCmd := &cli.JournalCommand{Cli: testCli.toCli()}
+	today := note.JournalAlias(time.Now())
+
+	for _, line := range []string{"first thought", "second thought"} {
+		if err := journalCmd.Run([]string{"add", line}); err != nil {
+			t.Fatalf("journal add failed: %v", err)
+		}
+	}
+	id, err := testCli.Store.Resolve(today, testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("today's entry not found: %v", err)
+	}
+	entry, _ := testCli.Store.Load(id, testCli.Username, testCli.KeyProvider)
+	want := "# " + today + "\n\nfirst thought\nsecond thought\n"
+	if entry.Content != want || !slices.Contains(entry.Tags, note.JournalTag) {
+		t.Errorf("unexpected entry %q tagged %v", entry.Content, entry.Tags)
+	}
+
+	// Opening keeps what the editor leaves, the skeleton here
+	t.Setenv("EDITOR", "true")
+	if err := journalCmd.Run([]string{"yesterday"}); err != nil {
+		t.Fatalf("journal yesterday failed: %v", err)
+	}
+	yesterday, err := testCli.Store.Resolve(note.JournalAlias(time.Now().AddDate(0, 0, -1)), testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("yesterday's entry not created: %v", err)
+	}
+	entry, _ = testCli.Store.Load(id, testCli.Username, testCli.KeyProvider)
+	if !entry.HasLink(yesterday) {
+		t.Error("today's entry should link to yesterday's")
+	}
+	if err := journalCmd.Run([]string{}); err != nil {
+		t.Errorf("journal on an existing entry failed: %v", err)
+	}
+
+	for _, args := range [][]string{{"list"}, {"list", "--month"}, {"list", "1999-01"}} {
+		if err := journalCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	for _, args := range [][]string{{"list", "soon"}, {"add"}, {"someday"}} {
+		if err := journalCmd.Run(args); err == nil {
+			t.Errorf("%v should fail", args)
+		}
+	}
+}
+
+// TestJournalCommandTemplate tests the journal.template setting
+func TestJournalCommandTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	journalCmd := &cli.JournalCommand{Cli: cliObj}
+	configCmd := &cli.ConfigCommand{Cli: cliObj}
+
+	template := &note.Template{Name: "daily", Text: "---\ntags: [daily]\nmood: {{prompt:Mood}}\n---\n## {{date}} by {{user}}\n"}
+	if err := testCli.Store.SaveTemplate(template, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("SaveTemplate failed: %v", err)
+	}
+	if err := configCmd.Run([]string{"set", "journal.template", "Not A Name"}); err == nil {
+		t.Error("Expected error for an invalid template name")
+	}
+	if err := configCmd.Run([]string{"set", "journal.template", "daily"}); err != nil {
+		t.Fatalf("config set failed: %v", err)
+	}
+
+	cliObj.SetInput(strings.NewReader("calm\n"))
+	if err := journalCmd.Run([]string{"add", "--date", "2026-01-02", "walked"}); err != nil {
+		t.Fatalf("journal add failed: %v", err)
+	}
+	id, err := testCli.Store.Resolve("2026-01-02", testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("entry not found: %v", err)
+	}
+	entry, _ := testCli.Store.Load(id, testCli.Username, testCli.KeyProvider)
+	if entry.Content != "## 2026-01-02 by testuser\nwalked\n" || entry.Properties["mood"].Value != "calm" {
+		t.Errorf("skeleton not used: %q %v", entry.Content, entry.Properties)
+	}
+	if !slices.Equal(entry.Tags, []string{note.JournalTag, "daily"}) {
+		t.Errorf("want journal and template tags, got %v", entry.Tags)
+	}
+}
+
+// lockProbe answers a prompt after checking that another process could
+// take the store lock while the question waits
+type lockProbe struct {
+	store    *note.Store
+	username string
+	answer   *strings.Reader
+	err      error
+}
+
+func (p *lockProbe) Read(buf []byte) (int, error) {
+	if _, unlock, err := p.store.Locked(p.username, true); err != nil {
+		p.err = err
+	} else {
+		unlock()
+	}
+	return p.answer.Read(buf)
+}
+
+// TestJournalCommandAddPromptsUnlocked tests that journal add asks the template's questions before locking
+func TestJournalCommandAddPromptsUnlocked(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	journalCmd := &cli.JournalCommand{Cli: cliObj}
+
+	template := &note.Template{Name: "daily", Text: "mood: {{prompt:Mood}}\n"}
+	testCli.Store.SaveTemplate(template, testCli.Username, testCli.KeyProvider)
+	settings, _ := testCli.Store.LoadSettings(testCli.Username, testCli.KeyProvider)
+	settings.JournalTemplate = "daily"
+	testCli.Store.SaveSettings(settings, testCli.Username, testCli.KeyProvider)
+
+	other := note.InitStore(tmpDir)
+	other.SetLockTimeout(0)
+	probe := &lockProbe{store: other, username: testCli.Username, answer: strings.NewReader("calm\n")}
+	cliObj.SetInput(probe)
+	if err := journalCmd.Run([]string{"add", "--date", "2026-01-03", "walked"}); err != nil {
+		t.Fatalf("journal add failed: %v", err)
+	}
+	if probe.err != nil {
+		t.Errorf("store should not be locked while prompting: %v", probe.err)
+	}
+	if _, err := testCli.Store.Resolve("2026-01-03", testCli.Username, testCli.KeyProvider); err != nil {
+		t.Errorf("entry not created: %v", err)
+	}
+}
@@ -63,7 +63,7 @@ func TestLinkCommandAdd(t *testing.T) {
 
 	found1 := false
 	for _, linkID := range loaded1.Links {
-		if linkID == n2.Id {
+		if linkID.Target == n2.Id {
 			found1 = true
 			break
 		}
@@ -80,7 +80,7 @@ func TestLinkCommandAdd(t *testing.T) {
 
 	found := false
 	for _, linkID := range loaded2.Links {
-		if linkID == n1.Id {
+		if linkID.Target == n1.Id {
 			found = true
 			break
 		}
@@ -128,7 +128,7 @@ func TestLinkCommandRemove(t *testing.T) {
 	t.Logf("Loaded links after remove: %v", loaded.Links)
 
 	for _, linkID := range loaded.Links {
-		if linkID == n2.Id {
+		if linkID.Target == n2.Id {
 			t.Error("Link not removed from note")
 		}
 	}
@@ -146,3 +146,121 @@ func TestLinkCommandMissingArgs(t *testing.T) {
 		t.Error("Expected error for missing arguments")
 	}
 }
+
+// TestLinkCommandShortIdsAndTitles tests linking by id prefix and title
+func TestLinkCommandShortIdsAndTitles(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	linkCmd := &cli.LinkCommand{Cli: testCli.toCli()}
+
+	source := note.NewNote("Graph Theory", "vertices")
+	target := note.NewNote("Dijkstra", "shortest paths")
+	for _, n := range []*note.Note{source, target} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	if err := linkCmd.Run([]string{"add", source.Id[:8], "--title", "dijkstra"}); err != nil {
+		t.Fatalf("Link by prefix and title failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(source.Id, testCli.Username, testCli.KeyProvider)
+	if len(loaded.Links) != 1 || loaded.Links[0].Target != target.Id {
+		t.Errorf("want link to %s, got %v", target.Id, loaded.Links)
+	}
+
+	if err := linkCmd.Run([]string{"add", "--title=nope", target.Id}); err == nil {
+		t.Error("Expected error for unknown title")
+	}
+}
+
+// TestLinkCommandTyped tests typed, annotated and one-way links
+func TestLinkCommandTyped(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	linkCmd := &cli.LinkCommand{Cli: testCli.toCli()}
+
+	claim := note.NewNote("Claim", "")
+	study := note.NewNote("Study", "")
+	paper := note.NewNote("Paper", "")
+	for _, n := range []*note.Note{claim, study, paper} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	if err := linkCmd.Run([]string{"add", study.Id, claim.Id, "--type", "supports", "--annotation", "n=200"}); err != nil {
+		t.Fatalf("typed link add failed: %v", err)
+	}
+	loadedStudy, _ := testCli.Store.Load(study.Id, testCli.Username, testCli.KeyProvider)
+	if link, _ := loadedStudy.LinkTo(claim.Id); link.Relation != "supports" || link.Annotation != "n=200" {
+		t.Errorf("want a supports link with its annotation, got %v", loadedStudy.Links)
+	}
+	loadedClaim, _ := testCli.Store.Load(claim.Id, testCli.Username, testCli.KeyProvider)
+	if back, _ := loadedClaim.LinkTo(study.Id); back.Relation != "supported-by" {
+		t.Errorf("want a supported-by back-link, got %v", loadedClaim.Links)
+	}
+
+	if err := linkCmd.Run([]string{"add", "--oneway", claim.Id, paper.Id, "--type", "derived-from"}); err != nil {
+		t.Fatalf("one-way link add failed: %v", err)
+	}
+	loadedPaper, _ := testCli.Store.Load(paper.Id, testCli.Username, testCli.KeyProvider)
+	if len(loadedPaper.Links) != 0 {
+		t.Errorf("one-way link should not link back, got %v", loadedPaper.Links)
+	}
+
+	for _, args := range [][]string{
+		{"list", claim.Id, "--type", "supported-by"},
+		{"walk", study.Id, "--depth", "3"},
+		{"export", "--type", "supports"},
+		{"export", "--format", "dot"},
+	} {
+		if err := linkCmd.Run(args); err != nil {
+			t.Errorf("link %v failed: %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"add", paper.Id, study.Id, "--type", "Not Valid"},
+		{"add", paper.Id, study.Id, "--oneway", "--inverse", "supports"},
+		{"export", "--format", "svg"},
+	} {
+		if err := linkCmd.Run(args); err == nil {
+			t.Errorf("link %v should fail", args)
+		}
+	}
+}
+
+// TestLinkCommandIntegrity tests that links need an existing other note
+// and that deleting a note takes the links to it along
+func TestLinkCommandIntegrity(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	linkCmd := &cli.LinkCommand{Cli: cliObj}
+	noteCmd := &cli.NoteCommand{Cli: cliObj}
+
+	a := note.NewNote("A", "")
+	b := note.NewNote("B", "")
+	for _, n := range []*note.Note{a, b} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+	if err := linkCmd.Run([]string{"add", a.Id, a.Id}); err == nil {
+		t.Error("Expected error for self-link")
+	}
+	if err := linkCmd.Run([]string{"add", a.Id, b.Id}); err != nil {
+		t.Fatalf("link add failed: %v", err)
+	}
+
+	if err := noteCmd.Run([]string{"delete", "--refuse-linked", b.Id}); err == nil {
+		t.Error("Expected error deleting a linked note with --refuse-linked")
+	}
+	if err := noteCmd.Run([]string{"delete", b.Id}); err != nil {
+		t.Fatalf("note delete failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(a.Id, testCli.Username, testCli.KeyProvider)
+	if len(loaded.Links) != 0 {
+		t.Errorf("delete should remove the link to the deleted note, got %v", loaded.Links)
+	}
+}
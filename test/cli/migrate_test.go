@@ -0,0 +1,36 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestMigrateCommandName tests MigrateCommand.Name()
+func TestMigrateCommandName(t *testing.T) {
+	migrateCmd := &cli.MigrateCommand{Cli: &cli.Cli{}}
+	if migrateCmd.Name() != "migrate" {
+		t.Errorf("Expected 'migrate', got %q", migrateCmd.Name())
+	}
+}
+
+// TestMigrateCommandCurrentVault tests migrating a vault that needs nothing
+func TestMigrateCommandCurrentVault(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	migrateCmd := &cli.MigrateCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Current", "Content")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	if err := migrateCmd.Run([]string{"--dry-run"}); err != nil {
+		t.Errorf("Dry run failed: %v", err)
+	}
+	if err := migrateCmd.Run([]string{}); err != nil {
+		t.Errorf("Migrate failed: %v", err)
+	}
+	if err := migrateCmd.Run([]string{"--bogus"}); err == nil {
+		t.Error("Expected error for unknown flag")
+	}
+}
@@ -1,7 +1,11 @@
 package cli_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
@@ -99,3 +103,281 @@ func TestNoteCommandDeleteMissingId(t *testing.T) {
 		t.Error("Expected error when delete has no arguments")
 	}
 }
+
+// TestNoteCommandEditDetectsConcurrentChange tests that edit refuses to clobber a note saved meanwhile
+func TestNoteCommandEditDetectsConcurrentChange(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Contended", "Original")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	// Editor signals it is open, then waits until the test saved a change
+	started := filepath.Join(tmpDir, "started")
+	done := filepath.Join(tmpDir, "done")
+	editor := filepath.Join(tmpDir, "editor.sh")
+	script := "#!/bin/sh\ntouch " + started + "\nwhile [ ! -f " + done + " ]; do sleep 0.05; done\necho edited >> \"$1\"\n"
+	if err := os.WriteFile(editor, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", editor)
+
+	go func() {
+		for {
+			if _, err := os.Stat(started); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		other := note.InitStore(tmpDir)
+		concurrent, _ := other.Load(n.Id, testCli.Username, testCli.KeyProvider)
+		concurrent.Content = "Saved by another process"
+		other.Save(concurrent, testCli.Username, testCli.KeyProvider)
+		os.WriteFile(done, nil, 0644)
+	}()
+
+	if err := noteCmd.Run([]string{"edit", n.Id}); err == nil {
+		t.Fatal("Expected edit to refuse clobbering a concurrent change")
+	}
+
+	loaded, err := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("Failed to load note: %v", err)
+	}
+	if loaded.Content != "Saved by another process" {
+		t.Errorf("concurrent change was clobbered: got %q", loaded.Content)
+	}
+}
+
+// TestNoteCommandEdit tests editing a note through $EDITOR
+func TestNoteCommandEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Editable", "Original")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	editor := filepath.Join(tmpDir, "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\necho ' appended' >> \"$1\"\n"), 0755)
+	t.Setenv("EDITOR", editor)
+
+	if err := noteCmd.Run([]string{"edit", n.Id}); err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+
+	loaded, _ := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Content != "Original appended\n" {
+		t.Errorf("Content not edited: got %q", loaded.Content)
+	}
+}
+
+// TestNoteCommandEditKeepsRejectedEdit tests that broken frontmatter reopens the editor or is kept on disk
+func TestNoteCommandEditKeepsRejectedEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	noteCmd := &cli.NoteCommand{Cli: cliObj}
+
+	n := note.NewNote("Typo", "Body")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	// Breaks the frontmatter, and fixes it when run again
+	editor := filepath.Join(tmpDir, "editor.sh")
+	script := `#!/bin/sh
+if grep -q '\[open$' "$1"; then
+	sed -i 's/\[open$/[open]/' "$1"
+else
+	printf -- '---\nstatus: [open\n---\n' | cat - "$1" > "$1.new" && mv "$1.new" "$1"
+fi
+`
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("EDITOR", editor)
+
+	cliObj.SetInput(strings.NewReader("n\n"))
+	err := noteCmd.Run([]string{"edit", n.Id})
+	if err == nil || !strings.Contains(err.Error(), "edit kept in ") {
+		t.Fatalf("want the rejected edit kept, got %v", err)
+	}
+	kept := err.Error()[strings.LastIndex(err.Error(), "edit kept in ")+len("edit kept in "):]
+	defer os.Remove(kept)
+	if data, _ := os.ReadFile(kept); !strings.Contains(string(data), "status: [open") {
+		t.Errorf("kept file should hold the edit, got %q", data)
+	}
+
+	cliObj.SetInput(strings.NewReader("y\n"))
+	if err := noteCmd.Run([]string{"edit", n.Id}); err != nil {
+		t.Fatalf("edit again failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Properties["status"].Type != note.PropertyList || loaded.Content != "Body" {
+		t.Errorf("second edit not saved: %q %v", loaded.Content, loaded.Properties)
+	}
+}
+
+// TestNoteCommandHistoryAndRevert tests listing, diffing and reverting revisions
+func TestNoteCommandHistoryAndRevert(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Versioned", "first version")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+	n.Content = "second version"
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+
+	if err := noteCmd.Run([]string{"history", n.Id}); err != nil {
+		t.Errorf("History failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"diff", n.Id, "1", "current", "--words"}); err != nil {
+		t.Errorf("Diff failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"diff", n.Id, "1"}); err == nil {
+		t.Error("Expected error for diff with one revision")
+	}
+	if err := noteCmd.Run([]string{"revert", n.Id, "1"}); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+
+	loaded, _ := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Content != "first version" {
+		t.Errorf("Revert did not restore content: got %q", loaded.Content)
+	}
+}
+
+// TestNoteCommandAliasAndRename tests setting aliases and renaming notes
+func TestNoteCommandAliasAndRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	derived := note.NewNote("Graph Theory", "vertices")
+	derived.SetAlias("graph-theory")
+	picked := note.NewNote("Trees", "branches")
+	for _, n := range []*note.Note{derived, picked} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	if err := noteCmd.Run([]string{"alias", picked.Id[:8], "forest"}); err != nil {
+		t.Fatalf("Set alias failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"alias", "forest", "graph-theory"}); err == nil {
+		t.Error("Expected error for an alias another note uses")
+	}
+	if err := noteCmd.Run([]string{"alias", "forest", "Not A Slug"}); err == nil {
+		t.Error("Expected error for an invalid alias")
+	}
+
+	// A derived alias follows the title, a picked one stays
+	if err := noteCmd.Run([]string{"rename", "graph-theory", "Graph", "Algorithms"}); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"rename", "forest", "Binary Trees"}); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(derived.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Title != "Graph Algorithms" || loaded.Alias != "graph-algorithms" {
+		t.Errorf("want renamed note with derived alias, got %q (%q)", loaded.Title, loaded.Alias)
+	}
+	loaded, _ = testCli.Store.Load(picked.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Title != "Binary Trees" || loaded.Alias != "forest" {
+		t.Errorf("want picked alias kept, got %q (%q)", loaded.Title, loaded.Alias)
+	}
+
+	if err := noteCmd.Run([]string{"alias", "forest", "--clear"}); err != nil {
+		t.Fatalf("Clear alias failed: %v", err)
+	}
+	loaded, _ = testCli.Store.Load(picked.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Alias != "" {
+		t.Errorf("want alias cleared, got %q", loaded.Alias)
+	}
+}
+
+// TestNoteCommandListSortAndInfo tests list ordering flags and note info
+func TestNoteCommandListSortAndInfo(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Metadata", "a few words here")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"list", "--sort", "updated"},
+		{"list", "--sort", "created", "--reverse"},
+		{"list", "--sort=title"},
+		{"info", n.Id[:8]},
+	} {
+		if err := noteCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	if err := noteCmd.Run([]string{"list", "--sort", "size"}); err == nil {
+		t.Error("Expected error for an unknown sort order")
+	}
+	if err := noteCmd.Run([]string{"info"}); err == nil {
+		t.Error("Expected error for missing note id")
+	}
+}
+
+// TestNoteCommandProperties tests note set/unset, frontmatter editing and list --long
+func TestNoteCommandProperties(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	noteCmd := &cli.NoteCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Reading", "Body")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	if err := noteCmd.Run([]string{"set", n.Id[:8], "status=draft", "rating=4", "authors=[Knuth, Tarjan]"}); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"set", n.Id, "Status=draft"}); err == nil {
+		t.Error("Expected error for an invalid key")
+	}
+	if err := noteCmd.Run([]string{"set", n.Id, "status"}); err == nil {
+		t.Error("Expected error without a value")
+	}
+
+	// The editor sees the properties as frontmatter and can change them
+	editor := filepath.Join(tmpDir, "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\nsed -i 's/^status: draft$/status: done/' \"$1\"\n"), 0755)
+	t.Setenv("EDITOR", editor)
+	if err := noteCmd.Run([]string{"edit", n.Id}); err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+	loaded, _ := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if loaded.Content != "Body" || loaded.Properties["status"].Value != "done" || loaded.Properties["rating"].Type != note.PropertyNumber {
+		t.Errorf("frontmatter not applied: %q %v", loaded.Content, loaded.Properties)
+	}
+
+	if err := noteCmd.Run([]string{"list", "--long"}); err != nil {
+		t.Errorf("list --long failed: %v", err)
+	}
+	searchCmd := &cli.SearchCommand{Cli: testCli.toCli()}
+	if err := searchCmd.Run([]string{"prop", "status=done", "authors=knuth"}); err != nil {
+		t.Errorf("search prop failed: %v", err)
+	}
+
+	if err := noteCmd.Run([]string{"unset", n.Id, "status"}); err != nil {
+		t.Fatalf("unset failed: %v", err)
+	}
+	if err := noteCmd.Run([]string{"unset", n.Id, "status"}); err == nil {
+		t.Error("Expected error for a missing property")
+	}
+	loaded, _ = testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider)
+	if _, ok := loaded.Properties["status"]; ok {
+		t.Error("status should be removed")
+	}
+}
@@ -1,6 +1,7 @@
 package cli_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
@@ -134,3 +135,116 @@ func TestTagCommandAddMultipleTags(t *testing.T) {
 		t.Errorf("Expected 3 tags, got %d", len(loaded.Tags))
 	}
 }
+
+// TestTagCommandVaultWide tests tag list, rename, merge and orphans
+func TestTagCommandVaultWide(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	tagCmd := &cli.TagCommand{Cli: testCli.toCli()}
+
+	if err := tagCmd.Run([]string{"list"}); err != nil {
+		t.Errorf("list on an empty vault failed: %v", err)
+	}
+
+	a := note.NewNote("A", "")
+	a.AddTag("ml,go")
+	b := note.NewNote("B", "")
+	b.AddTag("machine-learn,go")
+	for _, n := range []*note.Note{a, b} {
+		if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	for _, args := range [][]string{{"list"}, {"list", a.Id}, {"orphans"}} {
+		if err := tagCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	if err := tagCmd.Run([]string{"merge", "ml", "machine-learn", "--into", "machine-learning"}); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+	if err := tagCmd.Run([]string{"rename", "go", "golang"}); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	tags, err := testCli.Store.Tags(testCli.Username, testCli.KeyProvider)
+	if err != nil {
+		t.Fatalf("Tags failed: %v", err)
+	}
+	if len(tags) != 2 || len(tags["machine-learning"]) != 2 || len(tags["golang"]) != 2 {
+		t.Errorf("unexpected tags after merge and rename: %v", tags)
+	}
+
+	for _, args := range [][]string{{"merge", "a"}, {"rename", "only-one"}, {"rename", "nothing", "else"}} {
+		if err := tagCmd.Run(args); err == nil {
+			t.Errorf("%v should fail", args)
+		}
+	}
+}
+
+// TestTagCommandTree tests tag tree and search tag --exact
+func TestTagCommandTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	tagCmd := &cli.TagCommand{Cli: testCli.toCli()}
+	searchCmd := &cli.SearchCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Dijkstra", "")
+	n.AddTag("cs/algorithms/graphs,cs-theory")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+	for _, args := range [][]string{{"tree"}, {"list"}} {
+		if err := tagCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	if err := searchCmd.Run([]string{"tag", "--exact", "cs"}); err != nil {
+		t.Errorf("search tag --exact failed: %v", err)
+	}
+	if err := searchCmd.Run([]string{"keyword", "--exact", "dijkstra"}); err == nil {
+		t.Error("--exact should only apply to tag search")
+	}
+}
+
+// TestTagCommandAliases tests tag alias and tag suggest
+func TestTagCommandAliases(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	tagCmd := &cli.TagCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Backprop", "")
+	n.AddTag("machine-learning")
+	m := note.NewNote("Gradients", "")
+	m.AddTag("machinelearning")
+	for _, saved := range []*note.Note{n, m} {
+		if err := testCli.Store.Save(saved, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Fatalf("Failed to save note: %v", err)
+		}
+	}
+
+	for _, args := range [][]string{{"suggest"}, {"alias", "list"}, {"alias", "add", "machinelearning", "machine-learning"}, {"alias", "list"}, {"suggest"}} {
+		if err := tagCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+	loaded, _ := testCli.Store.Load(m.Id, testCli.Username, testCli.KeyProvider)
+	if !slices.Equal(loaded.Tags, []string{"machine-learning"}) {
+		t.Errorf("alias add should retag notes, got %v", loaded.Tags)
+	}
+	// Removing by alias removes the canonical tag
+	if err := tagCmd.Run([]string{"remove", m.Id, "machinelearning"}); err != nil {
+		t.Errorf("tag remove by alias failed: %v", err)
+	}
+	if loaded, _ := testCli.Store.Load(m.Id, testCli.Username, testCli.KeyProvider); len(loaded.Tags) != 0 {
+		t.Errorf("tag remove by alias should remove machine-learning, got %v", loaded.Tags)
+	}
+	if err := tagCmd.Run([]string{"alias", "remove", "machinelearning"}); err != nil {
+		t.Errorf("alias remove failed: %v", err)
+	}
+	for _, args := range [][]string{{"alias"}, {"alias", "add", "x"}, {"alias", "rename"}} {
+		if err := tagCmd.Run(args); err == nil {
+			t.Errorf("%v should fail", args)
+		}
+	}
+}
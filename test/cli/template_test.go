@@ -0,0 +1,136 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestTemplateCommandName tests TemplateCommand.Name()
+func TestTemplateCommandName(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+
+	templateCmd := &cli.TemplateCommand{Cli: testCli.toCli()}
+	if templateCmd.Name() != "template" {
+		t.Errorf("Expected 'template', got %q", templateCmd.Name())
+	}
+}
+
+// TestNoteCommandNewFromTemplate tests template add and note new --template
+func TestNoteCommandNewFromTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	templateCmd := &cli.TemplateCommand{Cli: cliObj}
+	noteCmd := &cli.NoteCommand{Cli: cliObj}
+
+	index := note.NewNote("Standups", "All standups")
+	index.SetAlias("standups")
+	if err := testCli.Store.Save(index, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	// The editor replaces the skeleton with the template
+	text := "---\ntags: [meeting]\nlinks: [standups]\nmood: {{prompt:Mood}}\n---\n# {{title}} by {{user}}\n"
+	source := filepath.Join(tmpDir, "template.txt")
+	os.WriteFile(source, []byte(text), 0644)
+	editor := filepath.Join(tmpDir, "editor.sh")
+	os.WriteFile(editor, []byte("#!/bin/sh\ncp "+source+" \"$1\"\n"), 0755)
+	t.Setenv("EDITOR", editor)
+
+	if err := templateCmd.Run([]string{"add", "meeting"}); err != nil {
+		t.Fatalf("template add failed: %v", err)
+	}
+	if err := templateCmd.Run([]string{"add", "meeting"}); err == nil {
+		t.Error("Expected error for an existing template")
+	}
+	for _, args := range [][]string{{"list"}, {"show", "meeting"}} {
+		if err := templateCmd.Run(args); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	// Keep what the template produced
+	t.Setenv("EDITOR", "true")
+	cliObj.SetInput(strings.NewReader("great\n"))
+	if err := noteCmd.Run([]string{"new", "--template", "meeting", "Standup"}); err != nil {
+		t.Fatalf("note new --template failed: %v", err)
+	}
+
+	summaries, _ := testCli.Store.List(testCli.Username, testCli.KeyProvider)
+	var created *note.Note
+	for _, summary := range summaries {
+		if summary.Title == "Standup" {
+			created, _ = testCli.Store.Load(summary.Id, testCli.Username, testCli.KeyProvider)
+		}
+	}
+	if created == nil {
+		t.Fatal("note not created")
+	}
+	if created.Content != "# Standup by testuser\n" || created.Properties["mood"].Value != "great" {
+		t.Errorf("template not expanded: %q %v", created.Content, created.Properties)
+	}
+	if !slices.Equal(created.Tags, []string{"meeting"}) || !slices.Equal(created.LinkTargets(), []string{index.Id}) {
+		t.Errorf("want the template's tags and links, got %v %v", created.Tags, created.Links)
+	}
+	backlinked, _ := testCli.Store.Load(index.Id, testCli.Username, testCli.KeyProvider)
+	if !backlinked.HasLink(created.Id) {
+		t.Error("linked note should link back")
+	}
+
+	if err := noteCmd.Run([]string{"new", "--template", "missing", "Other"}); err == nil {
+		t.Error("Expected error for a missing template")
+	}
+	if err := templateCmd.Run([]string{"rm", "meeting"}); err != nil {
+		t.Errorf("template rm failed: %v", err)
+	}
+	if err := templateCmd.Run([]string{"rm", "meeting"}); err == nil {
+		t.Error("Expected error removing a missing template")
+	}
+}
+
+// TestTemplateCommandAddKeepsRejectedEdit tests that a bad placeholder reopens the editor or is kept on disk
+func TestTemplateCommandAddKeepsRejectedEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	templateCmd := &cli.TemplateCommand{Cli: cliObj}
+
+	// Writes a misspelt placeholder, and fixes it when run again
+	editor := filepath.Join(tmpDir, "editor.sh")
+	script := `#!/bin/sh
+if grep -q '{{dat}}' "$1"; then
+	sed -i 's/{{dat}}/{{date}}/' "$1"
+else
+	printf '# Log {{dat}}\n' > "$1"
+fi
+`
+	os.WriteFile(editor, []byte(script), 0755)
+	t.Setenv("EDITOR", editor)
+
+	cliObj.SetInput(strings.NewReader("n\n"))
+	err := templateCmd.Run([]string{"add", "log"})
+	if err == nil || !strings.Contains(err.Error(), "edit kept in ") {
+		t.Fatalf("want the rejected edit kept, got %v", err)
+	}
+	kept := err.Error()[strings.LastIndex(err.Error(), "edit kept in ")+len("edit kept in "):]
+	defer os.Remove(kept)
+	if data, _ := os.ReadFile(kept); string(data) != "# Log {{dat}}\n" {
+		t.Errorf("kept file should hold the edit, got %q", data)
+	}
+
+	cliObj.SetInput(strings.NewReader("y\n"))
+	if err := templateCmd.Run([]string{"add", "log"}); err != nil {
+		t.Fatalf("edit again failed: %v", err)
+	}
+	template, err := testCli.Store.LoadTemplate("log", testCli.Username, testCli.KeyProvider)
+	if err != nil || template.Text != "# Log {{date}}\n" {
+		t.Errorf("second edit not saved: %v (%v)", template, err)
+	}
+}
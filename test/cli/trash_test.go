@@ -0,0 +1,93 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/cli"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestTrashCommandName tests TrashCommand.Name()
+func TestTrashCommandName(t *testing.T) {
+	trashCmd := &cli.TrashCommand{Cli: &cli.Cli{}}
+	if trashCmd.Name() != "trash" {
+		t.Errorf("Expected 'trash', got %q", trashCmd.Name())
+	}
+}
+
+// TestTrashCommandRestore tests deleting and restoring a note
+func TestTrashCommandRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	cliObj := testCli.toCli()
+	noteCmd := &cli.NoteCommand{Cli: cliObj}
+	trashCmd := &cli.TrashCommand{Cli: cliObj}
+
+	n := note.NewNote("Undeletable", "Content")
+	if err := testCli.Store.Save(n, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Fatalf("Failed to save note: %v", err)
+	}
+
+	if err := noteCmd.Run([]string{"delete", n.Id}); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := trashCmd.Run([]string{"list"}); err != nil {
+		t.Errorf("List failed: %v", err)
+	}
+	if err := trashCmd.Run([]string{"restore", n.Id}); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if _, err := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider); err != nil {
+		t.Errorf("Restored note should load: %v", err)
+	}
+}
+
+// TestTrashCommandEmpty tests emptying the trash
+func TestTrashCommandEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	trashCmd := &cli.TrashCommand{Cli: testCli.toCli()}
+
+	n := note.NewNote("Gone for good", "Content")
+	testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+	testCli.Store.Trash(n.Id, false, testCli.Username, testCli.KeyProvider)
+
+	if err := trashCmd.Run([]string{"empty", "--older-than", "bogus"}); err == nil {
+		t.Error("Expected error for invalid --older-than")
+	}
+	if err := trashCmd.Run([]string{"empty"}); err != nil {
+		t.Fatalf("Empty failed: %v", err)
+	}
+	if err := trashCmd.Run([]string{"restore", n.Id}); err == nil {
+		t.Error("Expected error restoring a purged note")
+	}
+}
+
+// TestTrashCommandRestoreByPrefixAndTitle tests restoring by id prefix and --title
+func TestTrashCommandRestoreByPrefixAndTitle(t *testing.T) {
+	tmpDir := t.TempDir()
+	testCli := setupTestEnvironment(t, tmpDir, "testuser", "password")
+	trashCmd := &cli.TrashCommand{Cli: testCli.toCli()}
+
+	first := note.NewNote("First deleted", "Content")
+	second := note.NewNote("Second deleted", "Content")
+	for _, n := range []*note.Note{first, second} {
+		testCli.Store.Save(n, testCli.Username, testCli.KeyProvider)
+		testCli.Store.Trash(n.Id, false, testCli.Username, testCli.KeyProvider)
+	}
+
+	if err := trashCmd.Run([]string{"restore", first.Id[:8]}); err != nil {
+		t.Fatalf("Restore by prefix failed: %v", err)
+	}
+	if err := trashCmd.Run([]string{"restore", "--title", "second deleted"}); err != nil {
+		t.Fatalf("Restore by title failed: %v", err)
+	}
+	for _, n := range []*note.Note{first, second} {
+		if _, err := testCli.Store.Load(n.Id, testCli.Username, testCli.KeyProvider); err != nil {
+			t.Errorf("Restored note %s should load: %v", n.Title, err)
+		}
+	}
+	if err := trashCmd.Run([]string{"restore"}); err == nil {
+		t.Error("Expected usage error without an id")
+	}
+}
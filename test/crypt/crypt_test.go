@@ -57,6 +57,76 @@ func TestNewKeyProviderUserNotFound(t *testing.T) {
 	}
 }
 
+func TestNewKeyProviderWithDEK(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestUser(t, tmpDir, "testuser", "password")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "testuser", "password")
+
+	// Same DEK should decrypt data from the .crypt backed provider
+	raw, err := crypt.NewKeyProviderWithDEK("testuser", kp.DEK())
+	if err != nil {
+		t.Fatalf("NewKeyProviderWithDEK failed: %v", err)
+	}
+
+	encrypted, err := kp.Encrypt([]byte("shared secret"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	decrypted, err := raw.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if string(decrypted) != "shared secret" {
+		t.Errorf("Decrypted data mismatch: got %q", decrypted)
+	}
+
+	if _, err := crypt.NewKeyProviderWithDEK("testuser", []byte("short")); err == nil {
+		t.Fatal("NewKeyProviderWithDEK with short DEK should fail")
+	}
+	if _, err := crypt.NewKeyProviderWithDEK("", kp.DEK()); err == nil {
+		t.Fatal("NewKeyProviderWithDEK with empty username should fail")
+	}
+}
+
+func TestEncryptWithAAD(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestUser(t, tmpDir, "user1", "password")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "user1", "password")
+
+	sealed, err := kp.EncryptWithAAD([]byte("secret"), []byte("header"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD failed: %v", err)
+	}
+	if plaintext, err := kp.DecryptWithAAD(sealed, []byte("header")); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("DecryptWithAAD failed: %q %v", plaintext, err)
+	}
+	if _, err := kp.DecryptWithAAD(sealed, []byte("HEADER")); err == nil {
+		t.Fatal("Altered additional data should fail to decrypt")
+	}
+	if len(kp.KeyID()) != crypt.KeyIDSize {
+		t.Fatalf("KeyID should be %d bytes, got %d", crypt.KeyIDSize, len(kp.KeyID()))
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestUser(t, tmpDir, "user1", "password")
+	setupTestUser(t, tmpDir, "user2", "password")
+	kp1, _ := crypt.NewKeyProvider(tmpDir, "user1", "password")
+	kp2, _ := crypt.NewKeyProvider(tmpDir, "user2", "password")
+
+	data := []byte("same file")
+	if kp1.ContentHash(data) != kp1.ContentHash(data) {
+		t.Fatal("ContentHash should be deterministic per key")
+	}
+	if kp1.ContentHash(data) == kp1.ContentHash([]byte("other file")) {
+		t.Fatal("Different content should hash differently")
+	}
+	if kp1.ContentHash(data) == kp2.ContentHash(data) {
+		t.Fatal("ContentHash should depend on the user's key")
+	}
+}
+
 func TestEncrypt(t *testing.T) {
 	tmpDir := t.TempDir()
 	username := "testuser"
@@ -0,0 +1,131 @@
+package note_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Graph Theory":          "graph-theory",
+		"  C++ & Go: 101!  ":    "c-go-101",
+		"Ünïcode only ✓":        "n-code-only",
+		"!!!":                   "",
+		strings.Repeat("a", 80): strings.Repeat("a", note.MaxAliasLength),
+	}
+	for title, want := range cases {
+		if got := note.Slugify(title); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+	for _, alias := range []string{"", "Graph", "a--b", "-a", "a b", "550e8400-e29b-41d4-a716-446655440000"} {
+		if note.ValidateAlias(alias) == nil {
+			t.Errorf("alias %q should be rejected", alias)
+		}
+	}
+}
+
+func TestAliasesUniqueAndResolvable(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			first := note.NewNote("Graph Theory", "")
+			alias, err := backend.SuggestAlias(first.Title, first.Id, "alice", kp)
+			if err != nil || alias != "graph-theory" {
+				t.Fatalf("want graph-theory, got %q (%v)", alias, err)
+			}
+			first.SetAlias(alias)
+			backend.Save(first, "alice", kp)
+
+			second := note.NewNote("Graph theory", "")
+			if alias, _ := backend.SuggestAlias(second.Title, second.Id, "alice", kp); alias != "graph-theory-2" {
+				t.Errorf("want a numbered alias for the second note, got %q", alias)
+			}
+			second.SetAlias("graph-theory")
+			if err := backend.Save(second, "alice", kp); err == nil {
+				t.Error("saving a duplicate alias should fail")
+			}
+
+			if id, err := backend.Resolve("graph-theory", "alice", kp); err != nil || id != first.Id {
+				t.Errorf("want alias resolved, got %q (%v)", id, err)
+			}
+			summaries, _ := backend.List("alice", kp)
+			if len(summaries) != 1 || summaries[0].Alias != "graph-theory" {
+				t.Errorf("want alias in the listing, got %v", summaries)
+			}
+
+			// Changing the alias frees the old one
+			first.SetAlias("graphs")
+			backend.Save(first, "alice", kp)
+			if _, err := backend.Resolve("graph-theory", "alice", kp); err == nil {
+				t.Error("old alias should no longer resolve")
+			}
+			if err := backend.Save(second, "alice", kp); err != nil {
+				t.Errorf("freed alias should be available: %v", err)
+			}
+
+			// Deleting frees it too
+			backend.Delete(first.Id, "alice", kp)
+			if _, err := backend.Resolve("graphs", "alice", kp); err == nil {
+				t.Error("alias of a deleted note should not resolve")
+			}
+			third := note.NewNote("Third", "")
+			third.SetAlias("graphs")
+			if err := backend.Save(third, "alice", kp); err != nil {
+				t.Errorf("alias of a deleted note should be available: %v", err)
+			}
+		})
+	}
+}
+
+func TestAliasTrashRestore(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Trees", "")
+	n.SetAlias("trees")
+	backend.Save(n, "alice", kp)
+	backend.Trash(n.Id, false, "alice", kp)
+
+	other := note.NewNote("Other trees", "")
+	other.SetAlias("trees")
+	if err := backend.Save(other, "alice", kp); err != nil {
+		t.Fatalf("trashed note should free its alias: %v", err)
+	}
+	if err := backend.Restore(n.Id, "alice", kp); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	restored, _ := backend.Load(n.Id, "alice", kp)
+	if restored.Alias != "" {
+		t.Errorf("restored note should give up a taken alias, got %q", restored.Alias)
+	}
+}
+
+func TestFsckDuplicateAlias(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	a := &note.Note{Id: "a", Title: "A", Alias: "same"}
+	b := &note.Note{Id: "b", Title: "B", Alias: "same"}
+	backend.Save(a, "alice", kp)
+	// Written behind the store's back, as a hand-edited vault would be
+	sealNote(t, backend, b, kp)
+	backend.RebuildIndex("alice", kp)
+
+	report, err := backend.Fsck(true, "alice", kp)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if kinds := problemKinds(report); len(kinds) != 1 || kinds[0] != note.ProblemAlias {
+		t.Errorf("want the duplicate alias reported, got %v", report.Problems)
+	}
+	if again, _ := backend.Fsck(false, "alice", kp); len(again.Problems) != 0 {
+		t.Errorf("repair should leave a clean store, got %v", again.Problems)
+	}
+	if id, _ := backend.Resolve("same", "alice", kp); id != "a" {
+		t.Errorf("first note should keep the alias, got %q", id)
+	}
+}
@@ -0,0 +1,93 @@
+package note_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestSaveLeavesNoTempFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "atomic", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "atomic", "pass")
+
+	n := note.NewNote("Atomic", "Write me safely")
+	if err := store.Save(n, "atomic", kp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(tmpDir, "atomic"))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") || entry.Name() == ".journal" {
+			t.Errorf("leftover file after save: %s", entry.Name())
+		}
+	}
+}
+
+func TestRecoverRemovesStrayTempFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "atomic", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "atomic", "pass")
+
+	n := note.NewNote("Keep", "Committed content")
+	store.Save(n, "atomic", kp)
+
+	// Simulate a crash between temp write and journal commit
+	userDir := filepath.Join(tmpDir, "atomic")
+	stray := filepath.Join(userDir, n.Id+".pkm.tmp")
+	os.WriteFile(stray, []byte("half written"), 0644)
+
+	if err := store.Recover("atomic"); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Error("stray temp file should be removed")
+	}
+
+	loaded, err := store.Load(n.Id, "atomic", kp)
+	if err != nil || loaded.Content != "Committed content" {
+		t.Errorf("committed note should survive recovery: %v", err)
+	}
+}
+
+func TestRecoverReplaysCommittedJournal(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "atomic", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "atomic", "pass")
+
+	old := note.NewNote("Old", "Old content")
+	store.Save(old, "atomic", kp)
+
+	// Produce the bytes of an updated note through a scratch store
+	scratch := note.NewMemoryStore()
+	old.Content = "New content"
+	scratch.Save(old, "atomic", kp)
+	newNote, _ := scratch.GetBlob("atomic", old.Id+".pkm")
+
+	// Simulate a crash after the journal was committed but before rename
+	userDir := filepath.Join(tmpDir, "atomic")
+	os.WriteFile(filepath.Join(userDir, old.Id+".pkm.tmp"), newNote, 0644)
+	os.WriteFile(filepath.Join(userDir, ".journal"), []byte(`{"puts":["`+old.Id+`.pkm"],"removes":[]}`), 0644)
+
+	if err := store.Recover("atomic"); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+
+	loaded, err := store.Load(old.Id, "atomic", kp)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Content != "New content" {
+		t.Errorf("journal not replayed: got %q", loaded.Content)
+	}
+	if _, err := os.Stat(filepath.Join(userDir, ".journal")); !os.IsNotExist(err) {
+		t.Error("journal should be removed after replay")
+	}
+}
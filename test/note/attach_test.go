@@ -0,0 +1,179 @@
+package note_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func countBlobs(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(filepath.Join(dir, ".blobs"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestAttachDeduplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "files", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "files", "pass")
+	userDir := filepath.Join(tmpDir, "files")
+
+	n1 := note.NewNote("Paper", "see attachment")
+	n2 := note.NewNote("Same paper", "see attachment")
+	store.Save(n1, "files", kp)
+	store.Save(n2, "files", kp)
+
+	pdf := []byte("%PDF-1.7 pretend paper")
+	attachment, err := store.Attach(n1.Id, "/tmp/paper.pdf", pdf, "files", kp)
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	if attachment.Name != "paper.pdf" || attachment.MediaType != "application/pdf" {
+		t.Errorf("unexpected attachment metadata: %+v", attachment)
+	}
+	if _, err := store.Attach(n1.Id, "paper.pdf", pdf, "files", kp); err == nil {
+		t.Error("attaching the same name twice should fail")
+	}
+	store.Attach(n2.Id, "copy.pdf", pdf, "files", kp)
+
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Errorf("identical files should share one blob, got %d", got)
+	}
+
+	data, err := store.Attachment(n2.Id, "copy.pdf", "files", kp)
+	if err != nil || string(data) != string(pdf) {
+		t.Errorf("attachment roundtrip failed: %q %v", data, err)
+	}
+
+	// Blob stays while another note still uses it
+	if err := store.Detach(n1.Id, "paper.pdf", "files", kp); err != nil {
+		t.Fatalf("Detach failed: %v", err)
+	}
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Errorf("shared blob removed too early, got %d", got)
+	}
+
+	// Trashed notes and archived revisions keep their attachments, purged
+	// ones release them
+	store.Trash(n2.Id, false, "files", kp)
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Errorf("trashed note's blob should survive, got %d", got)
+	}
+	store.EmptyTrash(0, "files", kp)
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Errorf("blob of n1's archived revision should survive, got %d", got)
+	}
+	store.Delete(n1.Id, "files", kp)
+	if got := countBlobs(t, userDir); got != 0 {
+		t.Errorf("orphaned blob should be removed, got %d", got)
+	}
+}
+
+func TestAttachmentEncrypted(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "files", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "files", "pass")
+
+	n := note.NewNote("Secret diagram", "")
+	store.Save(n, "files", kp)
+	attachment, _ := store.Attach(n.Id, "diagram.txt", []byte("plaintext secret"), "files", kp)
+
+	raw, err := os.ReadFile(filepath.Join(tmpDir, "files", ".blobs", attachment.Hash+".pkm"))
+	if err != nil {
+		t.Fatalf("blob not found: %v", err)
+	}
+	if string(raw) == "plaintext secret" || len(raw) < len("plaintext secret") {
+		t.Error("attachment should be stored encrypted")
+	}
+}
+
+func TestRevertKeepsDetachedBlob(t *testing.T) {
+	store := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Paper", "see attachment")
+	store.Save(n, "alice", kp)
+	store.Attach(n.Id, "paper.pdf", []byte("%PDF"), "alice", kp)
+	history, _ := store.History(n.Id, "alice", kp)
+	if err := store.Detach(n.Id, "paper.pdf", "alice", kp); err != nil {
+		t.Fatalf("Detach failed: %v", err)
+	}
+
+	// The revision archived by Detach still lists the attachment
+	if err := store.Revert(n.Id, len(history)+1, "alice", kp); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	data, err := store.Attachment(n.Id, "paper.pdf", "alice", kp)
+	if err != nil || string(data) != "%PDF" {
+		t.Errorf("attachment lost after revert: %q %v", data, err)
+	}
+}
+
+func TestHistoryPruningReleasesBlobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "files", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "files", "pass")
+	userDir := filepath.Join(tmpDir, "files")
+
+	settings := note.DefaultSettings()
+	settings.HistoryKeep = 1
+	store.SaveSettings(settings, "files", kp)
+
+	n := note.NewNote("Paper", "see attachment")
+	store.Save(n, "files", kp)
+	store.Attach(n.Id, "paper.pdf", []byte("%PDF"), "files", kp)
+	store.Detach(n.Id, "paper.pdf", "files", kp)
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Fatalf("blob of the archived revision should survive, got %d", got)
+	}
+
+	// The next edit prunes the only revision listing the attachment
+	n, _ = store.Load(n.Id, "files", kp)
+	n.Content = "attachment gone"
+	if err := store.Save(n, "files", kp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if got := countBlobs(t, userDir); got != 0 {
+		t.Errorf("blob of a pruned revision should be removed, got %d", got)
+	}
+}
+
+func TestUnreadableRevisionKeepsBlobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "files", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "files", "pass")
+	userDir := filepath.Join(tmpDir, "files")
+
+	n := note.NewNote("Paper", "see attachment")
+	other := note.NewNote("Other", "")
+	store.Save(n, "files", kp)
+	store.Save(other, "files", kp)
+	store.Attach(n.Id, "paper.pdf", []byte("%PDF"), "files", kp)
+	store.Detach(n.Id, "paper.pdf", "files", kp)
+
+	// Damage the newest revision, the only one listing the attachment
+	revisions, _ := filepath.Glob(filepath.Join(userDir, ".history", n.Id, "*.pkm"))
+	if len(revisions) != 2 {
+		t.Fatalf("want 2 revisions, got %d", len(revisions))
+	}
+	os.WriteFile(revisions[1], []byte("PKM\ngarbage"), 0644)
+
+	if err := store.Delete(other.Id, "files", kp); err == nil {
+		t.Error("garbage collection should report the unreadable revision")
+	}
+	if got := countBlobs(t, userDir); got != 1 {
+		t.Errorf("blobs should survive an unreadable revision, got %d", got)
+	}
+}
@@ -0,0 +1,151 @@
+package note_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// memoryKeyProvider returns a key provider with a random DEK, no .crypt needed
+func memoryKeyProvider(t *testing.T, username string) *crypt.KeyProvider {
+	dek := make([]byte, crypt.DEKSize)
+	if _, err := rand.Read(dek); err != nil {
+		t.Fatal(err)
+	}
+	kp, err := crypt.NewKeyProviderWithDEK(username, dek)
+	if err != nil {
+		t.Fatalf("NewKeyProviderWithDEK failed: %v", err)
+	}
+	return kp
+}
+
+// backends returns a store over every Backend implementation
+func backends(t *testing.T) map[string]*note.Store {
+	return map[string]*note.Store{
+		"file":   note.InitStore(t.TempDir()),
+		"memory": note.NewMemoryStore(),
+	}
+}
+
+func TestBackendSaveLoadDelete(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("Graph Theory", "Vertices and edges")
+			if err := backend.Save(n, "alice", kp); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			loaded, err := backend.Load(n.Id, "alice", kp)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if loaded.Title != n.Title || loaded.Content != n.Content {
+				t.Errorf("Loaded note mismatch: got %+v", loaded)
+			}
+
+			if err := backend.Delete(n.Id, "alice", kp); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if _, err := backend.Load(n.Id, "alice", kp); err == nil {
+				t.Error("Load should fail after delete")
+			}
+			if err := backend.Delete(n.Id, "alice", kp); err == nil {
+				t.Error("Deleting a missing note should fail")
+			}
+		})
+	}
+}
+
+func TestBackendListAndSearch(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			backend.Save(&note.Note{Id: "b", Title: "Breadth first search", Tags: []string{"graphs"}}, "alice", kp)
+			backend.Save(&note.Note{Id: "a", Title: "Algorithms", Tags: []string{"cs"}}, "alice", kp)
+
+			summaries, err := backend.List("alice", kp)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(summaries) != 2 || summaries[0].Title != "Algorithms" {
+				t.Errorf("want 2 sorted summaries, got %v", summaries)
+			}
+
+			matches, err := backend.Search("tag", []string{"graphs"}, "alice", kp)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if len(matches) != 1 || matches[0] != "b" {
+				t.Errorf("want [b], got %v", matches)
+			}
+
+			// Other users see nothing
+			other, err := backend.List("bob", kp)
+			if err == nil && len(other) != 0 {
+				t.Errorf("bob should have no notes, got %v", other)
+			}
+		})
+	}
+}
+
+func TestBackendBlobs(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := backend.PutBlob("alice", "raw/data.bin", []byte{1, 2, 3}); err != nil {
+				t.Fatalf("PutBlob failed: %v", err)
+			}
+			data, err := backend.GetBlob("alice", "raw/data.bin")
+			if err != nil {
+				t.Fatalf("GetBlob failed: %v", err)
+			}
+			if !bytes.Equal(data, []byte{1, 2, 3}) {
+				t.Errorf("blob mismatch: got %v", data)
+			}
+
+			if _, err := backend.GetBlob("alice", "missing"); err == nil {
+				t.Error("GetBlob of a missing blob should fail")
+			}
+			if err := backend.PutBlob("alice", "../escape", nil); err == nil {
+				t.Error("PutBlob outside the user space should fail")
+			}
+		})
+	}
+}
+
+// commitCounter is a Backend defined outside the note package
+type commitCounter struct {
+	*note.MemoryBackend
+	commits int
+}
+
+func (c *commitCounter) Commit(username string, b *note.Batch) error {
+	c.commits++
+	return c.MemoryBackend.Commit(username, b)
+}
+
+func TestStoreOverCustomBackend(t *testing.T) {
+	backend := &commitCounter{MemoryBackend: note.NewMemoryBackend()}
+	store := note.NewStore(backend)
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Plugged", "kept by a custom backend")
+	if err := store.Save(n, "alice", kp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if backend.commits != 1 {
+		t.Errorf("want the save committed once, got %d commits", backend.commits)
+	}
+	if _, err := backend.Get("alice", n.Id+".pkm"); err != nil {
+		t.Errorf("note blob not in the backend: %v", err)
+	}
+	loaded, err := store.Load(n.Id, "alice", kp)
+	if err != nil || loaded.Content != n.Content {
+		t.Errorf("Load = %v, %v", loaded, err)
+	}
+}
@@ -0,0 +1,138 @@
+package note_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+// TestCompressionShrinksIndex reports sizes on a synthetic vault of 200
+// notes drawn from a 500 word vocabulary (go test -v shows the numbers)
+func TestCompressionShrinksIndex(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	vocabulary := make([]string, 500)
+	for i := range vocabulary {
+		vocabulary[i] = fmt.Sprintf("term%03d", i)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := range 200 {
+		words := make([]string, 80)
+		for j := range words {
+			words[j] = vocabulary[rng.Intn(len(vocabulary))]
+		}
+		n := note.NewNote(fmt.Sprintf("Note %d", i), strings.Join(words, " "))
+		n.AddTag(vocabulary[i%20])
+		backend.Save(n, "alice", kp)
+	}
+
+	plainIndex := totalIndexBytes(backend)
+	plainNote := totalNoteBytes(t, backend, kp)
+
+	settings, _ := backend.LoadSettings("alice", kp)
+	settings.Set("compression", note.CompressionGzip)
+	backend.SaveSettings(settings, "alice", kp)
+
+	dry, err := backend.Recompress(true, "alice", kp)
+	if err != nil || len(dry.Upgraded) == 0 {
+		t.Fatalf("dry run should find files to compress, got %v (%v)", dry, err)
+	}
+	if index := totalIndexBytes(backend); index != plainIndex {
+		t.Error("dry run must not write")
+	}
+
+	if _, err := backend.Recompress(false, "alice", kp); err != nil {
+		t.Fatalf("Recompress failed: %v", err)
+	}
+	gzipIndex := totalIndexBytes(backend)
+	gzipNote := totalNoteBytes(t, backend, kp)
+
+	t.Logf("index: %d -> %d bytes (%.0f%%)", plainIndex, gzipIndex, 100*float64(gzipIndex)/float64(plainIndex))
+	t.Logf("notes: %d -> %d bytes (%.0f%%)", plainNote, gzipNote, 100*float64(gzipNote)/float64(plainNote))
+	if gzipIndex >= plainIndex/2 {
+		t.Errorf("want index at least halved, got %d -> %d bytes", plainIndex, gzipIndex)
+	}
+
+	matches, err := backend.Search("keyword", []string{"term042"}, "alice", kp)
+	if err != nil || len(matches) == 0 {
+		t.Errorf("compressed index should still search, got %v (%v)", matches, err)
+	}
+	if summaries, err := backend.List("alice", kp); err != nil || len(summaries) != 200 {
+		t.Errorf("compressed manifest should list 200 notes, got %d (%v)", len(summaries), err)
+	}
+
+	again, _ := backend.Recompress(false, "alice", kp)
+	if len(again.Upgraded) != 0 {
+		t.Errorf("second recompress should find nothing, got %d", len(again.Upgraded))
+	}
+
+	settings.Set("compression", note.CompressionNone)
+	backend.SaveSettings(settings, "alice", kp)
+	backend.Recompress(false, "alice", kp)
+	if index := totalIndexBytes(backend); index != plainIndex {
+		t.Errorf("decompressing should restore the original size, got %d want %d", index, plainIndex)
+	}
+}
+
+func TestCompressionSkipsIncompressible(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+	settings := note.DefaultSettings()
+	settings.Compression = note.CompressionGzip
+	backend.SaveSettings(settings, "alice", kp)
+
+	n := note.NewNote("With noise", "")
+	backend.Save(n, "alice", kp)
+	noise := make([]byte, 4096)
+	rand.New(rand.NewSource(2)).Read(noise)
+	attachment, err := backend.Attach(n.Id, "noise.bin", noise, "alice", kp)
+	if err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+
+	if data, err := backend.Attachment(n.Id, "noise.bin", "alice", kp); err != nil || len(data) != len(noise) {
+		t.Fatalf("attachment roundtrip failed: %v", err)
+	}
+	report, _ := backend.Recompress(false, "alice", kp)
+	for _, name := range report.Upgraded {
+		if strings.Contains(name, attachment.Hash) {
+			t.Error("incompressible attachment should not be rewritten")
+		}
+	}
+}
+
+// totalNoteBytes sums the stored size of every note blob
+func totalNoteBytes(t *testing.T, backend *note.Store, kp *crypt.KeyProvider) int {
+	summaries, err := backend.List("alice", kp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, summary := range summaries {
+		data, _ := backend.GetBlob("alice", summary.Id+".pkm")
+		total += len(data)
+	}
+	return total
+}
+
+// totalIndexBytes sums the stored size of every index shard
+func totalIndexBytes(backend *note.Store) int {
+	names := []string{"docs.pkm"}
+	for i := range 16 {
+		names = append(names, fmt.Sprintf("terms-%02d.pkm", i))
+	}
+	for _, c := range "abcdefghijklmnopqrstuvwxyz0123456789_" {
+		names = append(names, "k-"+string(c)+".pkm", "t-"+string(c)+".pkm")
+	}
+	total := 0
+	for _, name := range names {
+		data, _ := backend.GetBlob("alice", ".index/"+name)
+		total += len(data)
+	}
+	return total
+}
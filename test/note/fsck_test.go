@@ -0,0 +1,121 @@
+package note_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func problemKinds(report *note.FsckReport) []string {
+	var kinds []string
+	for _, problem := range report.Problems {
+		kinds = append(kinds, problem.Kind)
+	}
+	slices.Sort(kinds)
+	return slices.Compact(kinds)
+}
+
+func TestFsckCleanStore(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	a := note.NewNote("A", "first")
+	b := note.NewNote("B", "second")
+	a.AddLink(b.Id)
+	b.AddLink(a.Id)
+	backend.Save(a, "alice", kp)
+	backend.Save(b, "alice", kp)
+	backend.SaveSettings(note.DefaultSettings(), "alice", kp)
+
+	report, err := backend.Fsck(false, "alice", kp)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if report.Checked != 2 || len(report.Problems) != 0 {
+		t.Errorf("want 2 clean notes, got %d checked with %v", report.Checked, report.Problems)
+	}
+}
+
+func TestFsckRepair(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			a := note.NewNote("A", "links everywhere")
+			b := note.NewNote("B", "never links back")
+			a.AddLink(b.Id)
+			a.AddLink("00000000-0000-0000-0000-000000000000")
+			backend.Save(a, "alice", kp)
+			backend.Save(b, "alice", kp)
+			gone := note.NewNote("Gone", "removed by hand")
+			backend.Save(gone, "alice", kp)
+
+			// Damage the vault behind the store's back
+			backend.PutBlob("alice", "garbage.pkm", []byte("not encrypted"))
+			encrypted, _ := kp.Encrypt([]byte("{not json"))
+			backend.PutBlob("alice", "badjson.pkm", append([]byte("PKM\n"), encrypted...))
+			misplaced, _ := json.Marshal(note.NewNote("Misplaced", ""))
+			encrypted, _ = kp.Encrypt(misplaced)
+			backend.PutBlob("alice", "misplaced.pkm", append([]byte("PKM\n"), encrypted...))
+			goneBlob, _ := backend.GetBlob("alice", gone.Id+".pkm")
+			backend.PutBlob("alice", gone.Id+".pkm", goneBlob[:len(goneBlob)-1])
+
+			report, err := backend.Fsck(false, "alice", kp)
+			if err != nil {
+				t.Fatalf("Fsck failed: %v", err)
+			}
+			want := []string{
+				note.ProblemBadJSON,
+				note.ProblemDanglingLink,
+				note.ProblemIdMismatch,
+				note.ProblemIndex,
+				note.ProblemManifest,
+				note.ProblemOneSidedLink,
+				note.ProblemUndecryptable,
+			}
+			if got := problemKinds(report); !slices.Equal(got, want) {
+				t.Errorf("want problems %v, got %v", want, got)
+			}
+			for _, problem := range report.Problems {
+				if problem.Repair != "" {
+					t.Errorf("check-only run repaired %v", problem)
+				}
+			}
+
+			report, err = backend.Fsck(true, "alice", kp)
+			if err != nil {
+				t.Fatalf("Fsck --repair failed: %v", err)
+			}
+			for _, problem := range report.Problems {
+				if problem.Repair == "" {
+					t.Errorf("problem left unrepaired: %v", problem)
+				}
+			}
+
+			report, err = backend.Fsck(false, "alice", kp)
+			if err != nil || len(report.Problems) != 0 {
+				t.Fatalf("store should be clean after repair, got %v (%v)", report.Problems, err)
+			}
+			if report.Checked != 2 {
+				t.Errorf("damaged notes should be quarantined, %d notes left", report.Checked)
+			}
+			if _, err := backend.GetBlob("alice", ".quarantine/garbage.pkm"); err != nil {
+				t.Errorf("quarantined blob missing: %v", err)
+			}
+
+			repairedA, _ := backend.Load(a.Id, "alice", kp)
+			if !slices.Equal(repairedA.LinkTargets(), []string{b.Id}) {
+				t.Errorf("dangling link should be dropped, got %v", repairedA.Links)
+			}
+			repairedB, _ := backend.Load(b.Id, "alice", kp)
+			if !repairedB.HasLink(a.Id) {
+				t.Error("missing back-link should be added")
+			}
+			if matches, _ := backend.Search("keyword", []string{"removed"}, "alice", kp); len(matches) != 0 {
+				t.Errorf("quarantined note still indexed: %v", matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package note_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestHistoryKeepsPreviousRevisions(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("Draft", "first")
+			backend.Save(n, "alice", kp)
+			n.Content = "second"
+			backend.Save(n, "alice", kp)
+			// Saving unchanged content adds no revision
+			backend.Save(n, "alice", kp)
+			n.Content = "third"
+			backend.Save(n, "alice", kp)
+
+			history, err := backend.History(n.Id, "alice", kp)
+			if err != nil {
+				t.Fatalf("History failed: %v", err)
+			}
+			if len(history) != 2 {
+				t.Fatalf("want 2 revisions, got %d", len(history))
+			}
+			if history[0].Rev != 1 || history[0].Note.Content != "first" {
+				t.Errorf("want rev 1 = first, got %d = %q", history[0].Rev, history[0].Note.Content)
+			}
+
+			if err := backend.Revert(n.Id, 1, "alice", kp); err != nil {
+				t.Fatalf("Revert failed: %v", err)
+			}
+			loaded, _ := backend.Load(n.Id, "alice", kp)
+			if loaded.Content != "first" {
+				t.Errorf("want reverted content %q, got %q", "first", loaded.Content)
+			}
+
+			// Revert archives the replaced revision, so it can be undone
+			undo, err := backend.LoadRevision(n.Id, 3, "alice", kp)
+			if err != nil || undo.Content != "third" {
+				t.Errorf("want rev 3 = third, got %v %v", undo, err)
+			}
+		})
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	settings := note.DefaultSettings()
+	settings.Set("history.keep", "2")
+	if err := backend.SaveSettings(settings, "alice", kp); err != nil {
+		t.Fatalf("SaveSettings failed: %v", err)
+	}
+
+	n := note.NewNote("Busy", "v0")
+	backend.Save(n, "alice", kp)
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		n.Content = content
+		backend.Save(n, "alice", kp)
+	}
+
+	history, _ := backend.History(n.Id, "alice", kp)
+	if len(history) != 2 {
+		t.Fatalf("want 2 revisions kept, got %d", len(history))
+	}
+	if history[0].Note.Content != "v2" || history[1].Note.Content != "v3" {
+		t.Errorf("want newest revisions v2,v3, got %q,%q", history[0].Note.Content, history[1].Note.Content)
+	}
+}
+
+func TestDeleteRemovesHistory(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Doomed", "v0")
+	backend.Save(n, "alice", kp)
+	n.Content = "v1"
+	backend.Save(n, "alice", kp)
+
+	backend.Delete(n.Id, "alice", kp)
+	history, _ := backend.History(n.Id, "alice", kp)
+	if len(history) != 0 {
+		t.Errorf("want no history after delete, got %d", len(history))
+	}
+}
+
+func TestSettings(t *testing.T) {
+	settings := note.DefaultSettings()
+
+	if err := settings.Set("history.max-age", "30d"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if settings.HistoryMaxAge != 30*24*time.Hour {
+		t.Errorf("want 30 days, got %v", settings.HistoryMaxAge)
+	}
+	if value, _ := settings.Get("history.max-age"); value != "30d" {
+		t.Errorf("want 30d, got %q", value)
+	}
+	if err := settings.Set("history.keep", "-1"); err == nil {
+		t.Error("negative history.keep should fail")
+	}
+	if err := settings.Set("bogus", "1"); err == nil {
+		t.Error("unknown key should fail")
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Duration{"0": 0, "3d": 72 * time.Hour, "2w": 14 * 24 * time.Hour, "90m": 90 * time.Minute} {
+		if age, err := note.ParseAge(value); err != nil || age != want {
+			t.Errorf("ParseAge(%q) = %v (%v), want %v", value, age, err, want)
+		}
+	}
+	for _, value := range []string{"5dw", "5wd", "5dd", "d", "-1d", ""} {
+		if _, err := note.ParseAge(value); err == nil {
+			t.Errorf("ParseAge(%q) should fail", value)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	ops := note.Diff([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+
+	var got string
+	for _, op := range ops {
+		got += string(op.Kind) + op.Text + " "
+	}
+	if got != " a -b  c +d " {
+		t.Errorf("unexpected diff: %q", got)
+	}
+}
+
+func TestRevertRepairsLinks(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	x := note.NewNote("X", "")
+	y := note.NewNote("Y", "")
+	z := note.NewNote("Z", "")
+	for _, n := range []*note.Note{x, y, z} {
+		backend.Save(n, "alice", kp)
+	}
+	backend.LinkNotes(x.Id, note.Link{Target: y.Id}, "", "alice", kp)
+	backend.LinkNotes(x.Id, note.Link{Target: z.Id, Relation: "supports"}, "", "alice", kp)
+	x, _ = backend.Load(x.Id, "alice", kp)
+	x.Content = "edited"
+	backend.Save(x, "alice", kp)
+	history, _ := backend.History(x.Id, "alice", kp)
+	linked := history[len(history)-1].Rev
+
+	// y goes away for good, the link between x and z is removed both ways
+	backend.Trash(y.Id, false, "alice", kp)
+	backend.EmptyTrash(0, "alice", kp)
+	for _, id := range []string{x.Id, z.Id} {
+		n, _ := backend.Load(id, "alice", kp)
+		n.Links = nil
+		backend.Save(n, "alice", kp)
+	}
+
+	if err := backend.Revert(x.Id, linked, "alice", kp); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	reverted, _ := backend.Load(x.Id, "alice", kp)
+	if reverted.HasLink(y.Id) || !reverted.HasLink(z.Id) {
+		t.Errorf("want the link to z only, got %v", reverted.Links)
+	}
+	back, _ := backend.Load(z.Id, "alice", kp)
+	if link, ok := back.LinkTo(x.Id); !ok || link.Relation != "supported-by" {
+		t.Errorf("z should link back to x as supported-by, got %v", back.Links)
+	}
+	report, err := backend.Fsck(false, "alice", kp)
+	if err != nil || len(report.Problems) != 0 {
+		t.Errorf("want a clean store after revert, got %v %v", report, err)
+	}
+}
+
+func TestDiffIsMinimal(t *testing.T) {
+	before := strings.Split("a b c a b b a x y z q", " ")
+	after := strings.Split("c b a b a c z y x q r", " ")
+	ops := note.Diff(before, after)
+
+	var rebuiltBefore, rebuiltAfter []string
+	kept := 0
+	for _, op := range ops {
+		if op.Kind != '+' {
+			rebuiltBefore = append(rebuiltBefore, op.Text)
+		}
+		if op.Kind != '-' {
+			rebuiltAfter = append(rebuiltAfter, op.Text)
+		}
+		if op.Kind == ' ' {
+			kept++
+		}
+	}
+	if !slices.Equal(rebuiltBefore, before) || !slices.Equal(rebuiltAfter, after) {
+		t.Fatalf("diff does not rebuild its inputs: %v", ops)
+	}
+	// Their longest common subsequence, e.g. c a b a z q, has 6 lines
+	if kept != 6 {
+		t.Errorf("want 6 kept lines, got %d: %v", kept, ops)
+	}
+}
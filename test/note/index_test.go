@@ -0,0 +1,144 @@
+package note_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestIndexSaveRewritesOnlyTouchedShards(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			apple := note.NewNote("apple", "")
+			apple.AddTag("fruit")
+			backend.Save(apple, "alice", kp)
+			backend.Save(note.NewNote("zebra", ""), "alice", kp)
+
+			before := map[string][]byte{}
+			for _, shard := range []string{"k-a.pkm", "t-f.pkm", "k-z.pkm"} {
+				before[shard], _ = backend.GetBlob("alice", ".index/"+shard)
+			}
+
+			// Only the zebra keyword shard changes
+			backend.Save(note.NewNote("zoo", ""), "alice", kp)
+			for shard, data := range before {
+				after, _ := backend.GetBlob("alice", ".index/"+shard)
+				changed := !bytes.Equal(data, after)
+				if changed != (shard == "k-z.pkm") {
+					t.Errorf("shard %s rewritten: %v", shard, changed)
+				}
+			}
+
+			matches, err := backend.Search("keyword", []string{"zoo"}, "alice", kp)
+			if err != nil || len(matches) != 1 {
+				t.Errorf("want the new note found, got %v (%v)", matches, err)
+			}
+		})
+	}
+}
+
+func TestIndexSearchReadsOnlyNeededShards(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	backend.Save(note.NewNote("apple pie", ""), "alice", kp)
+	backend.Save(note.NewNote("zebra crossing", ""), "alice", kp)
+	backend.PutBlob("alice", ".index/k-z.pkm", []byte("damaged"))
+
+	matches, err := backend.Search("keyword", []string{"apple"}, "alice", kp)
+	if err != nil || len(matches) != 1 {
+		t.Errorf("search should not touch the damaged shard, got %v (%v)", matches, err)
+	}
+	if _, err := backend.Search("keyword", []string{"zebra"}, "alice", kp); err == nil {
+		t.Error("search through a damaged shard should fail")
+	}
+
+	report, err := backend.Fsck(true, "alice", kp)
+	if err != nil {
+		t.Fatalf("Fsck failed: %v", err)
+	}
+	if kinds := problemKinds(report); len(kinds) != 1 || kinds[0] != note.ProblemIndex {
+		t.Errorf("want the damaged shard reported, got %v", report.Problems)
+	}
+	if matches, _ := backend.Search("keyword", []string{"zebra"}, "alice", kp); len(matches) != 1 {
+		t.Errorf("repair should rebuild the shard, got %v", matches)
+	}
+}
+
+func TestIndexDeleteEmptiesShards(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("quokka", "")
+	n.AddTag("quirky")
+	backend.Save(n, "alice", kp)
+	backend.Save(note.NewNote("other", ""), "alice", kp)
+	backend.Delete(n.Id, "alice", kp)
+
+	for _, shard := range []string{"k-q.pkm", "t-q.pkm"} {
+		if _, err := backend.GetBlob("alice", ".index/"+shard); err == nil {
+			t.Errorf("empty shard %s should be removed", shard)
+		}
+	}
+}
+
+func TestMigrateReplacesJSONIndex(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	backend.Save(note.NewNote("legacy", ""), "alice", kp)
+	backend.PutBlob("alice", ".index/docs.pkm", nil)
+	backend.PutBlob("alice", ".index.pkm", []byte("old json index"))
+
+	_, err := backend.Search("keyword", []string{"legacy"}, "alice", kp)
+	if err == nil || !strings.Contains(err.Error(), "migrate") {
+		t.Errorf("want a hint to migrate, got %v", err)
+	}
+
+	report, err := backend.Migrate(false, "alice", kp)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(report.Upgraded, " "), ".index.pkm") {
+		t.Errorf("want the JSON index upgraded, got %v", report.Upgraded)
+	}
+	if _, err := backend.GetBlob("alice", ".index.pkm"); err == nil {
+		t.Error("JSON index should be removed")
+	}
+	if matches, err := backend.Search("keyword", []string{"legacy"}, "alice", kp); err != nil || len(matches) != 1 {
+		t.Errorf("want search working after migrate, got %v (%v)", matches, err)
+	}
+}
+
+func TestSearchRebuildsOutdatedIndex(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("Line\nbreaks", "")
+			n.AddTag("old")
+			backend.Save(n, "alice", kp)
+
+			// A doc table of layout version 2, as an older pkm wrote it
+			encrypted, err := kp.Encrypt([]byte{0, 2, 0, 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := backend.PutBlob("alice", ".index/docs.pkm", append([]byte("PKM\n"), encrypted...)); err != nil {
+				t.Fatal(err)
+			}
+
+			ids, err := backend.Search("keyword", []string{"breaks"}, "alice", kp)
+			if err != nil || len(ids) != 1 || ids[0] != n.Id {
+				t.Errorf("want the outdated index rebuilt and the note found, got %v (%v)", ids, err)
+			}
+			if tags, err := backend.Tags("alice", kp); err != nil || len(tags["old"]) != 1 {
+				t.Errorf("want tags read from the rebuilt index, got %v (%v)", tags, err)
+			}
+		})
+	}
+}
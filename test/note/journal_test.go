@@ -0,0 +1,106 @@
+package note_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestParseJournalDate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 15, 4, 5, 0, time.Local)
+	cases := map[string]string{
+		"":           "2026-03-01",
+		"today":      "2026-03-01",
+		"yesterday":  "2026-02-28",
+		"tomorrow":   "2026-03-02",
+		"2025-12-31": "2025-12-31",
+	}
+	for value, want := range cases {
+		date, err := note.ParseJournalDate(value, now)
+		if err != nil || note.JournalAlias(date) != want {
+			t.Errorf("ParseJournalDate(%q) = %v, %v; want %s", value, date, err, want)
+		}
+	}
+	for _, value := range []string{"last week", "2026-13-01", "01/03/2026"} {
+		if _, err := note.ParseJournalDate(value, now); err == nil {
+			t.Errorf("ParseJournalDate(%q) should fail", value)
+		}
+	}
+}
+
+func TestAddJournalLinksNeighbours(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+			day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.Local) }
+
+			first := note.NewJournal(day(14), "Monday")
+			third := note.NewJournal(day(16), "Wednesday")
+			for _, entry := range []*note.Note{first, third} {
+				if err := backend.AddJournal(entry, "alice", kp); err != nil {
+					t.Fatalf("AddJournal failed: %v", err)
+				}
+			}
+			// Not a journal entry, though tagged like one
+			about := note.NewNote("About journaling", "")
+			about.AddTag(note.JournalTag)
+			backend.Save(about, "alice", kp)
+
+			second := note.NewJournal(day(15), "Tuesday")
+			if err := backend.AddJournal(second, "alice", kp); err != nil {
+				t.Fatalf("AddJournal failed: %v", err)
+			}
+			if !slices.Equal(second.LinkTargets(), []string{first.Id, third.Id}) {
+				t.Errorf("entry should link to the days around it, got %v", second.Links)
+			}
+			for _, id := range []string{first.Id, third.Id} {
+				neighbour, _ := backend.Load(id, "alice", kp)
+				if !slices.Equal(neighbour.LinkTargets(), []string{second.Id}) {
+					t.Errorf("%s should link to the new entry in place of the other day, got %v", neighbour.Alias, neighbour.Links)
+				}
+			}
+
+			if err := backend.AddJournal(note.NewJournal(day(15), ""), "alice", kp); err == nil {
+				t.Error("a second entry for a day should be rejected")
+			}
+			if err := backend.AddJournal(note.NewNote("Not dated", ""), "alice", kp); err == nil {
+				t.Error("an entry without a date alias should be rejected")
+			}
+
+			entries, err := backend.JournalEntries("alice", kp)
+			if err != nil {
+				t.Fatalf("JournalEntries failed: %v", err)
+			}
+			var dates []string
+			for _, entry := range entries {
+				dates = append(dates, entry.Alias)
+			}
+			if !slices.Equal(dates, []string{"2026-10-14", "2026-10-15", "2026-10-16"}) {
+				t.Errorf("want entries by date, got %v", dates)
+			}
+			if id, err := backend.Resolve("2026-10-15", "alice", kp); err != nil || id != second.Id {
+				t.Errorf("entry should resolve by its date, got %q (%v)", id, err)
+			}
+		})
+	}
+}
+
+func TestAddJournalLinksBackTemplateLinks(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	goals := note.NewNote("Goals", "")
+	backend.Save(goals, "alice", kp)
+
+	entry := note.NewJournal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), "")
+	entry.Links = []note.Link{{Target: goals.Id}}
+	if err := backend.AddJournal(entry, "alice", kp); err != nil {
+		t.Fatalf("AddJournal failed: %v", err)
+	}
+	back, _ := backend.Load(goals.Id, "alice", kp)
+	if !back.HasLink(entry.Id) {
+		t.Errorf("template link target should link back, got %v", back.Links)
+	}
+}
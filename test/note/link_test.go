@@ -0,0 +1,235 @@
+package note_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestLinkJSON(t *testing.T) {
+	// Notes saved before links had types hold bare ids
+	var old note.Note
+	if err := json.Unmarshal([]byte(`{"id":"a","links":["b","c"]}`), &old); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !slices.Equal(old.LinkTargets(), []string{"b", "c"}) || old.Links[0].Relation != "" {
+		t.Fatalf("want plain links to b and c, got %v", old.Links)
+	}
+
+	old.AddTypedLink(note.Link{Target: "d", Relation: "supports", Annotation: "see table 2", OneWay: true})
+	data, err := json.Marshal(old.Links)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `["b","c",{"target":"d","relation":"supports","annotation":"see table 2","oneway":true}]`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var links []note.Link
+	if err := json.Unmarshal(data, &links); err != nil || !slices.Equal(links, old.Links) {
+		t.Errorf("round trip gave %v (%v)", links, err)
+	}
+}
+
+func TestInverseRelation(t *testing.T) {
+	cases := map[string]string{
+		"supports":     "supported-by",
+		"supported-by": "supports",
+		"contradicts":  "contradicts",
+		"part-of":      "has-part",
+		"inspired-by":  "inspired-by",
+		"":             "",
+	}
+	for relation, want := range cases {
+		if got := note.InverseRelation(relation); got != want {
+			t.Errorf("InverseRelation(%q) = %q, want %q", relation, got, want)
+		}
+	}
+	if back := (note.Link{Target: "b", Relation: "supports", OneWay: true}).Inverse("a"); back.Target != "" {
+		t.Errorf("one-way links have no back-link, got %v", back)
+	}
+	n := note.NewNote("Claim", "")
+	if err := n.AddTypedLink(note.Link{Target: "b", Relation: "Not A Slug"}); err == nil || len(n.Links) != 0 {
+		t.Error("invalid relation should be rejected")
+	}
+}
+
+func TestWalkAndEdges(t *testing.T) {
+	claim := note.NewNote("Claim", "")
+	study := note.NewNote("Study", "")
+	critique := note.NewNote("Critique", "")
+	data := note.NewNote("Data", "")
+	claim.AddTypedLink(note.Link{Target: study.Id, Relation: "supported-by"})
+	claim.AddTypedLink(note.Link{Target: critique.Id, Relation: "contradicts"})
+	study.AddTypedLink(note.Link{Target: data.Id, Relation: "supported-by"})
+	study.AddTypedLink(note.Link{Target: claim.Id, Relation: "supports"})
+	notes := []*note.Note{claim, study, critique, data}
+
+	reached := func(steps []note.Step) []string {
+		var ids []string
+		for _, step := range steps {
+			ids = append(ids, step.Via.Target)
+		}
+		return ids
+	}
+	if got := reached(note.Walk(notes, claim.Id, 2, "")); !slices.Equal(got, []string{study.Id, critique.Id, data.Id}) {
+		t.Errorf("unfiltered walk reached %v", got)
+	}
+	if got := reached(note.Walk(notes, claim.Id, 1, "")); !slices.Equal(got, []string{study.Id, critique.Id}) {
+		t.Errorf("walk of depth 1 reached %v", got)
+	}
+	steps := note.Walk(notes, claim.Id, 3, "supported-by")
+	if got := reached(steps); !slices.Equal(got, []string{study.Id, data.Id}) {
+		t.Errorf("supported-by walk reached %v", got)
+	}
+	if steps[1].Depth != 2 || steps[1].Via.From != study.Id {
+		t.Errorf("want data reached from study at depth 2, got %+v", steps[1])
+	}
+
+	if edges := note.Edges(notes, "contradicts"); len(edges) != 1 || edges[0].From != claim.Id || edges[0].Target != critique.Id {
+		t.Errorf("want only claim contradicts critique, got %v", edges)
+	}
+	if edges := note.Edges(notes, ""); len(edges) != 4 {
+		t.Errorf("want every link, got %v", edges)
+	}
+}
+
+func TestTypedLinksSurviveFsck(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+			draft := note.NewNote("Draft", "")
+			paper := note.NewNote("Paper", "")
+			claim := note.NewNote("Claim", "")
+			draft.AddTypedLink(note.Link{Target: paper.Id, Relation: "derived-from", OneWay: true})
+			claim.AddTypedLink(note.Link{Target: paper.Id, Relation: "supported-by"})
+			for _, n := range []*note.Note{draft, paper, claim} {
+				if err := backend.Save(n, "alice", kp); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+			}
+			backend.SaveSettings(note.DefaultSettings(), "alice", kp)
+
+			report, err := backend.Fsck(false, "alice", kp)
+			if err != nil {
+				t.Fatalf("Fsck failed: %v", err)
+			}
+			if len(report.Problems) != 1 || report.Problems[0].Kind != note.ProblemOneSidedLink || report.Problems[0].Name != claim.Id {
+				t.Errorf("only claim's link should be one-sided, got %v", report.Problems)
+			}
+			if _, err := backend.Fsck(true, "alice", kp); err != nil {
+				t.Fatalf("Fsck --repair failed: %v", err)
+			}
+			repaired, _ := backend.Load(paper.Id, "alice", kp)
+			if back, ok := repaired.LinkTo(claim.Id); !ok || back.Relation != "supports" {
+				t.Errorf("want a supports back-link to claim, got %v", repaired.Links)
+			}
+			if repaired.HasLink(draft.Id) {
+				t.Error("one-way link should not be given a back-link")
+			}
+		})
+	}
+}
+
+func TestLinkNotes(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+			a := note.NewNote("A", "")
+			b := note.NewNote("B", "")
+			backend.Save(a, "alice", kp)
+			backend.Save(b, "alice", kp)
+
+			missing := note.Link{Target: "00000000-0000-0000-0000-000000000000"}
+			if err := backend.LinkNotes(a.Id, missing, "", "alice", kp); err == nil {
+				t.Error("link to a missing note should fail")
+			}
+			if err := backend.LinkNotes(a.Id, note.Link{Target: a.Id}, "", "alice", kp); err == nil {
+				t.Error("self-link should fail")
+			}
+			if err := backend.LinkNotes(a.Id, note.Link{Target: b.Id, OneWay: true}, "supports", "alice", kp); err == nil {
+				t.Error("one-way link with a back-link relation should fail")
+			}
+			if unchanged, _ := backend.Load(a.Id, "alice", kp); len(unchanged.Links) != 0 {
+				t.Errorf("failed links should change nothing, got %v", unchanged.Links)
+			}
+
+			if err := backend.LinkNotes(a.Id, note.Link{Target: b.Id, Relation: "part-of"}, "contains", "alice", kp); err != nil {
+				t.Fatalf("LinkNotes failed: %v", err)
+			}
+			loadedB, _ := backend.Load(b.Id, "alice", kp)
+			if back, _ := loadedB.LinkTo(a.Id); back.Relation != "contains" {
+				t.Errorf("want a contains back-link, got %v", loadedB.Links)
+			}
+			if err := backend.LinkNotes(a.Id, note.Link{Target: b.Id}, "", "alice", kp); err == nil {
+				t.Error("duplicate link should fail")
+			}
+		})
+	}
+}
+
+func TestCreateLinksBack(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	target := note.NewNote("Target", "")
+	backend.Save(target, "alice", kp)
+
+	n := note.NewNote("New", "")
+	n.Links = []note.Link{{Target: target.Id, Relation: "supports"}}
+	if err := backend.Create(n, "alice", kp); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	back, _ := backend.Load(target.Id, "alice", kp)
+	if link, ok := back.LinkTo(n.Id); !ok || link.Relation != "supported-by" {
+		t.Errorf("target should link back, got %v", back.Links)
+	}
+	if err := backend.Create(n, "alice", kp); err == nil {
+		t.Error("creating an existing note should fail")
+	}
+
+	dangling := note.NewNote("Dangling", "")
+	dangling.Links = []note.Link{{Target: "missing"}}
+	if err := backend.Create(dangling, "alice", kp); err == nil {
+		t.Error("a link to a missing note should fail")
+	}
+	if _, err := backend.Load(dangling.Id, "alice", kp); err == nil {
+		t.Error("a failed Create should save nothing")
+	}
+}
+
+func TestUnlinkNotes(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	a := note.NewNote("A", "")
+	b := note.NewNote("B", "")
+	backend.Save(a, "alice", kp)
+	backend.Save(b, "alice", kp)
+
+	// A two-way link goes with its back-link
+	backend.LinkNotes(a.Id, note.Link{Target: b.Id, Relation: "supports"}, "", "alice", kp)
+	if err := backend.UnlinkNotes(a.Id, b.Id, "alice", kp); err != nil {
+		t.Fatalf("UnlinkNotes failed: %v", err)
+	}
+	for _, id := range []string{a.Id, b.Id} {
+		if n, _ := backend.Load(id, "alice", kp); len(n.Links) != 0 {
+			t.Errorf("%s should have no links left, got %v", n.Title, n.Links)
+		}
+	}
+
+	// A one-way link leaves the target's own link alone
+	backend.LinkNotes(b.Id, note.Link{Target: a.Id, OneWay: true}, "", "alice", kp)
+	backend.LinkNotes(a.Id, note.Link{Target: b.Id, OneWay: true}, "", "alice", kp)
+	if err := backend.UnlinkNotes(a.Id, b.Id, "alice", kp); err != nil {
+		t.Fatalf("UnlinkNotes failed: %v", err)
+	}
+	if n, _ := backend.Load(b.Id, "alice", kp); !n.HasLink(a.Id) {
+		t.Errorf("b's own link to a should stay, got %v", n.Links)
+	}
+	if err := backend.UnlinkNotes(a.Id, b.Id, "alice", kp); err == nil {
+		t.Error("removing a missing link should fail")
+	}
+}
@@ -0,0 +1,142 @@
+package note_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestLockBlocksOtherWriters(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestUser(t, tmpDir, "locked", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "locked", "pass")
+
+	// Two stores stand in for two pkm processes
+	holder := note.InitStore(tmpDir)
+	waiter := note.InitStore(tmpDir)
+	waiter.SetLockTimeout(100 * time.Millisecond)
+
+	_, unlock, err := holder.Locked("locked", true)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	err = waiter.Save(note.NewNote("Blocked", "content"), "locked", kp)
+	var lockedErr *note.LockedError
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("want LockedError, got %v", err)
+	}
+	if lockedErr.Pid != os.Getpid() {
+		t.Errorf("want holder pid %d, got %d", os.Getpid(), lockedErr.Pid)
+	}
+
+	unlock()
+	if err := waiter.Save(note.NewNote("Unblocked", "content"), "locked", kp); err != nil {
+		t.Errorf("Save after unlock failed: %v", err)
+	}
+}
+
+func TestLockAllowsConcurrentReaders(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestUser(t, tmpDir, "readers", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "readers", "pass")
+
+	reader1 := note.InitStore(tmpDir)
+	reader2 := note.InitStore(tmpDir)
+	reader2.SetLockTimeout(0)
+
+	n := note.NewNote("Shared", "content")
+	reader1.Save(n, "readers", kp)
+
+	_, unlock, err := reader1.Locked("readers", false)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	defer unlock()
+
+	if _, err := reader2.Load(n.Id, "readers", kp); err != nil {
+		t.Errorf("concurrent read should succeed: %v", err)
+	}
+	if err := reader2.Save(n, "readers", kp); err == nil {
+		t.Error("write should fail while a reader holds the lock")
+	}
+}
+
+func TestLockedViewReenters(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "reentrant", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "reentrant", "pass")
+	store.SetLockTimeout(0)
+
+	locked, unlock, err := store.Locked("reentrant", true)
+	if err != nil {
+		t.Fatalf("Locked failed: %v", err)
+	}
+	defer unlock()
+
+	n := note.NewNote("Nested", "content")
+	if err := locked.Save(n, "reentrant", kp); err != nil {
+		t.Fatalf("Save under held lock failed: %v", err)
+	}
+	if _, err := locked.Load(n.Id, "reentrant", kp); err != nil {
+		t.Fatalf("Load under held lock failed: %v", err)
+	}
+}
+
+func TestSharedViewRefusesWrites(t *testing.T) {
+	store := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	reader, unlock, err := store.Locked("alice", false)
+	if err != nil {
+		t.Fatalf("Locked failed: %v", err)
+	}
+	defer unlock()
+
+	if err := reader.Save(note.NewNote("Read only", "content"), "alice", kp); err == nil {
+		t.Error("Save through a shared view should fail")
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	setupTestUser(t, tmpDir, "busy", "pass")
+	kp, _ := crypt.NewKeyProvider(tmpDir, "busy", "pass")
+
+	const writers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.Save(note.NewNote("Concurrent", "content"), "busy", kp)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent Save failed: %v", err)
+		}
+	}
+
+	summaries, err := store.List("busy", kp)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(summaries) != writers {
+		t.Errorf("want %d notes listed, got %d", writers, len(summaries))
+	}
+	matches, err := store.Search("keyword", []string{"concurrent"}, "busy", kp)
+	if err != nil || len(matches) != writers {
+		t.Errorf("want %d notes indexed, got %d (%v)", writers, len(matches), err)
+	}
+}
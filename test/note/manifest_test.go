@@ -0,0 +1,105 @@
+package note_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/crypt"
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestListReadsManifest(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			kept := note.NewNote("Kept", "stays")
+			kept.AddTag("keep")
+			gone := note.NewNote("Gone", "deleted")
+			backend.Save(kept, "alice", kp)
+			backend.Save(gone, "alice", kp)
+			backend.Trash(gone.Id, false, "alice", kp)
+
+			// List must not need to decrypt the note itself
+			backend.PutBlob("alice", kept.Id+".pkm", []byte("unreadable"))
+
+			summaries, err := backend.List("alice", kp)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(summaries) != 1 || summaries[0].Id != kept.Id || summaries[0].Tags[0] != "keep" {
+				t.Errorf("want only %s from the manifest, got %+v", kept.Id, summaries)
+			}
+		})
+	}
+}
+
+func TestManifestForLegacyVault(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	kp := memoryKeyProvider(t, "alice")
+
+	first := note.NewNote("First", "written before the manifest")
+	store.Save(first, "alice", kp)
+	if err := os.Remove(filepath.Join(tmpDir, "alice", ".manifest.pkm")); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries, err := store.List("alice", kp)
+	if err != nil || len(summaries) != 1 {
+		t.Fatalf("List without manifest should fall back to notes, got %v (%v)", summaries, err)
+	}
+
+	store.Save(note.NewNote("Second", "written after"), "alice", kp)
+	if problems, err := store.VerifyManifest("alice", kp); err != nil || len(problems) != 0 {
+		t.Errorf("first save should persist a complete manifest, got %v (%v)", problems, err)
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Original", "content")
+	backend.Save(n, "alice", kp)
+	if problems, err := backend.VerifyManifest("alice", kp); err != nil || len(problems) != 0 {
+		t.Fatalf("fresh manifest should verify, got %v (%v)", problems, err)
+	}
+
+	// Rewrite the note behind the manifest's back
+	n.Title = "Changed by hand"
+	sealNote(t, backend, n, kp)
+
+	problems, err := backend.VerifyManifest("alice", kp)
+	if err != nil || len(problems) != 1 || problems[0].Name != n.Id {
+		t.Fatalf("want drift reported for %s, got %v (%v)", n.Id, problems, err)
+	}
+
+	if _, err := backend.RebuildIndex("alice", kp); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+	if problems, _ := backend.VerifyManifest("alice", kp); len(problems) != 0 {
+		t.Errorf("rebuild should fix the manifest, got %v", problems)
+	}
+	summaries, _ := backend.List("alice", kp)
+	if summaries[0].Title != "Changed by hand" {
+		t.Errorf("want rebuilt title, got %q", summaries[0].Title)
+	}
+}
+
+// sealNote writes n as the store would, without updating index or manifest
+func sealNote(t *testing.T, backend *note.Store, n *note.Note, kp *crypt.KeyProvider) {
+	jsonBody, err := json.Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := kp.Encrypt(jsonBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := backend.PutBlob("alice", n.Id+".pkm", append([]byte("PKM\n"), encrypted...)); err != nil {
+		t.Fatal(err)
+	}
+}
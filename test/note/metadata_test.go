@@ -0,0 +1,77 @@
+package note_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestSaveMaintainsMetadata(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("Counting", "one two three")
+			backend.Save(n, "alice", kp)
+			saved, _ := backend.Load(n.Id, "alice", kp)
+			if saved.Words != 3 || saved.Edits != 0 || saved.ContentHash == "" || saved.UpdatedAt.IsZero() {
+				t.Fatalf("want metadata on a new note, got %+v", saved)
+			}
+			created := saved.UpdatedAt
+
+			// Saving the same note again is not an edit
+			backend.Save(saved, "alice", kp)
+			again, _ := backend.Load(n.Id, "alice", kp)
+			if again.Edits != 0 || !again.UpdatedAt.Equal(created) {
+				t.Errorf("unchanged save should keep metadata, got %d edits at %v", again.Edits, again.UpdatedAt)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			again.Content = "one two three four"
+			backend.Save(again, "alice", kp)
+			again.AddTag("numbers")
+			backend.Save(again, "alice", kp)
+			edited, _ := backend.Load(n.Id, "alice", kp)
+			if edited.Edits != 2 || edited.Words != 4 || !edited.UpdatedAt.After(created) {
+				t.Errorf("want 2 edits, 4 words and a later update, got %+v", edited)
+			}
+			if edited.ContentHash == saved.ContentHash {
+				t.Error("content hash should follow the content")
+			}
+
+			summaries, _ := backend.List("alice", kp)
+			if len(summaries) != 1 || !summaries[0].UpdatedAt.Equal(edited.UpdatedAt) || summaries[0].Edits != 2 {
+				t.Errorf("manifest should take the note's metadata, got %+v", summaries)
+			}
+		})
+	}
+}
+
+func TestOldNotesWithoutMetadata(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	old := &note.Note{Id: "old", Title: "Old", Content: "from before", CreatedAt: time.Now().Add(-time.Hour).UTC()}
+	sealNote(t, backend, old, kp)
+
+	loaded, err := backend.Load("old", "alice", kp)
+	if err != nil {
+		t.Fatalf("old note should load: %v", err)
+	}
+	if !loaded.UpdatedAt.IsZero() || loaded.Edits != 0 {
+		t.Errorf("old note should have no metadata, got %+v", loaded)
+	}
+
+	// Re-saving it unchanged fills in the metadata without counting an edit
+	if err := backend.Save(loaded, "alice", kp); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	resaved, _ := backend.Load("old", "alice", kp)
+	if resaved.Edits != 0 || resaved.Words != 2 || resaved.ContentHash == "" {
+		t.Errorf("want metadata filled in without an edit, got %+v", resaved)
+	}
+	if history, _ := backend.History("old", "alice", kp); len(history) != 0 {
+		t.Errorf("filling in metadata should not archive a revision, got %d", len(history))
+	}
+}
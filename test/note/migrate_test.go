@@ -0,0 +1,85 @@
+package note_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestMigrateLegacyVault(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("Old note", "from an older pkm")
+			backend.Save(n, "alice", kp)
+			sealNote(t, backend, n, kp)
+			legacy, _ := backend.GetBlob("alice", n.Id+".pkm")
+
+			report, err := backend.Migrate(true, "alice", kp)
+			if err != nil {
+				t.Fatalf("dry run failed: %v", err)
+			}
+			if !slices.Equal(report.Upgraded, []string{n.Id + ".pkm"}) || report.Backup != "" {
+				t.Errorf("dry run should list only the legacy note, got %+v", report)
+			}
+			if unchanged, _ := backend.GetBlob("alice", n.Id+".pkm"); !bytes.Equal(unchanged, legacy) {
+				t.Error("dry run must not write")
+			}
+
+			report, err = backend.Migrate(false, "alice", kp)
+			if err != nil {
+				t.Fatalf("Migrate failed: %v", err)
+			}
+			upgraded, _ := backend.GetBlob("alice", n.Id+".pkm")
+			if !bytes.HasPrefix(upgraded, []byte("PKMF")) {
+				t.Error("note should carry the versioned header")
+			}
+			if loaded, err := backend.Load(n.Id, "alice", kp); err != nil || loaded.Title != n.Title {
+				t.Errorf("migrated note should load, got %v (%v)", loaded, err)
+			}
+			original, err := backend.GetBlob("alice", report.Backup+"/"+n.Id+".pkm")
+			if err != nil || !bytes.Equal(original, legacy) {
+				t.Errorf("original should be backed up under %s (%v)", report.Backup, err)
+			}
+
+			report, _ = backend.Migrate(false, "alice", kp)
+			if len(report.Upgraded) != 0 {
+				t.Errorf("second migration should find nothing, got %v", report.Upgraded)
+			}
+		})
+	}
+}
+
+func TestVersionedHeaderChecks(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Header", "authenticated")
+	backend.Save(n, "alice", kp)
+	sealed, _ := backend.GetBlob("alice", n.Id+".pkm")
+
+	// The header is authenticated: flipping the flags breaks decryption
+	tampered := bytes.Clone(sealed)
+	tampered[7] ^= 1
+	backend.PutBlob("alice", n.Id+".pkm", tampered)
+	if _, err := backend.Load(n.Id, "alice", kp); err == nil {
+		t.Error("tampered header should not load")
+	}
+
+	future := bytes.Clone(sealed)
+	future[4] = 99
+	backend.PutBlob("alice", n.Id+".pkm", future)
+	if _, err := backend.Load(n.Id, "alice", kp); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("want unsupported version error, got %v", err)
+	}
+
+	backend.PutBlob("alice", n.Id+".pkm", sealed)
+	other := memoryKeyProvider(t, "alice")
+	if _, err := backend.Load(n.Id, "alice", other); err == nil || !strings.Contains(err.Error(), "different key") {
+		t.Errorf("want key mismatch error, got %v", err)
+	}
+}
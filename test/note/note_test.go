@@ -1,6 +1,7 @@
 package note_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
@@ -46,7 +47,7 @@ func TestAddLink(t *testing.T) {
 		t.Fatalf("AddLink failed: %v", err)
 	}
 
-	if len(n.Links) != 1 || n.Links[0] != targetID {
+	if len(n.Links) != 1 || n.Links[0].Target != targetID {
 		t.Errorf("Link not added correctly: got %v", n.Links)
 	}
 
@@ -70,7 +71,7 @@ func TestRemoveLink(t *testing.T) {
 		t.Fatalf("RemoveLink failed: %v", err)
 	}
 
-	if len(n.Links) != 1 || n.Links[0] != link2 {
+	if len(n.Links) != 1 || n.Links[0].Target != link2 {
 		t.Errorf("Link not removed correctly: got %v", n.Links)
 	}
 
@@ -92,11 +93,24 @@ func TestAddTag(t *testing.T) {
 		t.Errorf("Tags count mismatch: got %d, want 3", len(n.Tags))
 	}
 
+	if n.Tags[1] != "tag2" {
+		t.Errorf("Tags should be trimmed, got %q", n.Tags[1])
+	}
+
 	// Case insensitivity
 	err = n.AddTag("TAG1")
 	if err == nil {
 		t.Fatal("Adding duplicate tag (case insensitive) should fail")
 	}
+
+	for _, tagList := range []string{"", "tag4,", " , tag5", "tag6,tag6"} {
+		if err := n.AddTag(tagList); err == nil {
+			t.Errorf("AddTag(%q) should fail", tagList)
+		}
+	}
+	if len(n.Tags) != 3 {
+		t.Errorf("Failed AddTag should add nothing, got %v", n.Tags)
+	}
 }
 
 func TestRemoveTag(t *testing.T) {
@@ -111,4 +125,13 @@ func TestRemoveTag(t *testing.T) {
 	if len(n.Tags) != 2 {
 		t.Errorf("Tags count mismatch: got %d, want 2", len(n.Tags))
 	}
+
+	// Tags are normalized as AddTag normalizes them
+	n.AddTag("CS / Graphs")
+	if err := n.RemoveTag("tag3, CS / Graphs"); err != nil {
+		t.Fatalf("RemoveTag of a hierarchical tag failed: %v", err)
+	}
+	if !slices.Equal(n.Tags, []string{"tag2"}) {
+		t.Errorf("want [tag2] left, got %v", n.Tags)
+	}
 }
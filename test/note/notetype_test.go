@@ -0,0 +1,124 @@
+package note_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestNoteTypeRules(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			n := note.NewNote("TAOCP", "")
+			if err := n.SetType("draft"); err == nil {
+				t.Error("unknown type should be rejected")
+			}
+			n.SetType(note.TypeLiterature)
+			if err := backend.Save(n, "alice", kp); err == nil || !strings.Contains(err.Error(), "source") {
+				t.Errorf("literature note without a source should not save, got %v", err)
+			}
+			n.SetProperty(note.SourceProperty, `""`)
+			if err := backend.Save(n, "alice", kp); err == nil {
+				t.Error("empty source should not count")
+			}
+			n.SetProperty(note.SourceProperty, "Knuth, The Art of Computer Programming")
+			if err := backend.Save(n, "alice", kp); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			summaries, _ := backend.List("alice", kp)
+			if len(summaries) != 1 || summaries[0].Type != note.TypeLiterature {
+				t.Errorf("manifest should carry the type, got %+v", summaries)
+			}
+			if note.TypeOf("") != note.TypePermanent {
+				t.Error("untyped notes should be permanent")
+			}
+		})
+	}
+}
+
+func TestMergeNotes(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			into := note.NewNote("Spaced repetition", "Review at growing intervals.\n")
+			into.AddTag("learning")
+			into.SetProperty("status", "done")
+			from := note.NewNote("Idea", "Anki uses SM-2.")
+			from.SetType(note.TypeFleeting)
+			from.AddTag("anki")
+			from.SetProperty("status", "draft")
+			from.SetProperty("source", "podcast")
+			other := note.NewNote("Memory", "")
+			for _, n := range []*note.Note{into, from, other} {
+				backend.Save(n, "alice", kp)
+			}
+			// other <-> from, from <-> into
+			from.AddLink(other.Id)
+			from.AddLink(into.Id)
+			other.AddLink(from.Id)
+			into.AddLink(from.Id)
+			for _, n := range []*note.Note{into, from, other} {
+				backend.Save(n, "alice", kp)
+			}
+
+			merged, err := backend.Merge(from.Id, into.Id, "alice", kp)
+			if err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+			if merged.Content != "Review at growing intervals.\n\nAnki uses SM-2." {
+				t.Errorf("content not appended: %q", merged.Content)
+			}
+			if !slices.Equal(merged.Tags, []string{"learning", "anki"}) {
+				t.Errorf("tags not merged: %v", merged.Tags)
+			}
+			if merged.Properties["status"].Value != "done" || merged.Properties["source"].Value != "podcast" {
+				t.Errorf("properties not merged: %v", merged.Properties)
+			}
+			if !slices.Equal(merged.LinkTargets(), []string{other.Id}) {
+				t.Errorf("want only the link to other, got %v", merged.Links)
+			}
+			relinked, _ := backend.Load(other.Id, "alice", kp)
+			if !slices.Equal(relinked.LinkTargets(), []string{into.Id}) {
+				t.Errorf("back-link should point at the merged note, got %v", relinked.Links)
+			}
+			if _, err := backend.Load(from.Id, "alice", kp); err == nil {
+				t.Error("merged note should be gone")
+			}
+			trash, _ := backend.ListTrash("alice", kp)
+			if len(trash) != 1 || trash[0].Note.Id != from.Id {
+				t.Errorf("merged note should be in the trash, got %v", trash)
+			}
+			if matches, _ := backend.Search("keyword", []string{"anki"}, "alice", kp); !slices.Equal(matches, []string{into.Id}) {
+				t.Errorf("merged content should be indexed under the target, got %v", matches)
+			}
+			if _, err := backend.Merge(into.Id, into.Id, "alice", kp); err == nil {
+				t.Error("merging a note into itself should fail")
+			}
+		})
+	}
+}
+
+func TestMoveLink(t *testing.T) {
+	n := note.NewNote("Map", "")
+	for _, id := range []string{"a", "b", "c"} {
+		n.AddLink(id)
+	}
+	if err := n.MoveLink("c", 1); err != nil || !slices.Equal(n.LinkTargets(), []string{"c", "a", "b"}) {
+		t.Errorf("got %v (%v)", n.Links, err)
+	}
+	if err := n.MoveLink("c", 3); err != nil || !slices.Equal(n.LinkTargets(), []string{"a", "b", "c"}) {
+		t.Errorf("got %v (%v)", n.Links, err)
+	}
+	if err := n.MoveLink("c", 4); err == nil {
+		t.Error("position past the end should fail")
+	}
+	if err := n.MoveLink("d", 1); err == nil {
+		t.Error("missing link should fail")
+	}
+}
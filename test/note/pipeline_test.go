@@ -0,0 +1,84 @@
+package note_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestLoadAllOrderedAndParallel(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+			backend.SetParallelism(4)
+
+			var ids []string
+			for range 40 {
+				n := note.NewNote("Bulk", "decrypt me")
+				backend.Save(n, "alice", kp)
+				ids = append(ids, n.Id)
+			}
+			sort.Strings(ids)
+
+			for range 3 {
+				notes, err := backend.LoadAll(context.Background(), "alice", kp)
+				if err != nil {
+					t.Fatalf("LoadAll failed: %v", err)
+				}
+				var got []string
+				for _, n := range notes {
+					got = append(got, n.Id)
+				}
+				if !slices.Equal(got, ids) {
+					t.Fatalf("want notes in id order, got %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadAllAggregatesErrors(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	good := note.NewNote("Good", "fine")
+	backend.Save(good, "alice", kp)
+	bad := []string{"bad-1", "bad-2"}
+	for _, id := range bad {
+		backend.PutBlob("alice", id+".pkm", []byte("PKM\nnot a ciphertext at all"))
+	}
+
+	notes, err := backend.LoadAll(context.Background(), "alice", kp)
+	if len(notes) != 1 || notes[0].Id != good.Id {
+		t.Errorf("readable notes should still load, got %v", notes)
+	}
+	if err == nil {
+		t.Fatal("want per-note errors")
+	}
+	var failed []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var noteErr *note.NoteError
+		if errors.As(e, &noteErr) {
+			failed = append(failed, noteErr.Id)
+		}
+	}
+	if !slices.Equal(failed, bad) {
+		t.Errorf("want errors for %v, got %v", bad, failed)
+	}
+}
+
+func TestLoadAllCancelled(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+	backend.Save(note.NewNote("Never read", ""), "alice", kp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := backend.LoadAll(ctx, "alice", kp); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package note_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestParseValueTypes(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  note.Property
+		quote string
+	}{
+		{"draft", note.Property{Type: note.PropertyString, Value: "draft"}, "draft"},
+		{"4", note.Property{Type: note.PropertyNumber, Value: "4"}, "4"},
+		{"2.50", note.Property{Type: note.PropertyNumber, Value: "2.50"}, "2.50"},
+		{"01234", note.Property{Type: note.PropertyNumber, Value: "01234"}, "01234"},
+		{"2025-03-01", note.Property{Type: note.PropertyDate, Value: "2025-03-01"}, "2025-03-01"},
+		{"true", note.Property{Type: note.PropertyBool, Value: "true"}, "true"},
+		{`"42"`, note.Property{Type: note.PropertyString, Value: "42"}, `"42"`},
+		{"'it''s'", note.Property{Type: note.PropertyString, Value: "it's"}, `"it's"`},
+		{`[Knuth, "Tarjan, R."]`, note.Property{Type: note.PropertyList, Items: []string{"Knuth", "Tarjan, R."}}, `[Knuth, "Tarjan, R."]`},
+		{"[]", note.Property{Type: note.PropertyList}, "[]"},
+	}
+	for _, test := range tests {
+		got, err := note.ParseValue(test.text)
+		if err != nil {
+			t.Errorf("ParseValue(%q) failed: %v", test.text, err)
+			continue
+		}
+		if got.Type != test.want.Type || got.Value != test.want.Value || !slices.Equal(got.Items, test.want.Items) {
+			t.Errorf("ParseValue(%q) = %+v, want %+v", test.text, got, test.want)
+		}
+		if got.String() != test.quote {
+			t.Errorf("ParseValue(%q).String() = %q, want %q", test.text, got.String(), test.quote)
+		}
+	}
+	for _, bad := range []string{"[a, b", `"open`, `["a" b]`} {
+		if _, err := note.ParseValue(bad); err == nil {
+			t.Errorf("ParseValue(%q) should fail", bad)
+		}
+	}
+}
+
+func TestFrontmatterRoundTrip(t *testing.T) {
+	n := note.NewNote("Props", "")
+	for key, value := range map[string]string{"status": "draft", "rating": "4", "due": "2025-03-01", "authors": "[Knuth, Tarjan]", "code": `"007"`} {
+		if err := n.SetProperty(key, value); err != nil {
+			t.Fatalf("SetProperty(%s) failed: %v", key, err)
+		}
+	}
+	content := "Body text\n---\nnot frontmatter\n"
+
+	buffer := note.JoinFrontmatter(n.Properties, content)
+	properties, rest, err := note.SplitFrontmatter(buffer)
+	if err != nil {
+		t.Fatalf("SplitFrontmatter failed: %v\n%s", err, buffer)
+	}
+	if rest != content {
+		t.Errorf("content changed: %q", rest)
+	}
+	if len(properties) != len(n.Properties) {
+		t.Fatalf("want %d properties, got %v", len(n.Properties), properties)
+	}
+	for key, want := range n.Properties {
+		if got := properties[key]; got.Type != want.Type || got.Value != want.Value || !slices.Equal(got.Items, want.Items) {
+			t.Errorf("property %s: got %+v, want %+v", key, got, want)
+		}
+	}
+
+	// Content that opens with a fence survives without properties
+	fenced := "---\nnot properties\n"
+	if properties, rest, err := note.SplitFrontmatter(note.JoinFrontmatter(nil, fenced)); err != nil || properties != nil || rest != fenced {
+		t.Errorf("fenced content should round trip, got %v %q (%v)", properties, rest, err)
+	}
+}
+
+func TestSplitFrontmatterBlockListsAndErrors(t *testing.T) {
+	properties, content, err := note.SplitFrontmatter("---\n# reading list\nauthors:\n  - Knuth\n  - Tarjan\n\nread: false\n---\nbody")
+	if err != nil {
+		t.Fatalf("SplitFrontmatter failed: %v", err)
+	}
+	if content != "body" || !slices.Equal(properties["authors"].Items, []string{"Knuth", "Tarjan"}) || properties["read"].Type != note.PropertyBool {
+		t.Errorf("got %v %q", properties, content)
+	}
+
+	for _, bad := range []string{
+		"---\nstatus: draft\n",
+		"---\nstatus: a\nstatus: b\n---\n",
+		"---\n- orphan\n---\n",
+		"---\nStatus: draft\n---\n",
+		"---\nno colon\n---\n",
+	} {
+		if _, _, err := note.SplitFrontmatter(bad); err == nil {
+			t.Errorf("SplitFrontmatter(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSearchByProperty(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			draft := note.NewNote("Draft", "")
+			draft.SetProperty("status", "Draft")
+			draft.SetProperty("authors", "[Knuth, Tarjan]")
+			backend.Save(draft, "alice", kp)
+			done := note.NewNote("Done", "")
+			done.SetProperty("status", "done")
+			done.SetProperty("version", "1.10")
+			done.SetProperty("scores", "[2.50, 07]")
+			backend.Save(done, "alice", kp)
+
+			for query, want := range map[string][]string{
+				"status":               {draft.Id, done.Id},
+				"status=draft":         {draft.Id},
+				"authors=tarjan":       {draft.Id},
+				"status=done":          {done.Id},
+				"rating=4":             nil,
+				"version=1.1":          {done.Id},
+				"version=1.10":         {done.Id},
+				"scores=2.5":           {done.Id},
+				"scores=7":             {done.Id},
+				"status=draft authors": {draft.Id},
+			} {
+				got, err := backend.Search("prop", strings.Fields(query), "alice", kp)
+				if err != nil {
+					t.Fatalf("Search(%s) failed: %v", query, err)
+				}
+				slices.Sort(got)
+				slices.Sort(want)
+				if !slices.Equal(got, want) && !(len(got) == 0 && len(want) == 0) {
+					t.Errorf("Search(%s) = %v, want %v", query, got, want)
+				}
+			}
+
+			// Removing a property drops it from the index
+			draft.RemoveProperty("status")
+			backend.Save(draft, "alice", kp)
+			if got, _ := backend.Search("prop", []string{"status=draft"}, "alice", kp); len(got) != 0 {
+				t.Errorf("removed property still found: %v", got)
+			}
+			summaries, _ := backend.List("alice", kp)
+			for _, summary := range summaries {
+				if summary.Id == done.Id && summary.Properties["status"].Value != "done" {
+					t.Errorf("manifest should carry properties, got %+v", summary)
+				}
+			}
+		})
+	}
+}
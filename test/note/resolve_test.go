@@ -0,0 +1,137 @@
+package note_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestResolvePrefix(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			graphs := &note.Note{Id: "550e8400-aaaa", Title: "Graph Theory"}
+			trees := &note.Note{Id: "550e9100-bbbb", Title: "Trees"}
+			backend.Save(graphs, "alice", kp)
+			backend.Save(trees, "alice", kp)
+
+			if id, err := backend.Resolve("550e8", "alice", kp); err != nil || id != graphs.Id {
+				t.Errorf("want unique prefix resolved, got %q (%v)", id, err)
+			}
+			if id, err := backend.Resolve(trees.Id, "alice", kp); err != nil || id != trees.Id {
+				t.Errorf("want full id resolved, got %q (%v)", id, err)
+			}
+
+			_, err := backend.Resolve("550e", "alice", kp)
+			var ambiguous *note.AmbiguousError
+			if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+				t.Fatalf("want ambiguous prefix, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "Graph Theory") || !strings.Contains(err.Error(), trees.Id) {
+				t.Errorf("error should list the candidates, got %q", err)
+			}
+
+			if _, err := backend.Resolve("550", "alice", kp); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("prefixes shorter than %d should not match, got %v", note.MinPrefix, err)
+			}
+			if _, err := backend.Resolve("ffff", "alice", kp); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("want not found, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveAliasShadowingPrefix(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	x := &note.Note{Id: "cafe1234-aaaa", Title: "X"}
+	y := &note.Note{Id: "0000abcd-bbbb", Title: "Y"}
+	y.SetAlias("cafe1234")
+	backend.Save(x, "alice", kp)
+	backend.Save(y, "alice", kp)
+
+	_, err := backend.Resolve("cafe1234", "alice", kp)
+	var ambiguous *note.AmbiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("want alias and prefix reported ambiguous, got %v", err)
+	}
+	if id, err := backend.Resolve("cafe1234-a", "alice", kp); err != nil || id != x.Id {
+		t.Errorf("want longer prefix resolved, got %q (%v)", id, err)
+	}
+}
+
+func TestResolveTitle(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	graphs := note.NewNote("Graph Theory", "")
+	advanced := note.NewNote("Advanced Graph Theory", "")
+	trees := note.NewNote("Binary Trees", "")
+	for _, n := range []*note.Note{graphs, advanced, trees} {
+		backend.Save(n, "alice", kp)
+	}
+
+	cases := []struct {
+		title string
+		want  string
+	}{
+		{"graph theory", graphs.Id},     // exact wins over containing
+		{"binary", trees.Id},            // substring
+		{"treesbinary", ""},             // characters out of order
+		{"bintre", trees.Id},            // characters in order
+		{"Advanced Graph", advanced.Id}, // substring
+	}
+	for _, c := range cases {
+		id, err := backend.ResolveTitle(c.title, "alice", kp)
+		if c.want == "" {
+			if err == nil {
+				t.Errorf("%q: want no match, got %q", c.title, id)
+			}
+			continue
+		}
+		if err != nil || id != c.want {
+			t.Errorf("%q: want %s, got %q (%v)", c.title, c.want, id, err)
+		}
+	}
+
+	var ambiguous *note.AmbiguousError
+	if _, err := backend.ResolveTitle("graph", "alice", kp); !errors.As(err, &ambiguous) {
+		t.Errorf("want two notes containing graph to be ambiguous, got %v", err)
+	}
+}
+
+func TestResolveTrashed(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			graphs := &note.Note{Id: "550e8400-aaaa", Title: "Graph Theory"}
+			trees := &note.Note{Id: "550e9100-bbbb", Title: "Trees"}
+			live := &note.Note{Id: "550e7700-cccc", Title: "Graph Paper"}
+			for _, n := range []*note.Note{graphs, trees, live} {
+				backend.Save(n, "alice", kp)
+			}
+			backend.Trash(graphs.Id, false, "alice", kp)
+			backend.Trash(trees.Id, false, "alice", kp)
+
+			if id, err := backend.ResolveTrashed("550e8", "alice", kp); err != nil || id != graphs.Id {
+				t.Errorf("want trashed prefix resolved, got %q (%v)", id, err)
+			}
+			var ambiguous *note.AmbiguousError
+			if _, err := backend.ResolveTrashed("550e", "alice", kp); !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+				t.Errorf("want ambiguous among trashed notes only, got %v", err)
+			}
+			if _, err := backend.ResolveTrashed("550e7", "alice", kp); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("live notes should not resolve, got %v", err)
+			}
+			if id, err := backend.ResolveTrashedTitle("graph", "alice", kp); err != nil || id != graphs.Id {
+				t.Errorf("want trashed title resolved, got %q (%v)", id, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package note_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -156,7 +157,7 @@ func TestDelete(t *testing.T) {
 		t.Fatal("Note file not found before delete")
 	}
 
-	err := store.Delete(n.Id, username)
+	err := store.Delete(n.Id, username, kp)
 	if err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
@@ -205,11 +206,11 @@ func TestSaveEncryptedData(t *testing.T) {
 		t.Fatalf("ReadFile failed: %v", err)
 	}
 
-	if string(fileContent[:4]) != "PKM\n" {
-		t.Fatal("Magic header missing")
+	if string(fileContent[:4]) != "PKMF" || fileContent[4] != 1 {
+		t.Fatal("Versioned header missing")
 	}
 
-	encryptedPart := fileContent[4:]
+	encryptedPart := fileContent[16:]
 	var testNote note.Note
 	err = json.Unmarshal(encryptedPart, &testNote)
 	if err == nil {
@@ -353,23 +354,102 @@ func TestIndexUpdates(t *testing.T) {
 	note1 := &note.Note{Id: "one", Title: "hello world", Tags: []string{"test"}}
 	store.Save(note1, username, kp)
 
-	// Check index created
-	indexPath := filepath.Join(tmpDir, username, ".index.pkm")
-	indexData, _ := os.ReadFile(indexPath)
+	// Check the index shards are created and encrypted
+	for _, shard := range []string{"docs.pkm", "t-t.pkm", "k-w.pkm"} {
+		shardData, err := os.ReadFile(filepath.Join(tmpDir, username, ".index", shard))
+		if err != nil {
+			t.Fatalf("index shard %s not written: %v", shard, err)
+		}
+		if _, err := kp.DecryptWithAAD(shardData[16:], shardData[:16]); err != nil { // header is authenticated
+			t.Fatalf("Failed to decrypt index shard %s: %v", shard, err)
+		}
+		if bytes.Contains(shardData, []byte("world")) {
+			t.Errorf("index shard %s holds plaintext", shard)
+		}
+	}
 
-	// Decrypt the index
-	decryptedData, err := kp.Decrypt(indexData[4:]) // skip PKM header
-	if err != nil {
-		t.Fatalf("Failed to decrypt index: %v", err)
+	if matches, _ := store.Search("tag", []string{"test"}, username, kp); len(matches) != 1 || matches[0] != "one" {
+		t.Errorf("tag index not updated: %v", matches)
+	}
+	if matches, _ := store.Search("keyword", []string{"world"}, username, kp); len(matches) != 1 {
+		t.Errorf("keyword index not updated: %v", matches)
+	}
+}
+
+func TestIndexDropsStaleTermsOnEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	username := "staletest"
+	password := "pass"
+	setupTestUser(t, tmpDir, username, password)
+	kp, _ := crypt.NewKeyProvider(tmpDir, username, password)
+
+	n := &note.Note{Id: "one", Title: "graphs", Content: "dijkstra", Tags: []string{"algo", "old"}}
+	store.Save(n, username, kp)
+
+	n.Content = "bellman ford"
+	n.RemoveTag("old")
+	store.Save(n, username, kp)
+
+	if matches, _ := store.Search("keyword", []string{"dijkstra"}, username, kp); len(matches) != 0 {
+		t.Errorf("removed word still matches: %v", matches)
+	}
+	if matches, _ := store.Search("tag", []string{"old"}, username, kp); len(matches) != 0 {
+		t.Errorf("removed tag still matches: %v", matches)
+	}
+	if matches, _ := store.Search("keyword", []string{"bellman"}, username, kp); len(matches) != 1 {
+		t.Errorf("new word should match, got %v", matches)
+	}
+}
+
+func TestIndexDropsDeletedNotes(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	username := "deletetest"
+	password := "pass"
+	setupTestUser(t, tmpDir, username, password)
+	kp, _ := crypt.NewKeyProvider(tmpDir, username, password)
+
+	store.Save(&note.Note{Id: "gone", Title: "ephemeral", Tags: []string{"tmp"}}, username, kp)
+	store.Save(&note.Note{Id: "kept", Title: "ephemeral too"}, username, kp)
+
+	if err := store.Delete("gone", username, kp); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if matches, _ := store.Search("tag", []string{"tmp"}, username, kp); len(matches) != 0 {
+		t.Errorf("deleted note still matches by tag: %v", matches)
 	}
 
-	var idx note.Index
-	json.Unmarshal(decryptedData, &idx)
+	// Removed behind the index's back
+	os.Remove(filepath.Join(tmpDir, username, "kept.pkm"))
+	if matches, _ := store.Search("keyword", []string{"ephemeral"}, username, kp); len(matches) != 0 {
+		t.Errorf("missing note returned by search: %v", matches)
+	}
+}
 
-	if len(idx.TagIndex["test"]) != 1 || idx.TagIndex["test"][0] != "one" {
-		t.Error("tag index not updated")
+func TestRebuildIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := note.InitStore(tmpDir)
+	username := "rebuildtest"
+	password := "pass"
+	setupTestUser(t, tmpDir, username, password)
+	kp, _ := crypt.NewKeyProvider(tmpDir, username, password)
+
+	store.Save(&note.Note{Id: "a", Title: "alpha", Tags: []string{"greek"}}, username, kp)
+	store.Save(&note.Note{Id: "b", Title: "beta", Tags: []string{"greek"}}, username, kp)
+	os.RemoveAll(filepath.Join(tmpDir, username, ".index"))
+	if _, err := store.Search("tag", []string{"greek"}, username, kp); err == nil {
+		t.Error("search without an index should ask for a rebuild")
+	}
+
+	count, err := store.RebuildIndex(username, kp)
+	if err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("want 2 indexed notes, got %d", count)
 	}
-	if len(idx.KeywordIndex["world"]) != 1 {
-		t.Error("keyword index not updated")
+	if matches, _ := store.Search("tag", []string{"greek"}, username, kp); len(matches) != 2 {
+		t.Errorf("want 2 matches after rebuild, got %v", matches)
 	}
 }
@@ -0,0 +1,235 @@
+package note_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestTagsAndRename(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			graphs := note.NewNote("Graphs", "")
+			graphs.AddTag("algoritms,graphs")
+			sorting := note.NewNote("Sorting", "")
+			sorting.AddTag("algorithms,sorting")
+			trees := note.NewNote("Trees", "")
+			trees.AddTag("graphs,ds")
+			for _, n := range []*note.Note{graphs, sorting, trees} {
+				backend.Save(n, "alice", kp)
+			}
+
+			tags, err := backend.Tags("alice", kp)
+			if err != nil {
+				t.Fatalf("Tags failed: %v", err)
+			}
+			if len(tags) != 5 || len(tags["graphs"]) != 2 || len(tags["algoritms"]) != 1 {
+				t.Errorf("unexpected tag counts %v", tags)
+			}
+
+			changed, err := backend.RenameTags([]string{"algoritms"}, "Algorithms", "alice", kp)
+			if err != nil || changed != 1 {
+				t.Fatalf("RenameTags = %d, %v", changed, err)
+			}
+			loaded, _ := backend.Load(graphs.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"algorithms", "graphs"}) {
+				t.Errorf("rename should keep the tag's place, got %v", loaded.Tags)
+			}
+			if found, _ := backend.Search("tag", []string{"algorithms"}, "alice", kp); len(found) != 2 {
+				t.Errorf("index should follow the rename, got %v", found)
+			}
+			if found, _ := backend.Search("tag", []string{"algoritms"}, "alice", kp); len(found) != 0 {
+				t.Errorf("old tag should be gone, got %v", found)
+			}
+
+			// Merging onto a tag a note already has leaves it once
+			changed, err = backend.RenameTags([]string{"graphs", "ds"}, "algorithms", "alice", kp)
+			if err != nil || changed != 2 {
+				t.Fatalf("merge = %d, %v", changed, err)
+			}
+			loaded, _ = backend.Load(trees.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"algorithms"}) {
+				t.Errorf("want merged tags, got %v", loaded.Tags)
+			}
+			loaded, _ = backend.Load(graphs.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"algorithms"}) {
+				t.Errorf("want one algorithms tag, got %v", loaded.Tags)
+			}
+
+			if _, err := backend.RenameTags([]string{"sorting", "missing"}, "x", "alice", kp); err == nil {
+				t.Error("renaming an unused tag should fail")
+			}
+			loaded, _ = backend.Load(sorting.Id, "alice", kp)
+			if !slices.Contains(loaded.Tags, "sorting") {
+				t.Error("a failed rename should change nothing")
+			}
+			if _, err := backend.RenameTags([]string{"sorting"}, " ", "alice", kp); err == nil {
+				t.Error("renaming to an empty tag should fail")
+			}
+		})
+	}
+}
+
+func TestHierarchicalTags(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			if tag, err := note.NormalizeTag(" CS / Graphs "); err != nil || tag != "cs/graphs" {
+				t.Errorf("NormalizeTag = %q, %v", tag, err)
+			}
+			for _, tag := range []string{"cs/", "/cs", "cs//graphs"} {
+				if _, err := note.NormalizeTag(tag); err == nil {
+					t.Errorf("NormalizeTag(%q) should fail", tag)
+				}
+			}
+
+			graphs := note.NewNote("Graphs", "")
+			graphs.AddTag("cs/algorithms/graphs")
+			cs := note.NewNote("CS", "")
+			cs.AddTag("cs")
+			theory := note.NewNote("Theory", "")
+			theory.AddTag("cs-theory")
+			for _, n := range []*note.Note{graphs, cs, theory} {
+				backend.Save(n, "alice", kp)
+			}
+
+			found, _ := backend.Search("tag", []string{"cs"}, "alice", kp)
+			slices.Sort(found)
+			want := []string{graphs.Id, cs.Id}
+			slices.Sort(want)
+			if !slices.Equal(found, want) {
+				t.Errorf("cs should match its subtree only, got %v", found)
+			}
+			if found, _ := backend.Search("tag-exact", []string{"cs"}, "alice", kp); !slices.Equal(found, []string{cs.Id}) {
+				t.Errorf("exact search should skip descendants, got %v", found)
+			}
+			if found, _ := backend.Search("tag", []string{"cs/algorithms"}, "alice", kp); !slices.Equal(found, []string{graphs.Id}) {
+				t.Errorf("inner level should match, got %v", found)
+			}
+
+			changed, err := backend.RenameTags([]string{"cs"}, "comp", "alice", kp)
+			if err != nil || changed != 2 {
+				t.Fatalf("RenameTags = %d, %v", changed, err)
+			}
+			loaded, _ := backend.Load(graphs.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"comp/algorithms/graphs"}) {
+				t.Errorf("subtree should move with its parent, got %v", loaded.Tags)
+			}
+			loaded, _ = backend.Load(theory.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"cs-theory"}) {
+				t.Errorf("sibling with a common prefix should stay, got %v", loaded.Tags)
+			}
+		})
+	}
+}
+
+func TestTagAliases(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			old := note.NewNote("Perceptrons", "")
+			old.AddTag("ml/deep,neural")
+			backend.Save(old, "alice", kp)
+
+			changed, err := backend.AddTagAlias("ML", "machine-learning", "alice", kp)
+			if err != nil || changed != 1 {
+				t.Fatalf("AddTagAlias = %d, %v", changed, err)
+			}
+			loaded, _ := backend.Load(old.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"machine-learning/deep", "neural"}) {
+				t.Errorf("existing notes should be retagged, got %v", loaded.Tags)
+			}
+
+			// Saves canonicalize, searches match either name
+			n := note.NewNote("Transformers", "")
+			n.AddTag("ml,machine-learning")
+			backend.Save(n, "alice", kp)
+			if !slices.Equal(n.Tags, []string{"machine-learning"}) {
+				t.Errorf("save should store the canonical tag once, got %v", n.Tags)
+			}
+			for _, query := range []string{"ml", "machine-learning"} {
+				if found, _ := backend.Search("tag", []string{query}, "alice", kp); len(found) != 2 {
+					t.Errorf("search %q should find both notes, got %v", query, found)
+				}
+			}
+			if found, _ := backend.Search("tag-exact", []string{"ml/deep"}, "alice", kp); !slices.Equal(found, []string{old.Id}) {
+				t.Errorf("aliases should cover the tags below them, got %v", found)
+			}
+
+			// Chains resolve to the canonical tag, cycles are refused
+			if _, err := backend.AddTagAlias("machinelearning", "ml", "alice", kp); err != nil {
+				t.Fatalf("AddTagAlias failed: %v", err)
+			}
+			if _, err := backend.AddTagAlias("machine-learning", "ml/deep", "alice", kp); err == nil {
+				t.Error("a tag cannot be an alias of a tag below it")
+			}
+			synonyms, _ := backend.TagAliases("alice", kp)
+			if synonyms["machinelearning"] != "machine-learning" || synonyms["ml"] != "machine-learning" {
+				t.Errorf("unexpected alias table %v", synonyms)
+			}
+
+			// Renaming the canonical tag carries its aliases along
+			if _, err := backend.RenameTags([]string{"machine-learning"}, "ai/ml", "alice", kp); err != nil {
+				t.Fatalf("RenameTags failed: %v", err)
+			}
+			synonyms, _ = backend.TagAliases("alice", kp)
+			if synonyms["ml"] != "ai/ml" {
+				t.Errorf("alias should follow the rename, got %v", synonyms)
+			}
+
+			// An alias renames its canonical tag
+			changed, err = backend.RenameTags([]string{"machinelearning"}, "ai/learning", "alice", kp)
+			if err != nil || changed != 2 {
+				t.Fatalf("RenameTags through an alias = %d, %v", changed, err)
+			}
+			loaded, _ = backend.Load(old.Id, "alice", kp)
+			if !slices.Equal(loaded.Tags, []string{"ai/learning/deep", "neural"}) {
+				t.Errorf("want the canonical tag renamed, got %v", loaded.Tags)
+			}
+
+			if err := backend.RemoveTagAlias("ml", "alice", kp); err != nil {
+				t.Fatalf("RemoveTagAlias failed: %v", err)
+			}
+			if err := backend.RemoveTagAlias("ml", "alice", kp); err == nil {
+				t.Error("removing an unknown alias should fail")
+			}
+		})
+	}
+}
+
+func TestSuggestTagAliases(t *testing.T) {
+	tags := map[string][]string{
+		"machine-learning": {"1", "2", "3"},
+		"machinelearning":  {"4"},
+		"ml":               {"5"},
+		"python":           {"1", "5"},
+		"algoritms":        {"6"},
+		"algorithms":       {"7", "8"},
+		"go":               {"9"},
+		"c":                {"10"},
+		// Used together, so not synonyms
+		"graph":  {"11"},
+		"graphs": {"11"},
+		// Parent and child
+		"cs":    {"12"},
+		"cs/ai": {"13"},
+	}
+	suggestions := note.SuggestTagAliases(tags)
+	want := []note.TagSuggestion{
+		{Canonical: "algorithms", Aliases: []string{"algoritms"}},
+		{Canonical: "machine-learning", Aliases: []string{"machinelearning", "ml"}},
+	}
+	if len(suggestions) != len(want) {
+		t.Fatalf("want %v, got %v", want, suggestions)
+	}
+	for i := range want {
+		if suggestions[i].Canonical != want[i].Canonical || !slices.Equal(suggestions[i].Aliases, want[i].Aliases) {
+			t.Errorf("suggestion %d: want %v, got %v", i, want[i], suggestions[i])
+		}
+	}
+}
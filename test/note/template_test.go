@@ -0,0 +1,120 @@
+package note_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+const meetingTemplate = `---
+type: fleeting
+tags: [meeting, work]
+links: standups
+attendees: [{{prompt:Attendees}}]
+---
+# {{title}} on {{date}} at {{time}}
+
+Taken by {{user}} with {{prompt:Attendees}}.
+`
+
+func TestTemplateExpand(t *testing.T) {
+	template := &note.Template{Name: "meeting", Text: meetingTemplate}
+	now := time.Date(2025, 3, 1, 9, 30, 0, 0, time.Local)
+
+	var asked []string
+	expansion, err := template.Expand(note.TemplateVars{Title: "Standup", User: "alice", Now: now}, func(question string) (string, error) {
+		asked = append(asked, question)
+		return "Bob, Carol", nil
+	})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if !slices.Equal(asked, []string{"Attendees"}) {
+		t.Errorf("each prompt should be asked once, got %v", asked)
+	}
+	if expansion.Content != "# Standup on 2025-03-01 at 09:30\n\nTaken by alice with Bob, Carol.\n" {
+		t.Errorf("unexpected content %q", expansion.Content)
+	}
+	if expansion.Type != note.TypeFleeting || !slices.Equal(expansion.Tags, []string{"meeting", "work"}) || !slices.Equal(expansion.Links, []string{"standups"}) {
+		t.Errorf("unexpected type, tags or links: %+v", expansion)
+	}
+	if attendees := expansion.Properties["attendees"]; !slices.Equal(attendees.Items, []string{"Bob", "Carol"}) || len(expansion.Properties) != 1 {
+		t.Errorf("want only the attendees property, got %v", expansion.Properties)
+	}
+
+	stop := errors.New("cancelled")
+	if _, err := template.Expand(note.TemplateVars{}, func(string) (string, error) { return "", stop }); !errors.Is(err, stop) {
+		t.Errorf("prompt errors should stop the expansion, got %v", err)
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	if err := note.ValidateTemplate(meetingTemplate); err != nil {
+		t.Errorf("valid template rejected: %v", err)
+	}
+	for _, bad := range []string{
+		"{{weekday}}",
+		"{{prompt:}}",
+		"{{title",
+		"---\ntype: draft\n---\n",
+		"---\ntags: [a\n---\n",
+	} {
+		if err := note.ValidateTemplate(bad); err == nil {
+			t.Errorf("ValidateTemplate(%q) should fail", bad)
+		}
+	}
+}
+
+func TestTemplateStorage(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			template := &note.Template{Name: "meeting", Text: meetingTemplate}
+			if err := backend.SaveTemplate(template, "alice", kp); err != nil {
+				t.Fatalf("SaveTemplate failed: %v", err)
+			}
+			backend.SaveTemplate(&note.Template{Name: "daily", Text: "# {{date}}\n"}, "alice", kp)
+			if err := backend.SaveTemplate(&note.Template{Name: "Bad Name", Text: ""}, "alice", kp); err == nil {
+				t.Error("invalid name should be rejected")
+			}
+			if err := backend.SaveTemplate(&note.Template{Name: "broken", Text: "{{nope}}"}, "alice", kp); err == nil {
+				t.Error("invalid template should be rejected")
+			}
+
+			raw, err := backend.GetBlob("alice", ".templates/meeting.pkm")
+			if err != nil || bytes.Contains(raw, []byte("attendees")) {
+				t.Errorf("template should be stored encrypted (%v)", err)
+			}
+			loaded, err := backend.LoadTemplate("meeting", "alice", kp)
+			if err != nil || loaded.Text != meetingTemplate || loaded.CreatedAt.IsZero() {
+				t.Errorf("want the template back, got %+v (%v)", loaded, err)
+			}
+
+			templates, _ := backend.ListTemplates("alice", kp)
+			if len(templates) != 2 || templates[0].Name != "daily" || templates[1].Name != "meeting" {
+				t.Errorf("want templates by name, got %+v", templates)
+			}
+			if err := backend.DeleteTemplate("daily", "alice"); err != nil {
+				t.Fatalf("DeleteTemplate failed: %v", err)
+			}
+			if _, err := backend.LoadTemplate("daily", "alice", kp); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("deleted template should be gone, got %v", err)
+			}
+			if err := backend.DeleteTemplate("daily", "alice"); err == nil {
+				t.Error("deleting a missing template should fail")
+			}
+			if ids, _ := backend.List("alice", kp); len(ids) != 0 {
+				t.Errorf("templates are not notes, got %v", ids)
+			}
+			if report, err := backend.Fsck(false, "alice", kp); err != nil || len(report.Problems) != 0 {
+				t.Errorf("fsck should accept templates, got %v (%v)", report, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package note_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sahay-shashank/personal-knowledge-manager/internal/note"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+
+			target := note.NewNote("Target", "trash me")
+			target.AddTag("doomed")
+			source := note.NewNote("Source", "links to target")
+			source.AddLink(target.Id)
+			target.AddLink(source.Id)
+			backend.Save(target, "alice", kp)
+			backend.Save(source, "alice", kp)
+
+			inbound, err := backend.Trash(target.Id, false, "alice", kp)
+			if err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if len(inbound) != 1 || inbound[0] != source.Id {
+				t.Errorf("want inbound [%s], got %v", source.Id, inbound)
+			}
+			if _, err := backend.Load(target.Id, "alice", kp); err == nil {
+				t.Error("trashed note should not load")
+			}
+			if matches, _ := backend.Search("tag", []string{"doomed"}, "alice", kp); len(matches) != 0 {
+				t.Errorf("trashed note still searchable: %v", matches)
+			}
+
+			entries, err := backend.ListTrash("alice", kp)
+			if err != nil || len(entries) != 1 || entries[0].Note.Id != target.Id {
+				t.Fatalf("want 1 trash entry, got %v (%v)", entries, err)
+			}
+
+			unlinked, _ := backend.Load(source.Id, "alice", kp)
+			if unlinked.HasLink(target.Id) {
+				t.Error("trash should remove the links to the trashed note")
+			}
+
+			if err := backend.Restore(target.Id, "alice", kp); err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if matches, _ := backend.Search("tag", []string{"doomed"}, "alice", kp); len(matches) != 1 {
+				t.Errorf("restored note not re-indexed: %v", matches)
+			}
+			relinked, _ := backend.Load(source.Id, "alice", kp)
+			if !relinked.HasLink(target.Id) {
+				t.Error("restore should restore the back-link")
+			}
+			if entries, _ := backend.ListTrash("alice", kp); len(entries) != 0 {
+				t.Errorf("trash should be empty after restore, got %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	backend := note.NewMemoryStore()
+	kp := memoryKeyProvider(t, "alice")
+
+	n := note.NewNote("Old news", "v0")
+	backend.Save(n, "alice", kp)
+	backend.Trash(n.Id, false, "alice", kp)
+
+	purged, err := backend.EmptyTrash(24*time.Hour, "alice", kp)
+	if err != nil || purged != 0 {
+		t.Errorf("fresh deletion should survive --older-than, purged %d (%v)", purged, err)
+	}
+	purged, err = backend.EmptyTrash(0, "alice", kp)
+	if err != nil || purged != 1 {
+		t.Errorf("want 1 purged, got %d (%v)", purged, err)
+	}
+	if err := backend.Restore(n.Id, "alice", kp); err == nil {
+		t.Error("purged note should not be restorable")
+	}
+}
+
+func TestTrashLinkedNote(t *testing.T) {
+	for name, backend := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			kp := memoryKeyProvider(t, "alice")
+			claim := note.NewNote("Claim", "")
+			study := note.NewNote("Study", "")
+			draft := note.NewNote("Draft", "")
+			backend.Save(claim, "alice", kp)
+			backend.Save(study, "alice", kp)
+			backend.Save(draft, "alice", kp)
+			if err := backend.LinkNotes(study.Id, note.Link{Target: claim.Id, Relation: "supports", Annotation: "n=200"}, "", "alice", kp); err != nil {
+				t.Fatalf("LinkNotes failed: %v", err)
+			}
+			if err := backend.LinkNotes(draft.Id, note.Link{Target: claim.Id, Relation: "derived-from", OneWay: true}, "", "alice", kp); err != nil {
+				t.Fatalf("LinkNotes failed: %v", err)
+			}
+
+			inbound, err := backend.Trash(claim.Id, true, "alice", kp)
+			if err == nil || len(inbound) != 2 {
+				t.Fatalf("linked note should not be trashed, got %v (%v)", inbound, err)
+			}
+			if _, err := backend.Load(claim.Id, "alice", kp); err != nil {
+				t.Errorf("refused note should still load: %v", err)
+			}
+
+			if _, err := backend.Trash(claim.Id, false, "alice", kp); err != nil {
+				t.Fatalf("Trash failed: %v", err)
+			}
+			if report, _ := backend.Fsck(false, "alice", kp); len(report.Problems) != 0 {
+				t.Errorf("trash should leave no dangling links, got %v", report.Problems)
+			}
+
+			if err := backend.Restore(claim.Id, "alice", kp); err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			relinked, _ := backend.Load(study.Id, "alice", kp)
+			if link, _ := relinked.LinkTo(claim.Id); link.Relation != "supports" || link.Annotation != "n=200" {
+				t.Errorf("restore should put back the typed link, got %v", relinked.Links)
+			}
+			relinked, _ = backend.Load(draft.Id, "alice", kp)
+			if link, _ := relinked.LinkTo(claim.Id); !link.OneWay {
+				t.Errorf("restore should put back the one-way link, got %v", relinked.Links)
+			}
+			restored, _ := backend.Load(claim.Id, "alice", kp)
+			if !slices.Equal(restored.LinkTargets(), []string{study.Id}) {
+				t.Errorf("restored note should only link back to study, got %v", restored.Links)
+			}
+
+			if err := backend.Delete(study.Id, "alice", kp); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+			if after, _ := backend.Load(claim.Id, "alice", kp); after.HasLink(study.Id) {
+				t.Error("delete should remove the links to the deleted note")
+			}
+		})
+	}
+}